@@ -8,6 +8,9 @@ type Assignment struct {
 	core.Expression
 	Identifier string
 	Value      core.Expression
+
+	// Pos is the position of the identifier
+	Pos Pos
 }
 
 func (a *Assignment) String() string {
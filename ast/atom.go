@@ -11,6 +11,10 @@ type Atom struct {
 	Value   string
 	Negated bool
 	Signed bool
+	Complemented bool
+
+	// Pos is the position of the atom
+	Pos Pos
 }
 
 func (a *Atom) String() string {
@@ -12,6 +12,7 @@ type Binary struct {
 	Operator lexer.Token
 	Negated  bool
 	Signed  bool
+	Complemented bool
 }
 
 func (b *Binary) String() string {
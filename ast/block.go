@@ -5,6 +5,15 @@ import "github.com/amupitan/hero/ast/core"
 type Block struct {
 	core.Statement
 	Statements []core.Statement
+
+	// Pos is the position of the left brace of the block or of
+	// the case or default keyword starting the body of a case.
+	// It is unknown for the body of a program
+	Pos Pos
+
+	// End is the position of the right brace of the block.
+	// It is unknown for the body of a program
+	End Pos
 }
 
 func (b *Block) String() string {
@@ -0,0 +1,18 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Break represents a break statement. Label is the name of the
+// loop being broken out of if it is named. An unlabeled break
+// ends the innermost loop or switch
+type Break struct {
+	core.Statement
+	Label string
+}
+
+func (b *Break) String() string {
+	if b.Label != `` {
+		return `break ` + b.Label
+	}
+	return `break`
+}
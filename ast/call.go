@@ -4,34 +4,61 @@ import (
 	"strings"
 
 	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
 )
 
+// Call represents a function call. A call is one of:
+//
+//	a named call e.g. print(x) which sets Name
+//	a lambda call e.g. func(x int) {}(1) which sets Func
+//	a call on any other expression e.g. obj.print(x) or
+//	makeAdder(1)(2) which sets Callee
 type Call struct {
 	core.Expression
-	Name    string // TODO: take in complete token?
-	Args    []core.Expression
-	Object  string
-	Func    *Function
-	Negated bool
-	Signed bool
+	Name         string // TODO: take in complete token?
+	Args         []core.Expression
+	Callee       core.Expression
+	Func         *Function
+	Negated      bool
+	Signed       bool
+	Complemented bool
+
+	// Named holds the arguments passed by name. They
+	// follow the positional arguments in Args
+	Named []*NamedArg
+
+	// Spread is true if the final argument is a list
+	// spread into a variadic parameter e.g. sum(xs...)
+	Spread bool
+
+	// Token locates the call. It is the name of a named
+	// call or the left parenthesis of any other call
+	Token lexer.Token
 }
 
 func (c *Call) String() string {
 	s := strings.Builder{}
-	// named call
 	if c.Name != `` {
-		if c.Object != `` {
-			s.WriteString(c.Object)
-			s.WriteRune('.')
-		}
+		// named call
 		s.WriteString(c.Name)
-	} else {
+	} else if c.Func != nil {
 		// lambda call
 		s.WriteString(c.Func.String())
+	} else {
+		s.WriteString(c.Callee.String())
 	}
 
 	s.WriteRune('(')
 	s.WriteString(core.StringifyExpressions(c.Args))
+	if c.Spread {
+		s.WriteString(`...`)
+	}
+	for i, arg := range c.Named {
+		if i > 0 || len(c.Args) > 0 {
+			s.WriteString(`, `)
+		}
+		s.WriteString(arg.String())
+	}
 	s.WriteRune(')')
 
 	return s.String()
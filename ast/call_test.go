@@ -14,8 +14,7 @@ func TestCall_String(t *testing.T) {
 			&Atom{Type: lexer.Identifier, Value: `foo`},
 			&Atom{Type: lexer.Bool, Value: `true`},
 		},
-		Name:   `print`,
-		Object: `obj`,
+		Callee: &Selector{Object: &Atom{Type: lexer.Identifier, Value: `obj`}, Name: `print`},
 	}
 
 	expects := `obj.print(foo, true)`
@@ -36,4 +35,15 @@ func TestCall_String(t *testing.T) {
 	if got := c.String(); got != expects {
 		t.Errorf("Param.String() = %s, Expected: %s", got, expects)
 	}
+
+	// test call on a call
+	c = &Call{
+		Args:   []core.Expression{&Atom{Type: lexer.Int, Value: `2`}},
+		Callee: &Call{Name: `makeAdder`, Args: []core.Expression{&Atom{Type: lexer.Int, Value: `1`}}},
+	}
+
+	expects = `makeAdder(1)(2)`
+	if got := c.String(); got != expects {
+		t.Errorf("Param.String() = %s, Expected: %s", got, expects)
+	}
 }
@@ -0,0 +1,16 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Conditional is an expression whose value is Then if its
+// Condition is true or Else otherwise e.g. x > 0 ? x : -x
+type Conditional struct {
+	core.Expression
+	Condition core.Expression
+	Then      core.Expression
+	Else      core.Expression
+}
+
+func (c *Conditional) String() string {
+	return c.Condition.String() + ` ? ` + c.Then.String() + ` : ` + c.Else.String()
+}
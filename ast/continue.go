@@ -0,0 +1,17 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Continue represents a continue statement. Label is the
+// name of the loop being continued if it is named
+type Continue struct {
+	core.Statement
+	Label string
+}
+
+func (c *Continue) String() string {
+	if c.Label != `` {
+		return `continue ` + c.Label
+	}
+	return `continue`
+}
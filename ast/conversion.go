@@ -0,0 +1,23 @@
+package ast
+
+import (
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/types"
+)
+
+// Conversion converts a value to a builtin type e.g. int("42").
+// Token is the type name and locates failed conversions
+type Conversion struct {
+	core.Expression
+	Type         types.Type
+	Value        core.Expression
+	Token        lexer.Token
+	Negated      bool
+	Signed       bool
+	Complemented bool
+}
+
+func (c *Conversion) String() string {
+	return c.Type.String() + `(` + c.Value.String() + `)`
+}
@@ -0,0 +1,15 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Defer delays a call till the function it is in returns
+// e.g. defer close(f). The callee and arguments are evaluated
+// when the defer statement is executed
+type Defer struct {
+	core.Statement
+	Call *Call
+}
+
+func (d *Defer) String() string {
+	return `defer ` + d.Call.String()
+}
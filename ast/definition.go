@@ -20,6 +20,10 @@ type Definition struct {
 	Name  string
 	Value core.Expression
 	Type  string // TODO use lexer or custom ast type for type
+
+	// Pos is the position of the name. It is the position
+	// of the func keyword of a lambda
+	Pos Pos
 }
 
 func (d *Definition) String() string {
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/amupitan/hero/ast/core"
@@ -12,25 +13,165 @@ type funcBody struct{}
 type Param struct {
 	Name string
 	Type types.Type
+
+	// Variadic is true if the parameter takes zero or more trailing
+	// arguments e.g. nums ...int. Only the final parameter can be variadic
+	Variadic bool
+
+	// Default is the value of the parameter when no argument
+	// is passed for it e.g. port int = 8080. It is nil if an
+	// argument is required
+	Default core.Expression
+
+	// Pos is the position of the name of the parameter
+	Pos Pos
 }
 
 type Function struct {
 	core.Expression
 	Definition
+	TypeParams  []*types.TypeParam
 	Parameters  []*Param
 	Lambda      bool
 	ReturnTypes []types.Type
 	Body        *Block
 	Owner       types.Type
 	Private     bool
+
+	// Captures holds the free variables of the function i.e. the
+	// variables it uses from enclosing scopes. It is set by the
+	// resolver and is nil if the function hasn't been resolved
+	Captures []string
 }
 
 func (p Param) String() string {
+	if p.Variadic {
+		return p.Name + ` ...` + p.Type.String()
+	}
+	if p.Default != nil {
+		return p.Name + ` ` + p.Type.String() + ` = ` + p.Default.String()
+	}
 	return p.Name + ` ` + p.Type.String()
 }
 
+// FunctionOf returns a function without a body named [name] with
+// the parameters and return types of [s]. It describes a function
+// implemented outside hero so calls to it can be bound and checked
+// like calls to functions written in hero
+func FunctionOf(name string, s *types.Signature) *Function {
+	f := &Function{
+		Definition:  Definition{Name: name, Type: types.Func.String()},
+		TypeParams:  s.TypeParams,
+		Parameters:  make([]*Param, 0, len(s.Params)),
+		ReturnTypes: s.Returns,
+	}
+	for i, p := range s.Params {
+		f.Parameters = append(f.Parameters, &Param{
+			Name:     p.Name,
+			Type:     p.Type,
+			Variadic: s.Variadic && i == len(s.Params)-1,
+		})
+	}
+	if f.ReturnTypes == nil {
+		f.ReturnTypes = []types.Type{}
+	}
+	return f
+}
+
+// DisplayName returns the name of the function for messages
+// e.g. errors. Lambdas are named lambda
+func (f *Function) DisplayName() string {
+	if f.Lambda {
+		return `lambda`
+	}
+	return f.Name
+}
+
+// IsVariadic returns true if the final parameter of the function is variadic
+func (f *Function) IsVariadic() bool {
+	return len(f.Parameters) > 0 && f.Parameters[len(f.Parameters)-1].Variadic
+}
+
+// BindArgs matches the arguments of a call to the parameters of the
+// function. The call passes [positional] arguments followed by the
+// arguments named [names]; arguments are indexed in that order. If
+// [spread] is set the final positional argument is a list spread into
+// the variadic parameter.
+//
+// The indexes of the arguments bound to each parameter are returned.
+// A variadic parameter is bound to any number of arguments and other
+// parameters are bound to at most one. A parameter that isn't bound
+// takes its default value. An error is returned if an argument doesn't
+// match a parameter or a parameter without a default isn't bound
+func (f *Function) BindArgs(positional int, names []string, spread bool) ([][]int, error) {
+	name := f.DisplayName()
+
+	n := len(f.Parameters)
+	fixed := n
+	if f.IsVariadic() {
+		fixed = n - 1
+	}
+	if spread && !f.IsVariadic() {
+		return nil, fmt.Errorf(`cannot use ... in call to non-variadic %s`, name)
+	}
+	if spread && positional != n {
+		return nil, fmt.Errorf(`%s expects %d arguments but received %d`, name, n, positional)
+	}
+	if positional > fixed && !f.IsVariadic() {
+		return nil, fmt.Errorf(`%s expects %d arguments but received %d`, name, n, positional)
+	}
+
+	bound := make([][]int, n)
+	for i := 0; i < positional; i++ {
+		if i < fixed {
+			bound[i] = []int{i}
+		} else {
+			bound[n-1] = append(bound[n-1], i)
+		}
+	}
+
+	for i, argName := range names {
+		index := -1
+		for j, param := range f.Parameters {
+			if param.Name == argName {
+				index = j
+				break
+			}
+		}
+
+		switch {
+		case index < 0:
+			return nil, fmt.Errorf(`unknown parameter %s in call to %s`, argName, name)
+		case f.Parameters[index].Variadic:
+			return nil, fmt.Errorf(`variadic parameter %s cannot be passed by name`, argName)
+		case bound[index] != nil:
+			return nil, fmt.Errorf(`parameter %s is passed more than once in call to %s`, argName, name)
+		}
+		bound[index] = []int{positional + i}
+	}
+
+	for i := 0; i < fixed; i++ {
+		if bound[i] == nil && f.Parameters[i].Default == nil {
+			return nil, fmt.Errorf(`missing argument for parameter %s in call to %s`, f.Parameters[i].Name, name)
+		}
+	}
+	return bound, nil
+}
+
 func (f *Function) String() string {
-	s := `func ` + f.Name + `(` + stringifyParams(f.Parameters) + `)`
+	s := `func ` + f.Name
+	if len(f.TypeParams) > 0 {
+		params := make([]string, 0, len(f.TypeParams))
+		for _, t := range f.TypeParams {
+			if t.Constraint == types.Generic {
+				params = append(params, t.Name)
+			} else {
+				params = append(params, t.Name+` `+t.Constraint.String())
+			}
+		}
+		s += `<` + strings.Join(params, `, `) + `>`
+	}
+	s += `(` + stringifyParams(f.Parameters) + `)`
 	if len(f.ReturnTypes) > 0 {
 		s += ` (` + stringifyTypes(f.ReturnTypes) + `)`
 	}
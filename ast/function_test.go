@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/amupitan/hero/types"
@@ -31,3 +32,114 @@ func TestFunction_String(t *testing.T) {
 		t.Errorf("Function.String() = %s, Expected: %s", got, expects)
 	}
 }
+
+func TestFunction_BindArgs(t *testing.T) {
+	connect := &Function{
+		Definition: Definition{Name: `connect`},
+		Parameters: []*Param{
+			&Param{Name: `host`, Type: types.String},
+			&Param{Name: `port`, Type: types.Int, Default: &Atom{Value: `8080`}},
+			&Param{Name: `tags`, Type: types.String, Variadic: true},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		positional int
+		names      []string
+		spread     bool
+		want       [][]int
+		wantErr    string
+	}{
+		{
+			name:       `required only`,
+			positional: 1,
+			want:       [][]int{{0}, nil, nil},
+		},
+		{
+			name:       `variadic arguments`,
+			positional: 4,
+			want:       [][]int{{0}, {1}, {2, 3}},
+		},
+		{
+			name:       `named argument`,
+			positional: 1,
+			names:      []string{`port`},
+			want:       [][]int{{0}, {1}, nil},
+		},
+		{
+			name:  `all named`,
+			names: []string{`port`, `host`},
+			want:  [][]int{{1}, {0}, nil},
+		},
+		{
+			name:       `spread`,
+			positional: 3,
+			spread:     true,
+			want:       [][]int{{0}, {1}, {2}},
+		},
+		{
+			name:    `missing required`,
+			names:   []string{`port`},
+			wantErr: `missing argument for parameter host in call to connect`,
+		},
+		{
+			name:       `unknown name`,
+			positional: 1,
+			names:      []string{`timeout`},
+			wantErr:    `unknown parameter timeout in call to connect`,
+		},
+		{
+			name:       `duplicate`,
+			positional: 2,
+			names:      []string{`port`},
+			wantErr:    `parameter port is passed more than once in call to connect`,
+		},
+		{
+			name:       `named variadic`,
+			positional: 1,
+			names:      []string{`tags`},
+			wantErr:    `variadic parameter tags cannot be passed by name`,
+		},
+		{
+			name:       `spread without all positional arguments`,
+			positional: 2,
+			spread:     true,
+			wantErr:    `connect expects 3 arguments but received 2`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := connect.BindArgs(tt.positional, tt.names, tt.spread)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Fatalf("Function.BindArgs() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != `` {
+				t.Fatalf("Function.BindArgs() error = nil, wantErr %v", tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Function.BindArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionOf(t *testing.T) {
+	sig := &types.Signature{
+		Params:   []types.Param{{Name: `sep`, Type: types.String}, {Name: `parts`, Type: types.String}},
+		Variadic: true,
+		Returns:  []types.Type{types.String},
+	}
+	f := FunctionOf(`join`, sig)
+
+	expects := `func join(sep string, parts ...string) (string) {}`
+	if got := f.String(); got != expects {
+		t.Errorf("FunctionOf() = %s, Expected: %s", got, expects)
+	}
+	if _, err := f.BindArgs(0, nil, false); err == nil {
+		t.Errorf("FunctionOf() parameters should be required")
+	}
+}
@@ -12,10 +12,15 @@ type If struct {
 	// Else represents an else clause
 	Else *If
 	Body *Block
-	// an assignment or definition in an if-block
-	Definition core.Expression //TODO(DEV) use this?
+	// Definition is a statement run before the condition e.g.
+	// x := f() in if x := f(); x > 0 {}. Variables it defines
+	// are visible in the if statement and its else clauses
+	Definition core.Statement
 }
 
 func (i *If) String() string {
+	if i.Definition != nil {
+		return `if ` + i.Definition.String() + `; ` + i.Condition.String() + `{}`
+	}
 	return `if ` + i.Condition.String() + `{}`
 }
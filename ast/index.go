@@ -0,0 +1,17 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Index represents an index into a list, map or string e.g. list[0]
+type Index struct {
+	core.Expression
+	Object       core.Expression
+	Index        core.Expression
+	Negated      bool
+	Signed       bool
+	Complemented bool
+}
+
+func (i *Index) String() string {
+	return i.Object.String() + `[` + i.Index.String() + `]`
+}
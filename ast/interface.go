@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/types"
+)
+
+// Interface declares a constraint for type parameters that is
+// satisfied by the types it lists e.g. interface Number { int | float }
+type Interface struct {
+	core.Statement
+	Name  string
+	Types []types.Type
+
+	// Pos is the position of the name
+	Pos Pos
+}
+
+func (i *Interface) String() string {
+	names := make([]string, 0, len(i.Types))
+	for _, t := range i.Types {
+		names = append(names, t.String())
+	}
+	return `interface ` + i.Name + ` { ` + strings.Join(names, ` | `) + ` }`
+}
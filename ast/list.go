@@ -0,0 +1,16 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// List represents a list literal e.g. [1, 2, 3]
+type List struct {
+	core.Expression
+	Elements []core.Expression
+
+	// Pos is the position of the opening bracket
+	Pos Pos
+}
+
+func (l *List) String() string {
+	return `[` + core.StringifyExpressions(l.Elements) + `]`
+}
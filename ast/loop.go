@@ -2,6 +2,7 @@ package ast
 
 import (
 	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
 )
 
 type Loop interface {
@@ -30,6 +31,9 @@ type ForLoop struct {
 
 	// Body is a block for body of the loop
 	Body *Block
+
+	// Token is the for token of the loop
+	Token lexer.Token
 }
 
 func (l *ForLoop) String() string {
@@ -43,23 +47,32 @@ type RangeLoop struct {
 	// The name of the loop if it is named
 	Name string
 
-	// First represents the first identifier in a range for loop
+	// First represents the first identifier in a range for loop.
+	// It is `_` if the value is discarded
 	First string
 
-	// Second represents the first identifier in a range for loop
+	// Second represents the second identifier in a range for loop.
+	// It is empty if only one identifier was given or `_` if
+	// the value is discarded
 	Second string
 
-	// Iterable represnets the iterable in for-range loops
-	// TODO(DEV) use different type to accommodate for list
-	// and map literals
-	Iterable string
+	// FirstPos and SecondPos are the positions of
+	// the identifiers if they are given
+	FirstPos, SecondPos Pos
+
+	// Iterable represents the expression being iterated over
+	// e.g. an identifier, a call or a list, map or string literal
+	Iterable core.Expression
 
 	// Body is a block for body of the loop
 	Body *Block
+
+	// Token is the for token of the loop
+	Token lexer.Token
 }
 
 func (r *RangeLoop) String() string {
-	return `for ` + r.First + `, ` + r.Second + ` in ` + r.Iterable + ` {}`
+	return `for ` + r.First + `, ` + r.Second + ` in ` + r.Iterable.String() + ` {}`
 }
 
 func (l *RangeLoop) evaluate() {}
@@ -0,0 +1,40 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/amupitan/hero/ast/core"
+)
+
+// Map represents a map literal e.g. ["one": 1, "two": 2]
+// Keys and Values are parallel slices in source order
+type Map struct {
+	core.Expression
+	Keys   []core.Expression
+	Values []core.Expression
+
+	// Pos is the position of the opening bracket
+	Pos Pos
+}
+
+func (m *Map) String() string {
+	if len(m.Keys) == 0 {
+		return `[:]`
+	}
+
+	s := strings.Builder{}
+	s.WriteRune('[')
+	for i := range m.Keys {
+		s.WriteString(m.Keys[i].String())
+		s.WriteString(`: `)
+		s.WriteString(m.Values[i].String())
+
+		// write comma if not last entry
+		if i+1 < len(m.Keys) {
+			s.WriteString(`, `)
+		}
+	}
+	s.WriteRune(']')
+
+	return s.String()
+}
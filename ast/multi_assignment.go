@@ -0,0 +1,32 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/amupitan/hero/ast/core"
+)
+
+// MultiAssignment represents the assignment or definition
+// of multiple identifiers e.g. q, r := divmod(7, 2) or a, b = b, a.
+// Values holds either one value per identifier or a single call
+// that returns a value for each identifier. An identifier is `_`
+// if its value is discarded
+type MultiAssignment struct {
+	core.Expression
+	Identifiers []string
+	Values      []core.Expression
+
+	// Define is true if the identifiers are being defined with :=
+	Define bool
+
+	// Positions holds the positions of the identifiers
+	Positions []Pos
+}
+
+func (m *MultiAssignment) String() string {
+	op := ` = `
+	if m.Define {
+		op = ` := `
+	}
+	return strings.Join(m.Identifiers, `, `) + op + core.StringifyExpressions(m.Values)
+}
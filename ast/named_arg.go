@@ -0,0 +1,15 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// NamedArg is an argument passed to a parameter
+// by name at a call site e.g. port: 9000
+type NamedArg struct {
+	core.Expression
+	Name  string
+	Value core.Expression
+}
+
+func (n *NamedArg) String() string {
+	return n.Name + `: ` + n.Value.String()
+}
@@ -0,0 +1,108 @@
+package ast
+
+import (
+	"strconv"
+
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
+)
+
+// Pos is a position in the source of a program. Lines and columns
+// start at 1 and the zero Pos is an unknown position
+type Pos struct {
+	Line, Column int
+}
+
+// PosOf returns the position of the token [t]
+func PosOf(t lexer.Token) Pos {
+	return Pos{Line: t.Line, Column: t.Column}
+}
+
+// IsValid returns true if the position is known
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Pos) String() string {
+	return strconv.Itoa(p.Line) + `:` + strconv.Itoa(p.Column)
+}
+
+// Before returns true if [p] is before [q] in the source
+func (p Pos) Before(q Pos) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Column < q.Column)
+}
+
+// Start returns the position where the node [n] starts if it is
+// known. Nodes whose first token isn't recorded e.g. a return
+// statement start at the earliest of their parts that is known
+func Start(n core.Node) Pos {
+	switch n := n.(type) {
+	case *Atom:
+		return n.Pos
+	case *Definition:
+		return n.Pos
+	case *Function:
+		return n.Pos
+	case *Param:
+		return n.Pos
+	case *Assignment:
+		return n.Pos
+	case *MultiAssignment:
+		if len(n.Positions) > 0 {
+			return n.Positions[0]
+		}
+	case *Call:
+		switch {
+		case n.Func != nil:
+			return n.Func.Pos
+		case n.Callee != nil:
+			return Start(n.Callee)
+		}
+		return PosOf(n.Token)
+	case *Binary:
+		if pos := Start(n.Left); pos.IsValid() {
+			return pos
+		}
+		return PosOf(n.Operator)
+	case *Conversion:
+		return PosOf(n.Token)
+	case *Selector:
+		return Start(n.Object)
+	case *Index:
+		return Start(n.Object)
+	case *Conditional:
+		return Start(n.Condition)
+	case *List:
+		return n.Pos
+	case *Map:
+		return n.Pos
+	case *Range:
+		return Start(n.Start)
+	case *ForLoop:
+		return PosOf(n.Token)
+	case *RangeLoop:
+		return PosOf(n.Token)
+	case *If:
+		if n.Definition != nil {
+			return Start(n.Definition)
+		}
+		if n.Condition != nil {
+			return Start(n.Condition)
+		}
+	case *Block:
+		return n.Pos
+	case *Switch:
+		return Start(n.Value)
+	case *Return:
+		if len(n.Values) > 0 {
+			return Start(n.Values[0])
+		}
+	case *Throw:
+		return PosOf(n.Token)
+	case *Defer:
+		return Start(n.Call)
+	case *Interface:
+		return n.Pos
+	}
+	return Pos{}
+}
@@ -0,0 +1,15 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Range is a case value that matches the values from
+// Start to End including both e.g. 'a'..'z'
+type Range struct {
+	core.Expression
+	Start core.Expression
+	End   core.Expression
+}
+
+func (r *Range) String() string {
+	return r.Start.String() + `..` + r.End.String()
+}
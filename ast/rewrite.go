@@ -0,0 +1,165 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Rewrite replaces each node in [node] with the node [f] returns for
+// it and returns the node [f] returns for [node]. The nodes in a node
+// are rewritten before it and [node] is changed in place. [f] returns
+// the node it is given to keep it and statements it returns nil for
+// are removed from their blocks. Rewrite panics if [f] replaces a node
+// with one its parent can't hold e.g. the body of a loop with an
+// expression rather than a *Block
+func Rewrite(node core.Node, f func(core.Node) core.Node) core.Node {
+	r := &rewriter{f: f}
+	return r.rewrite(node)
+}
+
+type rewriter struct {
+	f func(core.Node) core.Node
+}
+
+// rewrite rewrites the nodes in [node] and then [node]
+func (r *rewriter) rewrite(node core.Node) core.Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Body = r.block(n.Body)
+	case *Block:
+		statements := n.Statements[:0]
+		for _, s := range n.Statements {
+			if s = r.statement(s); s != nil {
+				statements = append(statements, s)
+			}
+		}
+		n.Statements = statements
+	case *Function:
+		for i, param := range n.Parameters {
+			n.Parameters[i] = r.rewrite(param).(*Param)
+		}
+		n.Body = r.block(n.Body)
+	case *Param:
+		n.Default = r.expression(n.Default)
+	case *Definition:
+		n.Value = r.expression(n.Value)
+	case *MultiAssignment:
+		r.expressions(n.Values)
+	case *Assignment:
+		n.Value = r.expression(n.Value)
+	case *If:
+		n.Definition = r.statement(n.Definition)
+		n.Condition = r.expression(n.Condition)
+		n.Body = r.block(n.Body)
+		if n.Else != nil {
+			if rewritten := r.rewrite(n.Else); rewritten != nil {
+				n.Else = rewritten.(*If)
+			} else {
+				n.Else = nil
+			}
+		}
+	case *Switch:
+		n.Value = r.expression(n.Value)
+		for i, c := range n.Cases {
+			n.Cases[i] = r.rewrite(c).(*Case)
+		}
+		n.Default = r.block(n.Default)
+	case *Case:
+		r.expressions(n.Values)
+		n.Body = r.block(n.Body)
+	case *ForLoop:
+		n.PreLoop = r.expression(n.PreLoop)
+		n.Condition = r.expression(n.Condition)
+		n.PostIteration = r.expression(n.PostIteration)
+		n.Body = r.block(n.Body)
+	case *RangeLoop:
+		n.Iterable = r.expression(n.Iterable)
+		n.Body = r.block(n.Body)
+	case *Try:
+		n.Body = r.block(n.Body)
+		n.Catch = r.block(n.Catch)
+	case *Throw:
+		n.Value = r.expression(n.Value)
+	case *Defer:
+		if n.Call != nil {
+			n.Call = r.rewrite(n.Call).(*Call)
+		}
+	case *Return:
+		r.expressions(n.Values)
+	case *Binary:
+		n.Left = r.expression(n.Left)
+		n.Right = r.expression(n.Right)
+	case *Conditional:
+		n.Condition = r.expression(n.Condition)
+		n.Then = r.expression(n.Then)
+		n.Else = r.expression(n.Else)
+	case *Operation:
+		n.Value = r.expression(n.Value)
+	case *Call:
+		switch {
+		case n.Func != nil:
+			n.Func = r.rewrite(n.Func).(*Function)
+		case n.Callee != nil:
+			n.Callee = r.expression(n.Callee)
+		}
+		r.expressions(n.Args)
+		for i, arg := range n.Named {
+			n.Named[i] = r.rewrite(arg).(*NamedArg)
+		}
+	case *NamedArg:
+		n.Value = r.expression(n.Value)
+	case *Conversion:
+		n.Value = r.expression(n.Value)
+	case *Range:
+		n.Start = r.expression(n.Start)
+		n.End = r.expression(n.End)
+	case *List:
+		r.expressions(n.Elements)
+	case *Map:
+		r.expressions(n.Keys)
+		r.expressions(n.Values)
+	case *Selector:
+		n.Object = r.expression(n.Object)
+	case *Index:
+		n.Object = r.expression(n.Object)
+		n.Index = r.expression(n.Index)
+	}
+	return r.f(node)
+}
+
+// block rewrites a block that can be nil
+func (r *rewriter) block(b *Block) *Block {
+	if b == nil {
+		return nil
+	}
+	if rewritten := r.rewrite(b); rewritten != nil {
+		return rewritten.(*Block)
+	}
+	return nil
+}
+
+// statement rewrites a statement that can be nil
+func (r *rewriter) statement(s core.Statement) core.Statement {
+	if s == nil {
+		return nil
+	}
+	if rewritten := r.rewrite(s); rewritten != nil {
+		return rewritten.(core.Statement)
+	}
+	return nil
+}
+
+// expression rewrites an expression that can be nil
+func (r *rewriter) expression(e core.Expression) core.Expression {
+	if e == nil {
+		return nil
+	}
+	if rewritten := r.rewrite(e); rewritten != nil {
+		return rewritten.(core.Expression)
+	}
+	return nil
+}
+
+// expressions rewrites the expressions in [exps] in place
+func (r *rewriter) expressions(exps []core.Expression) {
+	for i, e := range exps {
+		exps[i] = r.expression(e)
+	}
+}
@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
+)
+
+func TestRewrite(t *testing.T) {
+	x := &Atom{Type: lexer.Identifier, Value: `x`}
+	plus := lexer.Token{Type: lexer.Plus, Value: `+`}
+	num := func(n int) *Atom { return &Atom{Type: lexer.Int, Value: strconv.Itoa(n)} }
+	program := &Program{Body: &Block{Statements: []core.Statement{
+		&Definition{Name: `x`, Value: &Binary{Left: num(1), Operator: plus, Right: &Binary{Left: num(2), Operator: plus, Right: num(3)}}},
+		&If{Condition: x, Body: &Block{Statements: []core.Statement{&Throw{Value: x}}}, Else: &If{Body: &Block{}}},
+		&Call{Name: `print`, Args: []core.Expression{&Binary{Left: x, Operator: plus, Right: num(4)}}},
+	}}}
+
+	// sums of ints are folded, throws are removed and
+	// empty else clauses are dropped
+	got := Rewrite(program, func(n core.Node) core.Node {
+		switch n := n.(type) {
+		case *Binary:
+			left, lok := n.Left.(*Atom)
+			right, rok := n.Right.(*Atom)
+			if lok && rok && left.Type == lexer.Int && right.Type == lexer.Int {
+				a, _ := strconv.Atoi(left.Value)
+				b, _ := strconv.Atoi(right.Value)
+				return num(a + b)
+			}
+		case *Throw:
+			return nil
+		case *If:
+			if n.Condition == nil && len(n.Body.Statements) == 0 {
+				return nil
+			}
+		}
+		return n
+	})
+
+	want := `program: { var x  = 6, if x{}, print((x+4))}`
+	if got != program || got.(*Program).String() != want {
+		t.Errorf("Rewrite() = %v, want %s", got, want)
+	}
+	if f := program.Body.Statements[1].(*If); len(f.Body.Statements) != 0 || f.Else != nil {
+		t.Errorf("Rewrite() didn't remove the throw and the else clause")
+	}
+}
+
+func TestRewrite_invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Rewrite() didn't panic replacing a body with an expression")
+		}
+	}()
+	loop := &ForLoop{Body: &Block{}}
+	Rewrite(loop, func(n core.Node) core.Node {
+		if _, ok := n.(*Block); ok {
+			return &Atom{Type: lexer.Int, Value: `1`}
+		}
+		return n
+	})
+}
@@ -0,0 +1,17 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Selector represents a field access on an object e.g. cfg.entries
+type Selector struct {
+	core.Expression
+	Object       core.Expression
+	Name         string
+	Negated      bool
+	Signed       bool
+	Complemented bool
+}
+
+func (s *Selector) String() string {
+	return s.Object.String() + `.` + s.Name
+}
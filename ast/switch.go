@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/amupitan/hero/ast/core"
+)
+
+// Switch runs the body of the first case that matches its Value or
+// the Default body if no case matches. A break inside a case refers
+// to the loop enclosing the switch
+type Switch struct {
+	core.Statement
+	Value   core.Expression
+	Cases   []*Case
+	Default *Block
+}
+
+// Case matches a value equal to one of its Values. A value can
+// be a Range which matches the values between its bounds
+type Case struct {
+	Values []core.Expression
+	Body   *Block
+}
+
+func (s *Switch) String() string {
+	str := strings.Builder{}
+	str.WriteString(`switch ` + s.Value.String() + ` {`)
+	for _, c := range s.Cases {
+		str.WriteString(` case ` + core.StringifyExpressions(c.Values) + `: ` + c.Body.String())
+	}
+	if s.Default != nil {
+		str.WriteString(` default: ` + s.Default.String())
+	}
+	str.WriteString(` }`)
+	return str.String()
+}
@@ -0,0 +1,18 @@
+package ast
+
+import (
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
+)
+
+// Throw throws an error. Its Value is an error or a string used as
+// the message of a new error. Token locates the throw statement
+type Throw struct {
+	core.Statement
+	Value core.Expression
+	Token lexer.Token
+}
+
+func (t *Throw) String() string {
+	return `throw ` + t.Value.String()
+}
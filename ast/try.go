@@ -0,0 +1,24 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Try runs its Body and runs Catch if an error is thrown while
+// running it. The error is bound to Name in Catch if it is set
+// e.g. try { ... } catch err { ... }
+type Try struct {
+	core.Statement
+	Body  *Block
+	Name  string
+	Catch *Block
+
+	// NamePos is the position of Name if it is set
+	NamePos Pos
+}
+
+func (t *Try) String() string {
+	s := `try ` + t.Body.String() + ` catch `
+	if t.Name != `` {
+		s += t.Name + ` `
+	}
+	return s + t.Catch.String()
+}
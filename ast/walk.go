@@ -0,0 +1,163 @@
+package ast
+
+import "github.com/amupitan/hero/ast/core"
+
+// Visitor visits the nodes walked by Walk
+type Visitor interface {
+	// Visit is called on each node walked. The nodes in the node are
+	// walked with the visitor it returns, followed by a call to its
+	// Visit with nil. They aren't walked if it returns nil
+	Visit(node core.Node) Visitor
+}
+
+// Walk walks [node] and the nodes in it in the order they appear in
+// the source, starting with a call to v.Visit(node). Every node type
+// is walked into including the parameters and body of a function, the
+// else clauses of an if statement which are *If, the cases of a switch
+// which are *Case, the parts of a for loop and lambdas that are called
+func Walk(v Visitor, node core.Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	walk := func(nodes ...core.Node) {
+		for _, n := range nodes {
+			if n != nil {
+				Walk(v, n)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		if n.Body != nil {
+			walk(n.Body)
+		}
+	case *Block:
+		for _, s := range n.Statements {
+			walk(s)
+		}
+	case *Function:
+		for _, param := range n.Parameters {
+			walk(param)
+		}
+		if n.Body != nil {
+			walk(n.Body)
+		}
+	case *Param:
+		walk(n.Default)
+	case *Definition:
+		walk(n.Value)
+	case *MultiAssignment:
+		for _, value := range n.Values {
+			walk(value)
+		}
+	case *Assignment:
+		walk(n.Value)
+	case *If:
+		walk(n.Definition, n.Condition)
+		if n.Body != nil {
+			walk(n.Body)
+		}
+		if n.Else != nil {
+			walk(n.Else)
+		}
+	case *Switch:
+		walk(n.Value)
+		for _, c := range n.Cases {
+			walk(c)
+		}
+		if n.Default != nil {
+			walk(n.Default)
+		}
+	case *Case:
+		for _, value := range n.Values {
+			walk(value)
+		}
+		if n.Body != nil {
+			walk(n.Body)
+		}
+	case *ForLoop:
+		walk(n.PreLoop, n.Condition, n.PostIteration)
+		if n.Body != nil {
+			walk(n.Body)
+		}
+	case *RangeLoop:
+		walk(n.Iterable)
+		if n.Body != nil {
+			walk(n.Body)
+		}
+	case *Try:
+		if n.Body != nil {
+			walk(n.Body)
+		}
+		if n.Catch != nil {
+			walk(n.Catch)
+		}
+	case *Throw:
+		walk(n.Value)
+	case *Defer:
+		if n.Call != nil {
+			walk(n.Call)
+		}
+	case *Return:
+		for _, value := range n.Values {
+			walk(value)
+		}
+	case *Binary:
+		walk(n.Left, n.Right)
+	case *Conditional:
+		walk(n.Condition, n.Then, n.Else)
+	case *Operation:
+		walk(n.Value)
+	case *Call:
+		switch {
+		case n.Func != nil:
+			walk(n.Func)
+		case n.Callee != nil:
+			walk(n.Callee)
+		}
+		for _, arg := range n.Args {
+			walk(arg)
+		}
+		for _, arg := range n.Named {
+			walk(arg)
+		}
+	case *NamedArg:
+		walk(n.Value)
+	case *Conversion:
+		walk(n.Value)
+	case *Range:
+		walk(n.Start, n.End)
+	case *List:
+		for _, el := range n.Elements {
+			walk(el)
+		}
+	case *Map:
+		for i := range n.Keys {
+			walk(n.Keys[i], n.Values[i])
+		}
+	case *Selector:
+		walk(n.Object)
+	case *Index:
+		walk(n.Object, n.Index)
+	}
+
+	v.Visit(nil)
+}
+
+// inspector is a Visitor calling a function on each node
+type inspector func(core.Node) bool
+
+func (f inspector) Visit(node core.Node) Visitor {
+	if node != nil && f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect walks [node] like Walk and calls [f] on each node. The
+// nodes in a node aren't visited if [f] returns false for it
+func Inspect(node core.Node, f func(core.Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,95 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/lexer"
+)
+
+func TestInspect(t *testing.T) {
+	x := &Atom{Type: lexer.Identifier, Value: `x`}
+	one := &Atom{Type: lexer.Int, Value: `1`}
+	call := &Call{Name: `print`, Args: []core.Expression{x}}
+	f := &Function{
+		Definition: Definition{Name: `f`},
+		Parameters: []*Param{{Name: `x`, Default: one}},
+		Body: &Block{Statements: []core.Statement{
+			&If{
+				Condition: &Binary{Left: x, Right: one, Operator: lexer.Token{Type: lexer.GreaterThan, Value: `>`}},
+				Body:      &Block{Statements: []core.Statement{call}},
+				Else:      &If{Body: &Block{}},
+			},
+			&Return{},
+		}},
+	}
+
+	var got []string
+	Inspect(&Program{Body: &Block{Statements: []core.Statement{f}}}, func(n core.Node) bool {
+		got = append(got, fmt.Sprintf("%T", n))
+		// the arguments of calls aren't visited
+		_, ok := n.(*Call)
+		return !ok
+	})
+
+	want := []string{
+		`*ast.Program`, `*ast.Block`, `*ast.Function`, `*ast.Param`, `*ast.Atom`, `*ast.Block`,
+		`*ast.If`, `*ast.Binary`, `*ast.Atom`, `*ast.Atom`, `*ast.Block`, `*ast.Call`,
+		`*ast.If`, `*ast.Block`, `*ast.Return`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Inspect() visited %v, want %v", got, want)
+	}
+}
+
+// recorder records the nodes it visits and the
+// ends of the nodes with the nodes in them
+type recorder struct {
+	visits *[]string
+}
+
+func (r recorder) Visit(n core.Node) Visitor {
+	if n == nil {
+		*r.visits = append(*r.visits, `end`)
+		return nil
+	}
+	*r.visits = append(*r.visits, fmt.Sprintf("%T", n))
+	return r
+}
+
+func TestWalk(t *testing.T) {
+	i := &Atom{Type: lexer.Identifier, Value: `i`}
+	lambda := &Function{Lambda: true, Body: &Block{Statements: []core.Statement{&Return{Values: []core.Expression{i}}}}}
+	loop := &ForLoop{
+		PreLoop:       &Definition{Name: `i`, Value: &Atom{Type: lexer.Int, Value: `0`}},
+		Condition:     i,
+		PostIteration: &Operation{Type: lexer.Increment},
+		Body: &Block{Statements: []core.Statement{
+			&If{Condition: i, Body: &Block{}, Else: &If{Condition: i, Body: &Block{}, Else: &If{Body: &Block{}}}},
+			&Call{Func: lambda},
+		}},
+	}
+
+	var got []string
+	Walk(recorder{&got}, loop)
+	want := []string{
+		`*ast.ForLoop`,
+		`*ast.Definition`, `*ast.Atom`, `end`, `end`,
+		`*ast.Atom`, `end`,
+		`*ast.Operation`, `end`,
+		`*ast.Block`,
+		`*ast.If`, `*ast.Atom`, `end`, `*ast.Block`, `end`,
+		`*ast.If`, `*ast.Atom`, `end`, `*ast.Block`, `end`,
+		`*ast.If`, `*ast.Block`, `end`, `end`,
+		`end`,
+		`end`,
+		`*ast.Call`, `*ast.Function`, `*ast.Block`, `*ast.Return`, `*ast.Atom`, `end`, `end`, `end`, `end`, `end`,
+		`end`,
+		`end`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+// Package astjson converts the nodes of hero programs to JSON and back
+// for tools outside Go e.g. playgrounds and visualizers. A node is an
+// object whose "kind" names its type followed by its fields in the
+// order they are declared, named like the Go fields with a lower case
+// first letter e.g.
+//
+//	{"kind":"atom","type":"int","value":"1","pos":{"line":1,"column":6}}
+//
+// Fields with zero values are left out but empty lists aren't so a
+// decoded node is deeply equal to the node that was encoded. The fields
+// of the Definition embedded in a function are fields of the function.
+// Types are objects with a kind too e.g. {"kind":"builtin","name":"int"}
+// and {"kind":"list","elem":{"kind":"custom","name":"Number"}}
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+)
+
+// nodeTypes holds the types of the nodes by their kinds
+var nodeTypes = map[string]reflect.Type{
+	`program`:         reflect.TypeOf(ast.Program{}),
+	`block`:           reflect.TypeOf(ast.Block{}),
+	`function`:        reflect.TypeOf(ast.Function{}),
+	`param`:           reflect.TypeOf(ast.Param{}),
+	`definition`:      reflect.TypeOf(ast.Definition{}),
+	`multiAssignment`: reflect.TypeOf(ast.MultiAssignment{}),
+	`assignment`:      reflect.TypeOf(ast.Assignment{}),
+	`if`:              reflect.TypeOf(ast.If{}),
+	`switch`:          reflect.TypeOf(ast.Switch{}),
+	`case`:            reflect.TypeOf(ast.Case{}),
+	`forLoop`:         reflect.TypeOf(ast.ForLoop{}),
+	`rangeLoop`:       reflect.TypeOf(ast.RangeLoop{}),
+	`try`:             reflect.TypeOf(ast.Try{}),
+	`throw`:           reflect.TypeOf(ast.Throw{}),
+	`defer`:           reflect.TypeOf(ast.Defer{}),
+	`return`:          reflect.TypeOf(ast.Return{}),
+	`break`:           reflect.TypeOf(ast.Break{}),
+	`continue`:        reflect.TypeOf(ast.Continue{}),
+	`interface`:       reflect.TypeOf(ast.Interface{}),
+	`binary`:          reflect.TypeOf(ast.Binary{}),
+	`conditional`:     reflect.TypeOf(ast.Conditional{}),
+	`operation`:       reflect.TypeOf(ast.Operation{}),
+	`call`:            reflect.TypeOf(ast.Call{}),
+	`namedArg`:        reflect.TypeOf(ast.NamedArg{}),
+	`conversion`:      reflect.TypeOf(ast.Conversion{}),
+	`range`:           reflect.TypeOf(ast.Range{}),
+	`list`:            reflect.TypeOf(ast.List{}),
+	`map`:             reflect.TypeOf(ast.Map{}),
+	`selector`:        reflect.TypeOf(ast.Selector{}),
+	`index`:           reflect.TypeOf(ast.Index{}),
+	`atom`:            reflect.TypeOf(ast.Atom{}),
+	`value`:           reflect.TypeOf(ast.Value{}),
+}
+
+// kinds holds the kinds of the nodes by their types
+var kinds = map[reflect.Type]string{}
+
+func init() {
+	for kind, t := range nodeTypes {
+		kinds[t] = kind
+	}
+}
+
+// Marshal returns the JSON encoding of [node] and the nodes in it.
+// An error is returned if it holds a node or type that can't be encoded
+func Marshal(node core.Node) ([]byte, error) {
+	v, _, err := encode(reflect.ValueOf(node))
+	if err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	if err := write(b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal returns the node encoded in [data] by Marshal. The type
+// parameters of a function are shared by the types referring to them
+// as they are in parsed programs
+func Unmarshal(data []byte) (core.Node, error) {
+	d := &decoder{}
+	v := reflect.New(reflect.TypeOf((*core.Node)(nil)).Elem()).Elem()
+	if err := d.decode(v, data); err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// field is a field of an object
+type field struct {
+	name  string
+	value interface{}
+}
+
+// object is a JSON object whose fields are kept in order
+type object []field
+
+// write writes the JSON of [v] which is an object, a list or a value
+// encoding/json can encode. Operators e.g. < aren't escaped as they
+// are by json.Marshal
+func write(b *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case object:
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := write(b, f.name); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if err := write(b, f.value); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteByte('[')
+		for i, el := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := write(b, el); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		e := json.NewEncoder(b)
+		e.SetEscapeHTML(false)
+		if err := e.Encode(v); err != nil {
+			return err
+		}
+		// remove the new line written by the encoder
+		b.Truncate(b.Len() - 1)
+	}
+	return nil
+}
+
+// fieldName returns the name of the Go field [name] in JSON
+func fieldName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
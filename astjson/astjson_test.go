@@ -0,0 +1,195 @@
+package astjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/checker"
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/types"
+)
+
+// parse returns the program parsed from [source]
+func parse(t *testing.T, source string) *ast.Program {
+	t.Helper()
+	file := parser.ParseFile(source)
+	if err := file.Err(); err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	return file.Program()
+}
+
+const program = `interface Number { int | float }
+func sum<T Number>(nums ...T) T {
+	var total T
+	for n in nums {
+		total += n
+	}
+	return total
+}
+func pick<K comparable, V>(m map[K]V, key K, fallback V = null) V? {
+	return m[key] ?? fallback
+}
+func main(args list[string], verbose bool = false) {
+	counts := ["a": 1, "b": 2]
+	x, y := 1, 2.5
+	if n := len(args); n > 0 && !verbose {
+		println("<args>", -n)
+	} else if verbose {
+		println(pick(m: counts, key: "a"))
+	} else {
+		x = ~x
+	}
+	switch x {
+	case 1, 2:
+		y = float(x)
+	case 3..5:
+		y = 0.5
+	default:
+		println(x)
+	}
+	outer:
+	for i := 0; i < 3; i++ {
+		if i > 1 {
+			break
+		}
+		continue outer
+	}
+	for i, v in args {
+		println(i, v, sum(1, 2), sum(x, int(y)))
+	}
+	try {
+		throw "failed"
+	} catch err {
+		println(err.message)
+	}
+	defer println(x > 1 ? "big" : "small")
+	func(s string) { println(s) }("lambda")
+	sum([1, 2]...)
+}`
+
+func TestMarshal_roundTrip(t *testing.T) {
+	want := parse(t, program)
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		again, _ := Marshal(got)
+		t.Fatalf("Unmarshal() = %s, want %s", again, data)
+	}
+
+	// the types in a generic function refer to its type parameters
+	sum := got.(*ast.Program).Body.Statements[1].(*ast.Function)
+	if sum.Parameters[0].Type != sum.TypeParams[0] || sum.ReturnTypes[0] != sum.TypeParams[0] {
+		t.Errorf("Unmarshal() did not share the type parameter T of sum")
+	}
+
+	wantErrs := checker.Check(want)
+	gotErrs := checker.Check(got.(*ast.Program))
+	if !reflect.DeepEqual(gotErrs, wantErrs) {
+		t.Errorf("Check() of the decoded program = %v, want %v", gotErrs, wantErrs)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(parse(t, `x := float(1)`))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"kind":"program","body":{"kind":"block","statements":[` +
+		`{"kind":"definition","name":"x","value":{"kind":"conversion",` +
+		`"type":{"kind":"builtin","name":"float"},` +
+		`"value":{"kind":"atom","type":"int","value":"1","pos":{"line":1,"column":12}},` +
+		`"token":{"type":"identifier","value":"float","line":1,"column":6}},"pos":{"line":1,"column":1}}]}}`
+	if got := string(data); got != want {
+		t.Errorf("Marshal() = %s\nwant %s", got, want)
+	}
+}
+
+func TestMarshal_types(t *testing.T) {
+	param := &types.TypeParam{Name: `T`, Constraint: types.Constraints[`ordered`]}
+	inner := &types.TypeParam{Name: `T`, Constraint: types.Generic}
+	want := &ast.Program{Body: &ast.Block{Statements: nil}}
+	want.Body.Statements = append(want.Body.Statements, &ast.Function{
+		Definition: ast.Definition{Name: `f`, Type: `func`},
+		TypeParams: []*types.TypeParam{param},
+		Parameters: []*ast.Param{{Name: `g`, Type: &types.Signature{
+			TypeParams: []*types.TypeParam{inner},
+			Params:     []types.Param{{Name: `x`, Type: &types.Map{Key: parser.CustomType(`Key`), Value: inner}}},
+			Variadic:   true,
+			Returns:    []types.Type{types.Error},
+		}}, {Name: `y`, Type: &types.Nullable{Type: param}}},
+		ReturnTypes: []types.Type{&types.Interface{Name: `Number`, Types: []types.Type{types.Int, types.Float}}},
+	})
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		again, _ := Marshal(got)
+		t.Fatalf("Unmarshal() = %s, want %s", again, data)
+	}
+
+	// the type parameter of the signature hides the one of the function
+	f := got.(*ast.Program).Body.Statements[0].(*ast.Function)
+	if s := f.Parameters[0].Type.(*types.Signature); s.Params[0].Type.(*types.Map).Value != s.TypeParams[0] {
+		t.Errorf("Unmarshal() did not share the type parameter T of g")
+	}
+	if f.Parameters[1].Type.(*types.Nullable).Type != f.TypeParams[0] {
+		t.Errorf("Unmarshal() did not share the type parameter T of f")
+	}
+}
+
+func TestMarshal_error(t *testing.T) {
+	tests := []struct {
+		node core.Node
+		want string
+	}{
+		{node: &ast.Return{Values: []core.Expression{&ast.String{}}}, want: `cannot encode *ast.String`},
+		{node: &ast.Param{Type: types.Type(nil)}, want: ``},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(tt.node)
+		if tt.want == `` && err != nil || tt.want != `` && (err == nil || err.Error() != tt.want) {
+			t.Errorf("Marshal(%T) error = %v, want %q", tt.node, err, tt.want)
+		}
+	}
+}
+
+func TestUnmarshal_error(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: `{`, want: `unexpected end of JSON input`},
+		{data: `{"kind":"loop"}`, want: `unknown node kind "loop"`},
+		{data: `{"body":{}}`, want: `missing kind`},
+		{
+			data: `{"kind":"program","body":{"kind":"atom"}}`,
+			want: `body of Program: cannot use a atom node as *ast.Block`,
+		},
+		{
+			data: `{"kind":"param","type":{"kind":"builtin","name":"long"}}`,
+			want: `type of Param: unknown builtin type "long"`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := Unmarshal([]byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Unmarshal(%s) error = %v, want %s", tt.data, err, tt.want)
+		}
+	}
+}
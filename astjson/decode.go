@@ -0,0 +1,275 @@
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/types"
+)
+
+type decoder struct {
+	// typeParams holds the type parameters of the functions
+	// enclosing the node being decoded, the innermost last
+	typeParams []*types.TypeParam
+}
+
+// decode decodes [data] into [v] which is settable
+func (d *decoder) decode(v reflect.Value, data json.RawMessage) error {
+	if string(data) == `null` {
+		return nil
+	}
+
+	switch {
+	case v.Type() == typeType:
+		t, err := d.decodeType(data)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+	case v.Kind() == reflect.Ptr && v.Type().Implements(typeType):
+		// type parameters are declared by the lists of functions
+		param, err := d.typeParam(data)
+		if err != nil {
+			return err
+		}
+		d.typeParams = append(d.typeParams, param)
+		v.Set(reflect.ValueOf(param))
+	case v.Kind() == reflect.Interface, v.Kind() == reflect.Ptr:
+		node, err := d.node(data)
+		if err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Ptr && node.Type() != v.Type() || !node.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf(`cannot use a %s node as %s`, kinds[node.Type().Elem()], v.Type())
+		}
+		v.Set(node)
+	case v.Kind() == reflect.Slice:
+		var list []json.RawMessage
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, el := range list {
+			if err := d.decode(s.Index(i), el); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case v.Kind() == reflect.Struct:
+		var o map[string]json.RawMessage
+		if err := json.Unmarshal(data, &o); err != nil {
+			return err
+		}
+		return d.fields(v, o)
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	return nil
+}
+
+// node decodes a node. The type parameters it declares
+// are only visible in it
+func (d *decoder) node(data json.RawMessage) (reflect.Value, error) {
+	var o map[string]json.RawMessage
+	if err := json.Unmarshal(data, &o); err != nil {
+		return reflect.Value{}, err
+	}
+	kind, err := str(o, `kind`)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	t, ok := nodeTypes[kind]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf(`unknown node kind %q`, kind)
+	}
+
+	scope := len(d.typeParams)
+	defer func() { d.typeParams = d.typeParams[:scope] }()
+
+	node := reflect.New(t)
+	return node, d.fields(node.Elem(), o)
+}
+
+// fields decodes the fields of the struct [v] from [o]
+func (d *decoder) fields(v reflect.Value, o map[string]json.RawMessage) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case f.Anonymous && f.Type.Kind() == reflect.Interface:
+			continue
+		case f.Anonymous:
+			if err := d.fields(v.Field(i), o); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, ok := o[fieldName(f.Name)]
+		if !ok {
+			continue
+		}
+		if err := d.decode(v.Field(i), data); err != nil {
+			return fmt.Errorf(`%s of %s: %s`, fieldName(f.Name), t.Name(), err)
+		}
+	}
+	return nil
+}
+
+// decodeType decodes a type. Type parameters are the ones declared
+// by the enclosing functions if they declare one with the same name
+func (d *decoder) decodeType(data json.RawMessage) (types.Type, error) {
+	var o map[string]json.RawMessage
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	kind, err := str(o, `kind`)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case `builtin`, `constraint`, `custom`:
+		name, err := str(o, `name`)
+		if err != nil {
+			return nil, err
+		}
+		named := types.Builtins
+		if kind == `constraint` {
+			named = types.Constraints
+		} else if kind == `custom` {
+			return parser.CustomType(name), nil
+		}
+		if t, ok := named[name]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf(`unknown %s type %q`, kind, name)
+	case `list`:
+		elem, err := d.decodeType(o[`elem`])
+		return &types.List{Elem: elem}, err
+	case `map`:
+		key, err := d.decodeType(o[`key`])
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decodeType(o[`value`])
+		return &types.Map{Key: key, Value: value}, err
+	case `nullable`:
+		inner, err := d.decodeType(o[`type`])
+		return &types.Nullable{Type: inner}, err
+	case `typeParam`:
+		name, err := str(o, `name`)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(d.typeParams) - 1; i >= 0; i-- {
+			if d.typeParams[i].Name == name {
+				return d.typeParams[i], nil
+			}
+		}
+		return d.typeParam(data)
+	case `interface`:
+		name, err := str(o, `name`)
+		if err != nil {
+			return nil, err
+		}
+		list, err := d.decodeTypes(o[`types`])
+		return &types.Interface{Name: name, Types: list}, err
+	case `signature`:
+		return d.signature(o)
+	}
+	return nil, fmt.Errorf(`unknown type kind %q`, kind)
+}
+
+// typeParam decodes a new type parameter
+func (d *decoder) typeParam(data json.RawMessage) (*types.TypeParam, error) {
+	var o map[string]json.RawMessage
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	name, err := str(o, `name`)
+	if err != nil {
+		return nil, err
+	}
+	param := &types.TypeParam{Name: name}
+	if constraint, ok := o[`constraint`]; ok {
+		if param.Constraint, err = d.decodeType(constraint); err != nil {
+			return nil, err
+		}
+	}
+	return param, nil
+}
+
+// signature decodes the type of a function
+// from the fields of the object [o]
+func (d *decoder) signature(o map[string]json.RawMessage) (*types.Signature, error) {
+	scope := len(d.typeParams)
+	defer func() { d.typeParams = d.typeParams[:scope] }()
+
+	s := &types.Signature{}
+	if data, ok := o[`typeParams`]; ok {
+		if err := d.decode(reflect.ValueOf(&s.TypeParams).Elem(), data); err != nil {
+			return nil, err
+		}
+	}
+	if data, ok := o[`params`]; ok {
+		var params []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, err
+		}
+		s.Params = make([]types.Param, len(params))
+		for i, p := range params {
+			t, err := d.decodeType(p.Type)
+			if err != nil {
+				return nil, err
+			}
+			s.Params[i] = types.Param{Name: p.Name, Type: t}
+		}
+	}
+	if data, ok := o[`variadic`]; ok {
+		if err := json.Unmarshal(data, &s.Variadic); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	s.Returns, err = d.decodeTypes(o[`returns`])
+	return s, err
+}
+
+// decodeTypes decodes a list of types which is nil if [data] is
+func (d *decoder) decodeTypes(data json.RawMessage) ([]types.Type, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	decoded := make([]types.Type, len(list))
+	for i, el := range list {
+		t, err := d.decodeType(el)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = t
+	}
+	return decoded, nil
+}
+
+// str returns the string field [name] of [o]
+func str(o map[string]json.RawMessage, name string) (string, error) {
+	var s string
+	data, ok := o[name]
+	if !ok {
+		return ``, fmt.Errorf(`missing %s`, name)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ``, fmt.Errorf(`invalid %s: %s`, name, err)
+	}
+	return s, nil
+}
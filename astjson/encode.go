@@ -0,0 +1,179 @@
+package astjson
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/types"
+)
+
+// typeType is the type of the fields holding types
+var typeType = reflect.TypeOf((*types.Type)(nil)).Elem()
+
+// encode returns the JSON value of [v] and true if it is a
+// zero value which is left out of the object holding it
+func encode(v reflect.Value) (interface{}, bool, error) {
+	if !v.IsValid() {
+		return nil, true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, true, nil
+		}
+		if v.Type() == typeType {
+			t, err := encodeType(v.Interface().(types.Type))
+			return t, false, err
+		}
+		return encode(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, true, nil
+		}
+		// type parameters are listed by functions
+		if v.Type().Implements(typeType) {
+			t, err := encodeType(v.Interface().(types.Type))
+			return t, false, err
+		}
+		kind, ok := kinds[v.Type().Elem()]
+		if !ok {
+			return nil, false, fmt.Errorf(`cannot encode %s`, v.Type())
+		}
+		o, err := fields(v.Elem(), object{{`kind`, kind}})
+		return o, false, err
+	case reflect.Struct:
+		o, err := fields(v, object{})
+		return o, v.IsZero(), err
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, true, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			el, _, err := encode(v.Index(i))
+			if err != nil {
+				return nil, false, err
+			}
+			list[i] = el
+		}
+		return list, false, nil
+	case reflect.String, reflect.Bool, reflect.Int:
+		return v.Interface(), v.IsZero(), nil
+	}
+	return nil, false, fmt.Errorf(`cannot encode %s`, v.Type())
+}
+
+// fields appends the fields of the struct [v] to [o]
+func fields(v reflect.Value, o object) (object, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case f.Anonymous && f.Type.Kind() == reflect.Interface:
+			// the embedded node interfaces hold no values
+			continue
+		case f.Anonymous:
+			var err error
+			if o, err = fields(v.Field(i), o); err != nil {
+				return nil, err
+			}
+			continue
+		case f.PkgPath != ``:
+			return nil, fmt.Errorf(`cannot encode the unexported field %s of %s`, f.Name, t)
+		}
+
+		value, zero, err := encode(v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		if !zero {
+			o = append(o, field{fieldName(f.Name), value})
+		}
+	}
+	return o, nil
+}
+
+// encodeType returns the JSON value of the type [t]
+func encodeType(t types.Type) (interface{}, error) {
+	switch t := t.(type) {
+	case parser.CustomType:
+		return object{{`kind`, `custom`}, {`name`, string(t)}}, nil
+	case *types.List:
+		elem, err := encodeType(t.Elem)
+		return object{{`kind`, `list`}, {`elem`, elem}}, err
+	case *types.Map:
+		key, err := encodeType(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := encodeType(t.Value)
+		return object{{`kind`, `map`}, {`key`, key}, {`value`, value}}, err
+	case *types.Nullable:
+		inner, err := encodeType(t.Type)
+		return object{{`kind`, `nullable`}, {`type`, inner}}, err
+	case *types.TypeParam:
+		o := object{{`kind`, `typeParam`}, {`name`, t.Name}}
+		if t.Constraint == nil {
+			return o, nil
+		}
+		constraint, err := encodeType(t.Constraint)
+		return append(o, field{`constraint`, constraint}), err
+	case *types.Interface:
+		if c, ok := types.Constraints[t.Name]; ok && c == t {
+			return object{{`kind`, `constraint`}, {`name`, t.Name}}, nil
+		}
+		o := object{{`kind`, `interface`}, {`name`, t.Name}}
+		return encodeTypes(o, `types`, t.Types)
+	case *types.Signature:
+		o := object{{`kind`, `signature`}}
+		if t.TypeParams != nil {
+			params := make([]interface{}, len(t.TypeParams))
+			for i, param := range t.TypeParams {
+				p, err := encodeType(param)
+				if err != nil {
+					return nil, err
+				}
+				params[i] = p
+			}
+			o = append(o, field{`typeParams`, params})
+		}
+		if t.Params != nil {
+			params := make([]interface{}, len(t.Params))
+			for i, param := range t.Params {
+				p, err := encodeType(param.Type)
+				if err != nil {
+					return nil, err
+				}
+				params[i] = object{{`name`, param.Name}, {`type`, p}}
+			}
+			o = append(o, field{`params`, params})
+		}
+		if t.Variadic {
+			o = append(o, field{`variadic`, true})
+		}
+		return encodeTypes(o, `returns`, t.Returns)
+	}
+	if b, ok := types.Builtins[t.String()]; ok && b == t {
+		return object{{`kind`, `builtin`}, {`name`, t.String()}}, nil
+	}
+	return nil, fmt.Errorf(`cannot encode the type %s (%T)`, t, t)
+}
+
+// encodeTypes appends the field [name] holding [list] to [o]
+// unless [list] is nil
+func encodeTypes(o object, name string, list []types.Type) (object, error) {
+	if list == nil {
+		return o, nil
+	}
+	encoded := make([]interface{}, len(list))
+	for i, t := range list {
+		e, err := encodeType(t)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = e
+	}
+	return append(o, field{name, encoded}), nil
+}
@@ -0,0 +1,15 @@
+package checker
+
+import "github.com/amupitan/hero/ast"
+
+// check_capability reports an error if [call] calls a builtin function
+// needing a capability the program can't use. Calls to functions that
+// aren't called by name are checked when the program is run
+func (c *checker) check_capability(call *ast.Call) {
+	if c.allowed == nil {
+		return
+	}
+	if sym := c.lookup(call.Name); sym != nil && sym.capability != `` && !c.allowed[sym.capability] {
+		c.report(`call to %s is not allowed: it needs the %s capability`, call.Name, sym.capability)
+	}
+}
@@ -0,0 +1,668 @@
+package checker
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/resolver"
+	"github.com/amupitan/hero/types"
+)
+
+// symbol is a name defined in a scope
+type symbol struct {
+	// typ is the static type of the name if it is known
+	typ types.Type
+
+	// fn is the function the name refers to if it is known
+	fn *ast.Function
+
+	// nonNull is true if the name has a nullable type but
+	// has been checked to not be null
+	nonNull bool
+
+	// iface is the interface the name refers to if it names one
+	iface *types.Interface
+
+	// capability is the capability needed to call the
+	// builtin function the name refers to if it needs one
+	capability types.Capability
+}
+
+// scope holds the names defined in a block
+type scope struct {
+	parent  *scope
+	symbols map[string]*symbol
+}
+
+type checker struct {
+	scope  *scope
+	errors []error
+
+	// function is the function whose body is being checked
+	function *ast.Function
+
+	// typeParams holds the type parameters of the generic
+	// functions enclosing the code being checked
+	typeParams map[string]*types.TypeParam
+
+	// allowed holds the capabilities the program can use. All
+	// capabilities can be used if it is nil
+	allowed map[types.Capability]bool
+
+	// types records the types of the defined names if it is set
+	types map[ast.Pos]types.Type
+
+	// pos is the position of the statement being checked
+	pos ast.Pos
+}
+
+// Config configures the checks of a program
+type Config struct {
+	// Host holds the types of the names the host of the program
+	// defines e.g. a Go program embedding it. Functions defined
+	// by the host are described by their signatures
+	Host map[string]types.Type
+
+	// Allowed holds the capabilities the program can use. Calls to
+	// builtin functions needing other capabilities are reported.
+	// All capabilities can be used if it is nil
+	Allowed map[types.Capability]bool
+
+	// Types records the types of the names defined in the program
+	// by the positions of their definitions if it is set. Names
+	// whose types aren't known aren't recorded
+	Types map[ast.Pos]types.Type
+}
+
+// Check statically checks [program] and returns the errors found.
+// Names used where they aren't defined are found by resolving
+// [program], which records the captures of its functions
+func Check(program *ast.Program) []error {
+	return CheckWith(program, Config{})
+}
+
+// CheckWith checks [program] like Check with the names
+// and capabilities provided by its host in [config]
+func CheckWith(program *ast.Program, config Config) []error {
+	c := &checker{allowed: config.Allowed, types: config.Types}
+
+	// builtin functions can be redefined by the program
+	c.push()
+	for name, sig := range types.Functions {
+		c.define(name, &symbol{typ: types.Func, fn: ast.FunctionOf(name, sig), capability: types.Capabilities[name]})
+	}
+	for name, t := range config.Host {
+		if sig, ok := t.(*types.Signature); ok {
+			c.define(name, &symbol{typ: types.Func, fn: ast.FunctionOf(name, sig)})
+		} else {
+			c.define(name, &symbol{typ: t})
+		}
+	}
+
+	c.push()
+	c.check_block(program.Body)
+	c.pop()
+
+	// the names the program doesn't define must be
+	// builtins or names defined by the host
+	for _, u := range resolver.Resolve(program).Unresolved {
+		if c.lookup(u.Name) == nil {
+			c.pos = u.Pos
+			c.report(`undefined: %s`, u.Name)
+		}
+	}
+	return c.errors
+}
+
+// push enters a new scope
+func (c *checker) push() {
+	c.scope = &scope{parent: c.scope, symbols: map[string]*symbol{}}
+}
+
+// pop leaves the current scope
+func (c *checker) pop() {
+	c.scope = c.scope.parent
+}
+
+// define adds a name to the current scope
+func (c *checker) define(name string, s *symbol) {
+	if name != `` && name != `_` {
+		c.scope.symbols[name] = s
+	}
+}
+
+// record records the type of the name defined at [pos] if
+// types are recorded and both are known
+func (c *checker) record(pos ast.Pos, t types.Type) {
+	if c.types != nil && pos.IsValid() && t != nil {
+		c.types[pos] = t
+	}
+}
+
+// lookup returns the symbol of [name] from the closest
+// scope it was defined in or nil if it isn't defined
+func (c *checker) lookup(name string) *symbol {
+	for s := c.scope; s != nil; s = s.parent {
+		if sym, ok := s.symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// forget clears what is known about the value of [name]
+// after it is assigned to
+func (c *checker) forget(name string) {
+	if sym := c.lookup(name); sym != nil {
+		sym.fn = nil
+		sym.capability = ``
+	}
+}
+
+// callee returns the function called by [exp] if [exp] is a
+// call to a known function, or nil otherwise
+func (c *checker) callee(exp core.Expression) *ast.Function {
+	call, ok := exp.(*ast.Call)
+	if !ok {
+		return nil
+	}
+	if call.Func != nil {
+		return call.Func
+	}
+	if call.Callee != nil || call.Name == `` {
+		return nil
+	}
+	if sym := c.lookup(call.Name); sym != nil {
+		return sym.fn
+	}
+	return nil
+}
+
+// check_block checks the statements of a block in the current
+// scope. Named functions and interfaces are defined before the
+// statements are checked so they can be used before they are declared
+func (c *checker) check_block(b *ast.Block) {
+	if b == nil {
+		return
+	}
+	for _, s := range b.Statements {
+		if f, ok := s.(*ast.Function); ok && !f.Lambda {
+			c.define(f.Name, &symbol{typ: types.Func, fn: f})
+		}
+		if i, ok := s.(*ast.Interface); ok {
+			c.define(i.Name, &symbol{iface: &types.Interface{Name: i.Name, Types: i.Types}})
+		}
+	}
+	for _, s := range b.Statements {
+		c.check_statement(s)
+	}
+}
+
+// check_statement checks any statement. Errors found in
+// it are reported at its position if it is known
+func (c *checker) check_statement(s core.Statement) {
+	defer func(pos ast.Pos) { c.pos = pos }(c.pos)
+	if pos := ast.Start(s); pos.IsValid() {
+		c.pos = pos
+	}
+
+	switch stmt := s.(type) {
+	case *ast.Function:
+		c.record(stmt.Pos, types.Func)
+		c.check_function(stmt)
+	case *ast.Definition:
+		sym := &symbol{typ: c.typeNamed(stmt.Type)}
+		if stmt.Value != nil {
+			c.check_value(stmt.Value)
+			if f, ok := stmt.Value.(*ast.Function); ok {
+				sym.fn = f
+			}
+			if stmt.Type != `` {
+				c.check_assignable(stmt.Value, sym.typ)
+				c.check_type(stmt.Value, c.typeOf(stmt.Value), sym.typ, `variable declaration`)
+			} else if isNull(stmt.Value) {
+				c.report(`cannot infer the type of %s from null`, stmt.Name)
+			} else {
+				sym.typ = c.typeOf(stmt.Value)
+			}
+			sym.nonNull = isNullable(sym.typ) && !c.mayBeNull(stmt.Value)
+		}
+		c.define(stmt.Name, sym)
+		c.record(stmt.Pos, sym.typ)
+	case *ast.MultiAssignment:
+		c.check_multi_assignment(stmt)
+	case *ast.Block:
+		c.push()
+		c.check_block(stmt)
+		c.pop()
+	case *ast.If:
+		// notNull holds the identifiers that are known to not be
+		// null because the conditions of previous branches were false
+		var notNull []string
+
+		// inits counts the scopes of the variables defined
+		// before the conditions of the branches
+		inits := 0
+		for branch := stmt; branch != nil; branch = branch.Else {
+			if branch.Definition != nil {
+				c.push()
+				inits++
+				c.check_statement(branch.Definition)
+			}
+
+			var whenTrue, whenFalse []string
+			c.push()
+			c.narrow(notNull)
+			if branch.Condition != nil {
+				c.check_value(branch.Condition)
+				whenTrue, whenFalse = nullChecks(branch.Condition)
+			}
+			c.narrow(whenTrue)
+			c.check_block(branch.Body)
+			c.pop()
+			notNull = append(notNull, whenFalse...)
+		}
+		for ; inits > 0; inits-- {
+			c.pop()
+		}
+
+		// code after an if statement that exits when an identifier
+		// is null knows it isn't null e.g. if x == null { return }
+		if stmt.Else == nil && terminates(stmt.Body) {
+			c.narrow(notNull)
+		}
+	case *ast.Switch:
+		c.check_switch(stmt)
+	case *ast.ForLoop:
+		c.push()
+		if stmt.PreLoop != nil {
+			c.check_statement(stmt.PreLoop)
+		}
+		c.widen(stmt.Body, stmt.PostIteration)
+		if stmt.Condition != nil {
+			c.check_value(stmt.Condition)
+		}
+		if stmt.PostIteration != nil {
+			c.check_expression(stmt.PostIteration)
+		}
+		c.push()
+		c.check_block(stmt.Body)
+		c.pop()
+		c.pop()
+	case *ast.RangeLoop:
+		c.check_value(stmt.Iterable)
+		c.check_not_null(stmt.Iterable, `range`)
+		c.push()
+		c.define(stmt.First, &symbol{})
+		c.define(stmt.Second, &symbol{})
+		c.widen(stmt.Body)
+		c.check_block(stmt.Body)
+		c.pop()
+	case *ast.Return:
+		c.check_return(stmt)
+	case *ast.Try:
+		c.check_try(stmt)
+	case *ast.Throw:
+		c.check_throw(stmt)
+	case *ast.Defer:
+		c.check_expression(stmt.Call)
+	case *ast.Break, *ast.Continue, *ast.Interface:
+	default:
+		c.check_expression(stmt)
+	}
+}
+
+// check_function checks the body of a function in a
+// new scope holding its parameters
+func (c *checker) check_function(f *ast.Function) {
+	enclosing := c.function
+	c.function = f
+	defer func() { c.function = enclosing }()
+
+	if len(f.TypeParams) > 0 {
+		c.check_type_params(f)
+		enclosingParams := c.typeParams
+		c.typeParams = make(map[string]*types.TypeParam, len(enclosingParams)+len(f.TypeParams))
+		for name, t := range enclosingParams {
+			c.typeParams[name] = t
+		}
+		for _, t := range f.TypeParams {
+			c.typeParams[t.Name] = t
+		}
+		defer func() { c.typeParams = enclosingParams }()
+	}
+
+	c.push()
+	for _, param := range f.Parameters {
+		// default values can refer to the parameters before them
+		if param.Default != nil {
+			c.check_value(param.Default)
+		}
+		sym := &symbol{typ: param.Type}
+		if sig, ok := param.Type.(*types.Signature); ok && !param.Variadic {
+			// calls to parameters with function types are checked
+			// against their signatures
+			sym.fn = ast.FunctionOf(param.Name, sig)
+		}
+		c.define(param.Name, sym)
+		c.record(param.Pos, param.Type)
+	}
+	c.check_block(f.Body)
+	c.pop()
+}
+
+// check_multi_assignment checks that a multi-assignment
+// has a value for each identifier
+func (c *checker) check_multi_assignment(m *ast.MultiAssignment) {
+	// valueTypes holds the type of each value if it is known
+	valueTypes := make([]types.Type, len(m.Identifiers))
+	if len(m.Values) == 1 {
+		c.check_expression(m.Values[0])
+		if f := c.callee(m.Values[0]); f != nil {
+			if len(f.ReturnTypes) != len(m.Identifiers) {
+				c.report(`assignment mismatch: %d variables but %s returns %d values`,
+					len(m.Identifiers), m.Values[0], len(f.ReturnTypes))
+			} else if len(f.TypeParams) > 0 {
+				inferred := c.infer(m.Values[0].(*ast.Call), f, false)
+				for i, t := range f.ReturnTypes {
+					valueTypes[i] = substitute(t, inferred)
+				}
+			} else {
+				copy(valueTypes, f.ReturnTypes)
+			}
+		}
+	} else {
+		for i, v := range m.Values {
+			c.check_value(v)
+			valueTypes[i] = c.typeOf(v)
+		}
+	}
+
+	for i, name := range m.Identifiers {
+		// a single call returns a value of each of its return types
+		value, mayBeNull := m.Values[0], isNullable(valueTypes[i])
+		if len(m.Values) == len(m.Identifiers) {
+			value, mayBeNull = m.Values[i], c.mayBeNull(m.Values[i])
+		}
+
+		if m.Define {
+			if len(m.Values) == len(m.Identifiers) && isNull(value) && name != `_` {
+				c.report(`cannot infer the type of %s from null`, name)
+			}
+			c.define(name, &symbol{typ: valueTypes[i]})
+			if i < len(m.Positions) {
+				c.record(m.Positions[i], valueTypes[i])
+			}
+		} else {
+			if sym := c.lookup(name); sym != nil {
+				c.check_type(value, valueTypes[i], sym.typ, `assignment`)
+			}
+			c.forget(name)
+			c.assign_null(name, mayBeNull)
+		}
+	}
+}
+
+// check_return checks that a return statement returns as
+// many values as its function declares
+func (c *checker) check_return(r *ast.Return) {
+	// a single call can return all the values of a function
+	if len(r.Values) == 1 {
+		c.check_expression(r.Values[0])
+		f := c.callee(r.Values[0])
+		if f != nil && c.function != nil && (len(f.ReturnTypes) != 1 || len(c.function.ReturnTypes) != 1) {
+			if len(f.ReturnTypes) != len(c.function.ReturnTypes) {
+				c.report(`%s returns %d values but %s returns %d values`,
+					c.function.DisplayName(), len(c.function.ReturnTypes), r.Values[0], len(f.ReturnTypes))
+				return
+			}
+			var inferred map[*types.TypeParam]types.Type
+			if len(f.TypeParams) > 0 {
+				inferred = c.infer(r.Values[0].(*ast.Call), f, false)
+			}
+			for i, t := range f.ReturnTypes {
+				c.check_type(r.Values[0], substitute(t, inferred), c.function.ReturnTypes[i], `return statement`)
+			}
+			return
+		}
+	} else {
+		for _, v := range r.Values {
+			c.check_value(v)
+		}
+	}
+
+	// top-level returns have no declared values
+	if c.function == nil {
+		return
+	}
+	if len(r.Values) != len(c.function.ReturnTypes) {
+		c.report(`%s returns %d values but %d values are returned`,
+			c.function.DisplayName(), len(c.function.ReturnTypes), len(r.Values))
+		return
+	}
+	for i, v := range r.Values {
+		c.check_assignable(v, c.function.ReturnTypes[i])
+		c.check_type(v, c.typeOf(v), c.function.ReturnTypes[i], `return statement`)
+	}
+}
+
+// check_arity checks that the arguments of [call] match the
+// parameters of [f]
+func (c *checker) check_arity(call *ast.Call, f *ast.Function) {
+	names := make([]string, 0, len(call.Named))
+	for _, arg := range call.Named {
+		names = append(names, arg.Name)
+	}
+	bound, err := f.BindArgs(len(call.Args), names, call.Spread)
+	if err != nil {
+		c.report(`%s`, err)
+		return
+	}
+	if len(f.TypeParams) > 0 {
+		c.check_instantiation(call, f)
+	}
+
+	// named arguments are indexed after positional arguments
+	for i, param := range f.Parameters {
+		if param.Variadic && call.Spread {
+			continue
+		}
+		for _, arg := range bound[i] {
+			var value core.Expression
+			if arg < len(call.Args) {
+				value = call.Args[arg]
+			} else {
+				value = call.Named[arg-len(call.Args)].Value
+			}
+			c.check_assignable(value, param.Type)
+			c.check_type(value, c.typeOf(value), param.Type, `argument to `+f.DisplayName())
+		}
+	}
+}
+
+// check_value checks an expression used where exactly
+// one value is expected
+func (c *checker) check_value(e core.Expression) {
+	c.check_expression(e)
+	if f := c.callee(e); f != nil && len(f.ReturnTypes) != 1 {
+		c.report(`%s returns %d values but is used as a single value`, e, len(f.ReturnTypes))
+	}
+}
+
+// check_expression checks any expression
+func (c *checker) check_expression(e core.Expression) {
+	switch exp := e.(type) {
+	case *ast.Atom:
+		operand := *exp
+		operand.Negated, operand.Signed, operand.Complemented = false, false, false
+		if exp.Complemented {
+			c.check_integer(&operand, `operator ~`)
+		}
+		if exp.Negated || exp.Signed || exp.Complemented {
+			c.check_not_null(&operand, `operation`)
+		}
+	case *ast.Binary:
+		c.check_value(exp.Left)
+		if exp.Operator.Type == lx.And || exp.Operator.Type == lx.Or {
+			// the right side is only evaluated if the left side
+			// is true for && and false for ||
+			whenTrue, whenFalse := nullChecks(exp.Left)
+			c.push()
+			if exp.Operator.Type == lx.And {
+				c.narrow(whenTrue)
+			} else {
+				c.narrow(whenFalse)
+			}
+			c.check_value(exp.Right)
+			c.pop()
+		} else {
+			c.check_value(exp.Right)
+		}
+		switch exp.Operator.Type {
+		case lx.Equal, lx.NotEqual, lx.NullCoalesce:
+		default:
+			c.check_not_null(exp.Left, `operation `+exp.Operator.Value)
+			c.check_not_null(exp.Right, `operation `+exp.Operator.Value)
+		}
+		if isBitwise(exp.Operator.Type) {
+			c.check_integer(exp.Left, `operator `+exp.Operator.Value)
+			c.check_integer(exp.Right, `operator `+exp.Operator.Value)
+		} else {
+			c.check_operands(exp.Left, exp.Right, exp.Operator.Value)
+		}
+		if exp.Complemented {
+			operand := *exp
+			operand.Complemented = false
+			c.check_integer(&operand, `operator ~`)
+		}
+	case *ast.Assignment:
+		c.check_value(exp.Value)
+		identifier := &ast.Atom{Type: lx.Identifier, Value: exp.Identifier}
+		if op, ok := exp.Value.(*ast.Operation); ok {
+			if isBitwise(op.Type) {
+				what := `operator ` + string(op.Type)
+				c.check_integer(identifier, what)
+				c.check_integer(op.Value, what)
+			} else if op.Value != nil {
+				c.check_operands(identifier, op.Value, string(op.Type))
+			}
+			c.check_not_null(identifier, `operation `+string(op.Type))
+		} else {
+			if sym := c.lookup(exp.Identifier); sym != nil {
+				c.check_assignable(exp.Value, sym.typ)
+				c.check_type(exp.Value, c.typeOf(exp.Value), sym.typ, `assignment`)
+			}
+			c.assign_null(exp.Identifier, c.mayBeNull(exp.Value))
+		}
+		c.forget(exp.Identifier)
+	case *ast.Operation:
+		if exp.Value != nil {
+			c.check_value(exp.Value)
+		}
+	case *ast.Call:
+		switch {
+		case exp.Func != nil:
+			c.check_function(exp.Func)
+		case exp.Callee != nil:
+			c.check_value(exp.Callee)
+			c.check_not_null(exp.Callee, `call`)
+		default:
+			c.check_not_null(&ast.Atom{Type: lx.Identifier, Value: exp.Name}, `call`)
+			c.check_capability(exp)
+		}
+		for _, arg := range exp.Args {
+			c.check_value(arg)
+		}
+		for _, arg := range exp.Named {
+			c.check_value(arg.Value)
+		}
+		if f := c.callee(exp); f != nil {
+			c.check_arity(exp, f)
+		}
+		if exp.Complemented {
+			operand := *exp
+			operand.Complemented = false
+			c.check_integer(&operand, `operator ~`)
+		}
+	case *ast.Conversion:
+		c.check_conversion(exp)
+	case *ast.Conditional:
+		c.check_conditional(exp)
+	case *ast.Function:
+		c.check_function(exp)
+	case *ast.List:
+		for _, el := range exp.Elements {
+			c.check_value(el)
+		}
+	case *ast.Map:
+		for i := range exp.Keys {
+			c.check_value(exp.Keys[i])
+			c.check_value(exp.Values[i])
+		}
+	case *ast.Selector:
+		c.check_value(exp.Object)
+		c.check_not_null(exp.Object, `field access`)
+		c.check_error_field(exp)
+	case *ast.Index:
+		c.check_value(exp.Object)
+		c.check_value(exp.Index)
+		c.check_not_null(exp.Object, `index`)
+	}
+}
+
+// check_conversion checks that the type of the converted value
+// can be converted to the type of [conv] if it is known
+func (c *checker) check_conversion(conv *ast.Conversion) {
+	c.check_value(conv.Value)
+	c.check_not_null(conv.Value, `conversion to `+conv.Type.String())
+
+	from := c.typeOf(conv.Value)
+	if n, ok := from.(*types.Nullable); ok {
+		from = n.Type
+	}
+	if _, ok := from.(*types.TypeParam); !ok && from != nil && !types.Convertible(from, conv.Type) {
+		c.report(`cannot convert %s (%s) to %s`, conv.Value, from, conv.Type)
+	}
+
+	if conv.Complemented {
+		operand := *conv
+		operand.Complemented = false
+		c.check_integer(&operand, `operator ~`)
+	}
+}
+
+// check_conditional checks the branches of a conditional expression
+// with the identifiers its condition checks for null narrowed. The
+// types of the branches must agree if they are known
+func (c *checker) check_conditional(cond *ast.Conditional) {
+	c.check_value(cond.Condition)
+	if t := c.typeOf(cond.Condition); t != nil && t != types.Bool {
+		c.report(`non-boolean condition %s (%s) in conditional expression`, cond.Condition, t)
+	}
+
+	whenTrue, whenFalse := nullChecks(cond.Condition)
+	c.push()
+	c.narrow(whenTrue)
+	c.check_value(cond.Then)
+	then := c.typeOf(cond.Then)
+	c.pop()
+
+	c.push()
+	c.narrow(whenFalse)
+	c.check_value(cond.Else)
+	els := c.typeOf(cond.Else)
+	c.pop()
+
+	if then == nil || els == nil {
+		return
+	}
+	if n, ok := then.(*types.Nullable); ok {
+		then = n.Type
+	}
+	if n, ok := els.(*types.Nullable); ok {
+		els = n.Type
+	}
+	if !types.Equal(then, els) {
+		c.report(`mismatched types %s and %s in conditional expression %s`, then, els, cond)
+	}
+}
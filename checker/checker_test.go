@@ -0,0 +1,680 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/types"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: `destructure call`,
+			input: `
+			func divmod(a, b int) (int, int) {
+				return a / b, a % b
+			}
+			q, r := divmod(7, 2)
+			_, r = divmod(9, 4)`,
+		},
+		{
+			name: `function called before it is declared`,
+			input: `
+			q, r := divmod(7, 2)
+			func divmod(a, b int) (int, int) {
+				return a / b, a % b
+			}`,
+		},
+		{
+			name: `swap`,
+			input: `
+			a := 1
+			b := 2
+			a, b = b, a`,
+		},
+		{
+			name: `return call with matching values`,
+			input: `
+			func divmod(a, b int) (int, int) {
+				return a / b, a % b
+			}
+			func f() (int, int) {
+				return divmod(1, 1)
+			}`,
+		},
+		{
+			name: `unknown callee`,
+			input: `
+			f := ["g": 1]
+			a, b := f.g()`,
+		},
+		{
+			name: `too many variables`,
+			input: `
+			func one() int {
+				return 1
+			}
+			a, b := one()`,
+			want: []string{`assignment mismatch: 2 variables but one() returns 1 values`},
+		},
+		{
+			name: `lambda callee`,
+			input: `
+			pair := func() (int, int) { return 1, 2 }
+			a, b, c := pair()`,
+			want: []string{`assignment mismatch: 3 variables but pair() returns 2 values`},
+		},
+		{
+			name: `multiple values in single value context`,
+			input: `
+			func divmod(a, b int) (int, int) {
+				return a / b, a % b
+			}
+			x := divmod(1, 2) + 1`,
+			want: []string{`divmod(1, 2) returns 2 values but is used as a single value`},
+		},
+		{
+			name: `wrong number of returned values`,
+			input: `
+			func divmod(a, b int) (int, int) {
+				return a / b
+			}`,
+			want: []string{`divmod returns 2 values but 1 values are returned`},
+		},
+		{
+			name: `returned call with wrong number of values`,
+			input: `
+			func one() int {
+				return 1
+			}
+			func two() (int, int) {
+				return one()
+			}`,
+			want: []string{`two returns 2 values but one() returns 1 values`},
+		},
+		{
+			name: `bitwise operators on ints`,
+			input: `
+			func mask() int {
+				return 255
+			}
+			var x int = 5
+			y := ~x & mask() | 1 << 2
+			y ^= x
+			func f(n int) int {
+				return n >> 1
+			}`,
+		},
+		{
+			name: `bitwise operators on non-ints`,
+			input: `
+			x := 1.5
+			s := "a"
+			y := x & 1
+			z := ~s
+			w := 1 << (2 < 3)
+			s |= 1`,
+			want: []string{
+				`invalid operation: operator & not defined on x (float)`,
+				`invalid operation: operator ~ not defined on s (string)`,
+				`invalid operation: operator << not defined on (2<3) (bool)`,
+				`invalid operation: operator |= not defined on s (string)`,
+			},
+		},
+		{
+			name: `unknown types are not reported`,
+			input: `
+			f := ["g": 1]
+			y := f.g & 1`,
+		},
+		{
+			name: `variadic calls`,
+			input: `
+			func sum(base int, nums ...int) int {
+				return base
+			}
+			a := sum(1)
+			b := sum(1, 2, 3)
+			c := sum(1, [2, 3]...)`,
+		},
+		{
+			name: `wrong number of arguments`,
+			input: `
+			func add(x, y int) int {
+				return x + y
+			}
+			func sum(base int, nums ...int) int {
+				return base
+			}
+			a := add(1)
+			b := add([1, 2]...)
+			c := sum()
+			d := sum(1, 2, [3]...)`,
+			want: []string{
+				`missing argument for parameter y in call to add`,
+				`cannot use ... in call to non-variadic add`,
+				`missing argument for parameter base in call to sum`,
+				`sum expects 2 arguments but received 3`,
+			},
+		},
+		{
+			name: `default and named arguments`,
+			input: `
+			func connect(host string, port int = 8080) string {
+				return host
+			}
+			a := connect("x")
+			b := connect("x", port: 9000)
+			c := connect(port: 9000)
+			d := connect("x", timeout: 1, host: "y")`,
+			want: []string{
+				`missing argument for parameter host in call to connect`,
+				`unknown parameter timeout in call to connect`,
+			},
+		},
+		{
+			name: `null checks narrow nullable values`,
+			input: `
+			func find(key string) string? {
+				return null
+			}
+			func run(name string?, cfg string?) string {
+				if name != null {
+					s := name + "!"
+				} else {
+					s := name ?? "none"
+				}
+				if cfg == null {
+					return "none"
+				}
+				if name != null && name.len > 0 {}
+				if name == null || name.len == 0 {}
+				v := find("a")
+				if v == null {
+					return ""
+				} else if v.len > 0 {}
+				return cfg + "?"
+			}`,
+		},
+		{
+			name: `possibly null values`,
+			input: `
+			func find(key string) string? {
+				return null
+			}
+			func run(name string?, xs int?) {
+				a := name.len
+				b := xs + 1
+				c := find("k")[0]
+				for x in xs {}
+				if name != null {
+					name = find("n")
+					d := name.len
+				}
+				xs++
+			}`,
+			want: []string{
+				`invalid field access: name may be null`,
+				`invalid operation +: xs may be null`,
+				`invalid index: find(k) may be null`,
+				`invalid range: xs may be null`,
+				`invalid field access: name may be null`,
+				`invalid operation ++: xs may be null`,
+			},
+		},
+		{
+			name: `null assigned in loops`,
+			input: `
+			var x string? = "a"
+			for i in [1, 2] {
+				y := x + "b"
+				x = null
+			}
+			var z string? = "c"
+			for i := 0; i < 2; z = null {
+				w := z + "d"
+				i++
+			}
+			var v string? = "e"
+			for {
+				v = "f"
+				u := v + "g"
+				break
+			}`,
+			want: []string{
+				`invalid operation +: x may be null`,
+				`invalid operation +: z may be null`,
+			},
+		},
+		{
+			name: `null assigned by multi-assignments`,
+			input: `
+			func find() (int?, int) {
+				return null, 1
+			}
+			var x int? = 3
+			z := 1
+			x, z = null, 2
+			a := x + 1
+			var y int? = 3
+			if y != null {
+				y, z = null, 2
+				b := y + 1
+			}
+			var w int? = 3
+			w, z = find()
+			c := w + 1
+			v, u := null, 2`,
+			want: []string{
+				`invalid operation +: x may be null`,
+				`invalid operation +: y may be null`,
+				`invalid operation +: w may be null`,
+				`cannot infer the type of v from null`,
+			},
+		},
+		{
+			name: `undefined names`,
+			input: `
+			func compute() int {
+				return 4
+			}
+			func f() int {
+				return zz
+			}
+			if x := compute(); x > 3 {
+				y := x
+			}
+			for i := 0; i < 3; i++ {}
+			println(i, y)
+			return x`,
+			want: []string{
+				`undefined: zz`,
+				`undefined: i`,
+				`undefined: y`,
+				`undefined: x`,
+			},
+		},
+		{
+			name: `mixed numeric operands`,
+			input: `
+			x := 3
+			half := float(x) / 2
+			ok := float(x) / 2.0
+			big := x > 2.5
+			f := 1.5
+			f += 1
+			n := 1
+			n += 2
+			s := "ab" * 3
+			t := "ab" + s
+			eq := x == 2.5`,
+			want: []string{
+				`invalid operation: float(x) / 2 (mismatched types float and int)`,
+				`invalid operation: x > 2.5 (mismatched types int and float)`,
+				`invalid operation: f += 1 (mismatched types float and int)`,
+			},
+		},
+		{
+			name: `mismatched types`,
+			input: `
+			func first<T>(xs list[T]) T {
+				return xs[0]
+			}
+			func g() string {
+				return first([1])
+			}
+			func f() (int, string) {
+				return 1, "a"
+			}
+			func h() (string, string) {
+				return f()
+			}
+			var c int = "a"
+			a, b := f()
+			var d int = b
+			n := 1
+			n = 2.5
+			s := "x"
+			n, s = f()
+			s, n = f()
+			var e float = float(n)
+			var l list[int] = [1, 2]`,
+			want: []string{
+				`cannot use first([1]) (int) as string in return statement`,
+				`cannot use f() (int) as string in return statement`,
+				`cannot use a (string) as int in variable declaration`,
+				`cannot use b (string) as int in variable declaration`,
+				`cannot use 2.5 (float) as int in assignment`,
+				`cannot use f() (int) as string in assignment`,
+				`cannot use f() (string) as int in assignment`,
+			},
+		},
+		{
+			name: `null used as non-nullable value`,
+			input: `
+			func find(key string) string? {
+				return null
+			}
+			func greet(name string) string {
+				return null
+			}
+			var s string = null
+			var t string? = null
+			u := null
+			greet(find("x"))
+			greet(t ?? "anon")
+			n := 1
+			n = null`,
+			want: []string{
+				`cannot use null as string value`,
+				`cannot use null as string value`,
+				`cannot infer the type of u from null`,
+				`cannot use find(x) (may be null) as string value`,
+				`cannot use null as int value`,
+			},
+		},
+		{
+			name: `generic functions`,
+			input: `
+			interface Number { int | float }
+			func first<T>(xs list[T]) T {
+				return xs[0]
+			}
+			func max<T ordered>(a, b T) T {
+				if a > b {
+					return a
+				}
+				return b
+			}
+			func sum<T Number>(nums ...T) T {
+				var total T
+				for n in nums {
+					total += n
+				}
+				return total
+			}
+			x := first([1, 2]) ?? 0
+			y := max(1, 2) & 3
+			z := max("a", "b") & 3
+			sum(1, 2)
+			sum(1.5, 2.5)
+			sum("a")
+			max(true, false)
+			max(1, "a")
+			var s string = first(["a"])`,
+			want: []string{
+				`invalid operation: operator & not defined on max(a, b) (string)`,
+				`cannot use string as T in call to sum: string does not satisfy Number`,
+				`cannot use bool as T in call to max: bool does not satisfy ordered`,
+				`cannot use a (string) as T in call to max: T was inferred as int`,
+			},
+		},
+		{
+			name: `function types`,
+			input: `
+			func apply(f func(int) int, x int) int {
+				return f(x)
+			}
+			func twice(f func) func {
+				return f
+			}
+			apply(func(n int) int { return n * 2 }, 1)
+			apply(twice, 1)
+			apply(1, 2)
+			func bad(f func(int) int) {
+				f("a")
+				f(1, 2)
+			}`,
+			want: []string{
+				`cannot use 1 (int) as func(int) int in argument to apply`,
+				`cannot use a (string) as int in argument to f`,
+				`f expects 1 arguments but received 2`,
+			},
+		},
+		{
+			name: `undefined constraint`,
+			input: `
+			func f<T Missing>(x T) {}`,
+			want: []string{
+				`undefined constraint Missing for type parameter T of f`,
+			},
+		},
+		{
+			name: `conversions`,
+			input: `
+			func find(key string) string? {
+				return null
+			}
+			a := float(1) + 0.5
+			b := int("42") & 3
+			c := string('x') + string(1.5) + string(true)
+			d := rune(65)
+			e := int(true)
+			f := rune(1.5)
+			g := bool(1)
+			h := int(find("x"))
+			i := string(a) & 1`,
+			want: []string{
+				`cannot convert true (bool) to int`,
+				`cannot convert 1.5 (float) to rune`,
+				`cannot convert 1 (int) to bool`,
+				`invalid conversion to int: find(x) may be null`,
+				`invalid operation: operator & not defined on string(a) (string)`,
+			},
+		},
+		{
+			name: `switch`,
+			input: `
+			func find() bool? {
+				return null
+			}
+			x := 3
+			switch x {
+			case 1, 2, 1:
+			case 'a'..'z':
+			case "b":
+			case 5..[1]:
+			default:
+			}
+			ok := true
+			switch ok {
+			case true:
+			}
+			switch ok {
+			case true:
+			case false:
+			}
+			switch find() {
+			case true, false:
+			}`,
+			want: []string{
+				`duplicate case 1 in switch on x`,
+				`invalid case a in switch on x (mismatched types rune and int)`,
+				`invalid case z in switch on x (mismatched types rune and int)`,
+				`invalid case b in switch on x (mismatched types string and int)`,
+				`invalid case 5..[1] in switch on x: list[int] is not ordered`,
+				`switch on ok is not exhaustive: missing case false`,
+				`switch on find() is not exhaustive: missing case null`,
+			},
+		},
+		{
+			name: `conditionals and if definitions`,
+			input: `
+			func find(key string) string? {
+				return null
+			}
+			a := true ? 1 : 2
+			b := a > 0 ? a : "none"
+			c := (a ? 1 : 2) + a
+			if s := find("k"); s != null {
+				d := s.len
+			} else if t := s ?? "x"; t == "x" {
+				e := t.len + s.len
+			}
+			f := s
+			n := find("n")
+			g := n != null ? n.len : 0
+			h := (a > 0 ? null : "x").len`,
+			want: []string{
+				`mismatched types int and string in conditional expression (a>0) ? a : none`,
+				`non-boolean condition a (int) in conditional expression`,
+				`invalid field access: s may be null`,
+				`invalid field access: (a>0) ? null : x may be null`,
+				`undefined: s`,
+			},
+		},
+		{
+			name: `try and throw`,
+			input: `
+			func find(name string) string? {
+				if name == "" {
+					throw error("empty name")
+				}
+				return null
+			}
+			func first(s string?) string {
+				if s == null {
+					throw "missing"
+				}
+				return s
+			}
+			try {
+				throw 42
+			} catch err {
+				m := err.message + err.trace
+				c := err.code
+			}
+			throw null`,
+			want: []string{
+				`cannot throw 42 (int)`,
+				`err has no field code (type error)`,
+				`cannot throw null`,
+			},
+		},
+		{
+			name: `builtin functions`,
+			input: `
+			words := split("a b", " ")
+			n := len(words) + 1
+			s := join(append(words, "c"), ",")
+			m := max(1, 2, n)
+			println(s, m)
+			upper(1)
+			contains("a")
+			min(true, false)
+			x := max(1, "a")
+			f := sqrt(2)`,
+			want: []string{
+				`cannot use 1 (int) as string in argument to upper`,
+				`missing argument for parameter sub in call to contains`,
+				`cannot use bool as T in call to min: bool does not satisfy ordered`,
+				`cannot use a (string) as T in call to max: T was inferred as int`,
+				`cannot use 2 (int) as float in argument to sqrt`,
+			},
+		},
+		{
+			name: `builtin functions can be redefined`,
+			input: `
+			func len(n int) int {
+				return n
+			}
+			x := len(1)`,
+		},
+		{
+			name: `argument types`,
+			input: `
+			func greet(name string, times int = 1) {}
+			func total(xs list[int]) {}
+			func maybe(s string?) {}
+			greet("a", times: "b")
+			greet(1)
+			total([1, 2])
+			total(["a"])
+			maybe("a")`,
+			want: []string{
+				`cannot use b (string) as int in argument to greet`,
+				`cannot use 1 (int) as string in argument to greet`,
+				`cannot use [a] (list[string]) as list[int] in argument to total`,
+			},
+		},
+		{
+			name: `reassigned function is unknown`,
+			input: `
+			f := func() int { return 1 }
+			g := func() int { return 2 }
+			f = g
+			a, b := f()`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Check(parser.New(tt.input).Parse().Body.(*ast.Program))
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWith_capabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed map[types.Capability]bool
+		input   string
+		want    []string
+	}{
+		{
+			name:  `no allowlist`,
+			input: `println(now(), random())`,
+		},
+		{
+			name:    `disallowed calls`,
+			allowed: map[types.Capability]bool{types.Stdout: true},
+			input: `
+			println(read_file("a"))
+			func f() {
+				x := getenv("HOME")
+			}`,
+			want: []string{
+				`call to read_file is not allowed: it needs the fs capability`,
+				`call to getenv is not allowed: it needs the env capability`,
+			},
+		},
+		{
+			name:    `redefined builtin`,
+			allowed: map[types.Capability]bool{},
+			input: `
+			func now() int {
+				return 0
+			}
+			x := now()
+			func f() {
+				sleep := func(ms int) {}
+				sleep(1)
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := CheckWith(parser.New(tt.input).Parse().Body.(*ast.Program), Config{Allowed: tt.allowed})
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckWith() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/types"
+)
+
+// errorFields holds the types of the fields of an error
+var errorFields = map[string]types.Type{
+	`message`: types.String,
+	`trace`:   types.String,
+}
+
+// check_try checks the body and the catch of a try statement.
+// The caught error is defined in the scope of the catch
+func (c *checker) check_try(t *ast.Try) {
+	c.push()
+	c.check_block(t.Body)
+	c.pop()
+
+	c.push()
+	c.define(t.Name, &symbol{typ: types.Error})
+	c.record(t.NamePos, types.Error)
+	c.check_block(t.Catch)
+	c.pop()
+}
+
+// check_throw reports an error if the thrown value is
+// known to not be a string or an error
+func (c *checker) check_throw(t *ast.Throw) {
+	c.check_value(t.Value)
+	if isNull(t.Value) {
+		c.report(`cannot throw null`)
+		return
+	}
+	switch typ := c.typeOf(t.Value); typ {
+	case nil, types.String, types.Error, types.Generic:
+	default:
+		if _, ok := typ.(*types.TypeParam); !ok {
+			c.report(`cannot throw %s (%s)`, t.Value, typ)
+		}
+	}
+}
+
+// check_error_field reports an error if an error has no field [name]
+func (c *checker) check_error_field(s *ast.Selector) {
+	if c.typeOf(s.Object) != types.Error {
+		return
+	}
+	if _, ok := errorFields[s.Name]; !ok {
+		c.report(`%s has no field %s (type error)`, s.Object, s.Name)
+	}
+}
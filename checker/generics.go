@@ -0,0 +1,165 @@
+package checker
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/types"
+)
+
+// constraint returns the constraint of the type parameter [t] or
+// nil if it names an interface that isn't defined
+func (c *checker) constraint(t *types.TypeParam) types.Type {
+	switch t.Constraint.(type) {
+	case *types.Interface:
+		return t.Constraint
+	}
+	if t.Constraint == types.Generic {
+		return t.Constraint
+	}
+	if sym := c.lookup(t.Constraint.String()); sym != nil && sym.iface != nil {
+		return sym.iface
+	}
+	return nil
+}
+
+// check_type_params checks that the constraints of the
+// type parameters of [f] are defined
+func (c *checker) check_type_params(f *ast.Function) {
+	for _, t := range f.TypeParams {
+		if c.constraint(t) == nil {
+			c.report(`undefined constraint %s for type parameter %s of %s`, t.Constraint, t.Name, f.DisplayName())
+		}
+	}
+}
+
+// infer infers the types of the type parameters of [f] from the
+// arguments of [call]. Type parameters whose types can't be known
+// are left out. Conflicting inferences are reported if [report] is set
+func (c *checker) infer(call *ast.Call, f *ast.Function, report bool) map[*types.TypeParam]types.Type {
+	inferred := map[*types.TypeParam]types.Type{}
+	names := make([]string, 0, len(call.Named))
+	for _, arg := range call.Named {
+		names = append(names, arg.Name)
+	}
+	bound, err := f.BindArgs(len(call.Args), names, call.Spread)
+	if err != nil {
+		return inferred
+	}
+
+	for i, param := range f.Parameters {
+		for _, arg := range bound[i] {
+			var value core.Expression
+			if arg < len(call.Args) {
+				value = call.Args[arg]
+			} else {
+				value = call.Named[arg-len(call.Args)].Value
+			}
+
+			// a spread list holds the values of a variadic parameter
+			paramType := param.Type
+			if param.Variadic && call.Spread {
+				paramType = &types.List{Elem: param.Type}
+			}
+			argType := c.typeOf(value)
+			if t := unify(paramType, argType, inferred); t != nil && report {
+				c.report(`cannot use %s (%s) as %s in call to %s: %s was inferred as %s`,
+					value, argType, paramType, f.DisplayName(), t, inferred[t])
+			}
+		}
+	}
+	return inferred
+}
+
+// unify matches the type [param] of a parameter with the type [arg]
+// of its argument and records the types of the type parameters in
+// [param]. It returns the type parameter that was already inferred
+// as a different type, if any
+func unify(param, arg types.Type, inferred map[*types.TypeParam]types.Type) *types.TypeParam {
+	if arg == nil {
+		return nil
+	}
+	switch p := param.(type) {
+	case *types.TypeParam:
+		if t, ok := inferred[p]; ok && !types.Equal(t, arg) {
+			return p
+		}
+		inferred[p] = arg
+	case *types.List:
+		if a, ok := arg.(*types.List); ok {
+			return unify(p.Elem, a.Elem, inferred)
+		}
+	case *types.Map:
+		if a, ok := arg.(*types.Map); ok {
+			if t := unify(p.Key, a.Key, inferred); t != nil {
+				return t
+			}
+			return unify(p.Value, a.Value, inferred)
+		}
+	case *types.Nullable:
+		if a, ok := arg.(*types.Nullable); ok {
+			return unify(p.Type, a.Type, inferred)
+		}
+		return unify(p.Type, arg, inferred)
+	}
+	return nil
+}
+
+// check_instantiation checks that the types inferred for the
+// type parameters of [f] in [call] satisfy their constraints
+func (c *checker) check_instantiation(call *ast.Call, f *ast.Function) {
+	inferred := c.infer(call, f, true)
+	for _, t := range f.TypeParams {
+		arg, ok := inferred[t]
+		constraint := c.constraint(t)
+		if !ok || constraint == nil || types.Satisfies(arg, constraint) {
+			continue
+		}
+		c.report(`cannot use %s as %s in call to %s: %s does not satisfy %s`,
+			arg, t, f.DisplayName(), arg, constraint)
+	}
+}
+
+// substitute replaces the type parameters in [t] with the types
+// inferred for them. It returns nil if a type parameter in [t]
+// wasn't inferred
+func substitute(t types.Type, inferred map[*types.TypeParam]types.Type) types.Type {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return inferred[t]
+	case *types.List:
+		if elem := substitute(t.Elem, inferred); elem != nil {
+			return &types.List{Elem: elem}
+		}
+		return nil
+	case *types.Map:
+		key, value := substitute(t.Key, inferred), substitute(t.Value, inferred)
+		if key != nil && value != nil {
+			return &types.Map{Key: key, Value: value}
+		}
+		return nil
+	case *types.Nullable:
+		if inner := substitute(t.Type, inferred); inner != nil {
+			if isNullable(inner) {
+				return inner
+			}
+			return &types.Nullable{Type: inner}
+		}
+		return nil
+	}
+	return t
+}
+
+// hasTypeParams returns true if [t] is or contains a type parameter
+func hasTypeParams(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.List:
+		return hasTypeParams(t.Elem)
+	case *types.Map:
+		return hasTypeParams(t.Key) || hasTypeParams(t.Value)
+	case *types.Nullable:
+		return hasTypeParams(t.Type)
+	}
+	return false
+}
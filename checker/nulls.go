@@ -0,0 +1,181 @@
+package checker
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/types"
+)
+
+// isNullable returns true if values of [t] can be null
+func isNullable(t types.Type) bool {
+	_, ok := t.(*types.Nullable)
+	return ok
+}
+
+// nullableOf returns the nullable version of [t]
+// or nil if [t] isn't known
+func nullableOf(t types.Type) types.Type {
+	if t == nil || isNullable(t) {
+		return t
+	}
+	return &types.Nullable{Type: t}
+}
+
+// isNull returns true if [e] is the null literal
+func isNull(e core.Expression) bool {
+	a, ok := e.(*ast.Atom)
+	return ok && a.Type == lx.Null
+}
+
+// mayBeNull returns true if [e] is null or has a nullable type
+// and hasn't been narrowed by a null check
+func (c *checker) mayBeNull(e core.Expression) bool {
+	if isNull(e) {
+		return true
+	}
+	if b, ok := e.(*ast.Binary); ok && b.Operator.Type == lx.NullCoalesce {
+		return c.mayBeNull(b.Right)
+	}
+	return isNullable(c.typeOf(e))
+}
+
+// check_not_null reports an error if [e] may be null where it is
+// used in [what] e.g. a field access
+func (c *checker) check_not_null(e core.Expression, what string) {
+	if c.mayBeNull(e) {
+		c.report(`invalid %s: %s may be null`, what, e)
+	}
+}
+
+// check_assignable reports an error if [value] may be null but
+// is used where a value of the non-nullable type [t] is expected
+func (c *checker) check_assignable(value core.Expression, t types.Type) {
+	if _, ok := t.(*types.TypeParam); ok {
+		// a type parameter can stand for a nullable type
+		return
+	}
+	if t == nil || t == types.Generic || isNullable(t) || !c.mayBeNull(value) {
+		return
+	}
+	if isNull(value) {
+		c.report(`cannot use null as %s value`, t)
+		return
+	}
+	c.report(`cannot use %s (may be null) as %s value`, value, t)
+}
+
+// nullChecks returns the identifiers that are not null when [cond]
+// is true and those that are not null when [cond] is false
+func nullChecks(cond core.Expression) (whenTrue, whenFalse []string) {
+	b, ok := cond.(*ast.Binary)
+	if !ok {
+		return nil, nil
+	}
+
+	switch b.Operator.Type {
+	case lx.Equal, lx.NotEqual:
+		var name string
+		if a, ok := b.Left.(*ast.Atom); ok && a.Type == lx.Identifier && isNull(b.Right) {
+			name = a.Value
+		} else if a, ok := b.Right.(*ast.Atom); ok && a.Type == lx.Identifier && isNull(b.Left) {
+			name = a.Value
+		} else {
+			break
+		}
+
+		if b.Operator.Type == lx.NotEqual {
+			whenTrue = []string{name}
+		} else {
+			whenFalse = []string{name}
+		}
+	case lx.And:
+		// both sides are true if the condition is true
+		leftTrue, _ := nullChecks(b.Left)
+		rightTrue, _ := nullChecks(b.Right)
+		whenTrue = append(leftTrue, rightTrue...)
+	case lx.Or:
+		// both sides are false if the condition is false
+		_, leftFalse := nullChecks(b.Left)
+		_, rightFalse := nullChecks(b.Right)
+		whenFalse = append(leftFalse, rightFalse...)
+	}
+
+	if b.Negated {
+		return whenFalse, whenTrue
+	}
+	return whenTrue, whenFalse
+}
+
+// narrow marks nullable identifiers as not null in the current
+// scope e.g. in the body of if x != null {}
+func (c *checker) narrow(names []string) {
+	for _, name := range names {
+		if sym := c.lookup(name); sym != nil && isNullable(sym.typ) && !sym.nonNull {
+			narrowed := *sym
+			narrowed.nonNull = true
+			c.define(name, &narrowed)
+		}
+	}
+}
+
+// assign_null updates what is known about [name] being null after
+// a value is assigned to it. Narrowings of the identifier in the
+// enclosing scopes are undone if [mayBeNull] is set
+func (c *checker) assign_null(name string, mayBeNull bool) {
+	for s := c.scope; s != nil; s = s.parent {
+		sym, ok := s.symbols[name]
+		if !ok {
+			continue
+		}
+		if !mayBeNull {
+			sym.nonNull = true
+			return
+		}
+		sym.nonNull = false
+	}
+}
+
+// widen forgets that the identifiers assigned in [nodes] aren't null
+// in the current scope. A loop widens the identifiers assigned in it
+// before checking its body which can run again after the assignments
+func (c *checker) widen(nodes ...core.Node) {
+	var names []string
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		ast.Inspect(n, func(node core.Node) bool {
+			switch a := node.(type) {
+			case *ast.Assignment:
+				names = append(names, a.Identifier)
+			case *ast.MultiAssignment:
+				if !a.Define {
+					names = append(names, a.Identifiers...)
+				}
+			}
+			return true
+		})
+	}
+
+	for _, name := range names {
+		if sym := c.lookup(name); sym != nil && sym.nonNull {
+			widened := *sym
+			widened.nonNull = false
+			c.define(name, &widened)
+		}
+	}
+}
+
+// terminates returns true if a block always ends with a
+// return, break, continue or throw statement
+func terminates(b *ast.Block) bool {
+	if b == nil || len(b.Statements) == 0 {
+		return false
+	}
+	switch b.Statements[len(b.Statements)-1].(type) {
+	case *ast.Return, *ast.Break, *ast.Continue, *ast.Throw:
+		return true
+	}
+	return false
+}
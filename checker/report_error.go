@@ -0,0 +1,22 @@
+package checker
+
+import "fmt"
+
+// Error is an error found while checking a program
+type Error struct {
+	Message string
+
+	// Line and Column locate the statement the
+	// error was found in if its position is known
+	Line, Column int
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// report records an error with a formatted message. Checking
+// continues after an error so all errors in a program are found
+func (c *checker) report(format string, args ...interface{}) {
+	c.errors = append(c.errors, &Error{Message: fmt.Sprintf(format, args...), Line: c.pos.Line, Column: c.pos.Column})
+}
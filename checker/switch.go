@@ -0,0 +1,95 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/types"
+)
+
+// check_switch checks the cases of a switch against its value.
+// A switch over a bool without a default case must handle
+// true, false and null if the bool is nullable
+func (c *checker) check_switch(s *ast.Switch) {
+	c.check_value(s.Value)
+	t := c.typeOf(s.Value)
+	if n, ok := t.(*types.Nullable); ok {
+		t = n.Type
+	}
+
+	// seen holds the literal case values that were handled
+	seen := map[string]bool{}
+	for _, cs := range s.Cases {
+		for _, v := range cs.Values {
+			if r, ok := v.(*ast.Range); ok {
+				c.check_range(s, r, t)
+				continue
+			}
+			c.check_value(v)
+			c.check_case_type(s, v, t)
+
+			if a, ok := v.(*ast.Atom); ok && a.Type != lx.Identifier && !a.Negated && !a.Signed && !a.Complemented {
+				key := string(a.Type) + ` ` + a.Value
+				if seen[key] {
+					c.report(`duplicate case %s in switch on %s`, v, s.Value)
+				}
+				seen[key] = true
+			}
+		}
+		c.push()
+		c.check_block(cs.Body)
+		c.pop()
+	}
+	if s.Default != nil {
+		c.push()
+		c.check_block(s.Default)
+		c.pop()
+		return
+	}
+
+	if t != types.Bool {
+		return
+	}
+	var missing []string
+	for _, b := range []string{`true`, `false`} {
+		if !seen[string(lx.Bool)+` `+b] {
+			missing = append(missing, b)
+		}
+	}
+	if c.mayBeNull(s.Value) && !seen[string(lx.Null)+` `+string(lx.Null)] {
+		missing = append(missing, string(lx.Null))
+	}
+	if len(missing) > 0 {
+		c.report(`switch on %s is not exhaustive: missing case %s`, s.Value, strings.Join(missing, `, `))
+	}
+}
+
+// check_range checks that the bounds of a case range are ordered
+// values of the type [t] of the switch value if it is known
+func (c *checker) check_range(s *ast.Switch, r *ast.Range, t types.Type) {
+	for _, bound := range []core.Expression{r.Start, r.End} {
+		c.check_value(bound)
+		c.check_not_null(bound, `range`)
+		if bt := c.typeOf(bound); bt != nil && !types.Satisfies(bt, types.Ordered) {
+			c.report(`invalid case %s in switch on %s: %s is not ordered`, r, s.Value, bt)
+			return
+		}
+		c.check_case_type(s, bound, t)
+	}
+}
+
+// check_case_type reports an error if the type of the case value
+// [v] and the type [t] of the switch value are known and differ
+func (c *checker) check_case_type(s *ast.Switch, v core.Expression, t types.Type) {
+	if isNull(v) || t == nil {
+		return
+	}
+	if _, ok := t.(*types.TypeParam); ok {
+		return
+	}
+	if vt := c.typeOf(v); vt != nil && !types.Equal(vt, t) {
+		c.report(`invalid case %s in switch on %s (mismatched types %s and %s)`, v, s.Value, vt, t)
+	}
+}
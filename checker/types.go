@@ -0,0 +1,306 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/types"
+)
+
+// typeOf returns the static type of an expression
+// or nil if it can't be known before running it
+func (c *checker) typeOf(e core.Expression) types.Type {
+	switch exp := e.(type) {
+	case *ast.Atom:
+		if exp.Negated {
+			return types.Bool
+		}
+		if exp.Complemented {
+			return types.Int
+		}
+		switch exp.Type {
+		case lx.Int:
+			return types.Int
+		case lx.Float:
+			return types.Float
+		case lx.Bool:
+			return types.Bool
+		case lx.String, lx.RawString:
+			return types.String
+		case lx.Rune:
+			return types.Rune
+		case lx.Identifier:
+			sym := c.lookup(exp.Value)
+			if sym == nil {
+				return nil
+			}
+			if n, ok := sym.typ.(*types.Nullable); ok && sym.nonNull {
+				return n.Type
+			}
+			return sym.typ
+		}
+	case *ast.Binary:
+		op := exp.Operator.Type
+		if op == lx.NullCoalesce {
+			left := c.typeOf(exp.Left)
+			if n, ok := left.(*types.Nullable); ok && !c.mayBeNull(exp.Right) {
+				return n.Type
+			}
+			return left
+		}
+		switch {
+		case exp.Negated || isComparison(op) || op == lx.And || op == lx.Or:
+			return types.Bool
+		case exp.Complemented || isBitwise(op):
+			return types.Int
+		}
+
+		left, right := c.typeOf(exp.Left), c.typeOf(exp.Right)
+		if left == types.String && right == types.Int && op == lx.Times {
+			// a string can be repeated
+			return types.String
+		}
+		if left == right {
+			return left
+		}
+	case *ast.Call:
+		if exp.Negated {
+			return types.Bool
+		}
+		if exp.Complemented {
+			return types.Int
+		}
+		if f := c.callee(exp); f != nil && len(f.ReturnTypes) == 1 {
+			if len(f.TypeParams) > 0 {
+				return substitute(f.ReturnTypes[0], c.infer(exp, f, false))
+			}
+			return f.ReturnTypes[0]
+		}
+	case *ast.Conditional:
+		whenTrue, whenFalse := nullChecks(exp.Condition)
+		c.push()
+		c.narrow(whenTrue)
+		then := c.typeOf(exp.Then)
+		c.pop()
+		c.push()
+		c.narrow(whenFalse)
+		els := c.typeOf(exp.Else)
+		c.pop()
+
+		switch {
+		case isNull(exp.Then):
+			return nullableOf(els)
+		case isNull(exp.Else):
+			return nullableOf(then)
+		case then == nil || els == nil:
+			return nil
+		case types.Equal(then, els):
+			return then
+		case types.Equal(nullableOf(then), nullableOf(els)):
+			// one of the branches may be null
+			return nullableOf(then)
+		}
+	case *ast.Conversion:
+		switch {
+		case exp.Negated:
+			return types.Bool
+		case exp.Complemented:
+			return types.Int
+		case exp.Type == types.Generic:
+			return c.typeOf(exp.Value)
+		}
+		return exp.Type
+	case *ast.Function:
+		return types.Func
+	case *ast.List:
+		// a list has a known type if its elements have the same type
+		var elem types.Type
+		for _, el := range exp.Elements {
+			t := c.typeOf(el)
+			if t == nil || (elem != nil && !types.Equal(t, elem)) {
+				return nil
+			}
+			elem = t
+		}
+		if elem != nil {
+			return &types.List{Elem: elem}
+		}
+	case *ast.Map:
+		var key, value types.Type
+		for i := range exp.Keys {
+			k, v := c.typeOf(exp.Keys[i]), c.typeOf(exp.Values[i])
+			if k == nil || v == nil || (key != nil && (!types.Equal(k, key) || !types.Equal(v, value))) {
+				return nil
+			}
+			key, value = k, v
+		}
+		if key != nil {
+			return &types.Map{Key: key, Value: value}
+		}
+	case *ast.Selector:
+		if c.typeOf(exp.Object) == types.Error {
+			return errorFields[exp.Name]
+		}
+	case *ast.Index:
+		switch t := c.typeOf(exp.Object).(type) {
+		case *types.List:
+			return t.Elem
+		case *types.Map:
+			return t.Value
+		}
+		if c.typeOf(exp.Object) == types.String {
+			return types.Rune
+		}
+	}
+	return nil
+}
+
+// typeNamed returns the type named [name] or nil if it isn't a
+// builtin type, a type parameter in scope, or a list or map of known
+// types. A name ending with ? is a nullable type
+func (c *checker) typeNamed(name string) types.Type {
+	if strings.HasSuffix(name, `?`) {
+		if t := c.typeNamed(strings.TrimSuffix(name, `?`)); t != nil {
+			return &types.Nullable{Type: t}
+		}
+		return nil
+	}
+	if strings.HasPrefix(name, `list[`) && strings.HasSuffix(name, `]`) {
+		if elem := c.typeNamed(name[len(`list[`) : len(name)-1]); elem != nil {
+			return &types.List{Elem: elem}
+		}
+		return nil
+	}
+	if strings.HasPrefix(name, `map[`) {
+		// find the bracket closing the key type
+		depth := 0
+		for i := len(`map`); i < len(name); i++ {
+			switch name[i] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			if depth == 0 {
+				key, value := c.typeNamed(name[len(`map[`):i]), c.typeNamed(name[i+1:])
+				if key == nil || value == nil {
+					return nil
+				}
+				return &types.Map{Key: key, Value: value}
+			}
+		}
+		return nil
+	}
+	if t, ok := c.typeParams[name]; ok {
+		return t
+	}
+	if t, ok := types.Builtins[name]; ok {
+		return t
+	}
+	return nil
+}
+
+// isComparison returns true if [op] compares its operands
+func isComparison(op lx.TokenType) bool {
+	return op == lx.Equal || op == lx.NotEqual || op == lx.LessThan || op == lx.GreaterThan ||
+		op == lx.LessThanOrEqual || op == lx.GreaterThanOrEqual
+}
+
+// isBitwise returns true if [op] is a bitwise operator
+// or a bitwise compound assignment
+func isBitwise(op lx.TokenType) bool {
+	switch op {
+	case lx.BitAnd, lx.BitOr, lx.BitXor, lx.BitLeftShift, lx.BitRightShift,
+		lx.BitAndEq, lx.BitOrEq, lx.BitXorEq:
+		return true
+	}
+	return false
+}
+
+// check_integer reports an error if the type of [e] is
+// known and isn't an int. [what] describes the operation
+func (c *checker) check_integer(e core.Expression, what string) {
+	t := c.typeOf(e)
+	if _, ok := t.(*types.TypeParam); ok {
+		// the operation is checked where the type is known
+		return
+	}
+	if t != nil && t != types.Int {
+		c.report(`invalid operation: %s not defined on %s (%s)`, what, e, t)
+	}
+}
+
+// check_operands reports an error if the types of the operands
+// [left] and [right] of the arithmetic or comparison operator [op]
+// are known and differ. Ints aren't converted to floats implicitly
+func (c *checker) check_operands(left, right core.Expression, op string) {
+	switch lx.TokenType(op) {
+	case lx.Equal, lx.NotEqual, lx.NullCoalesce, lx.And, lx.Or:
+		return
+	}
+	l, r := c.typeOf(left), c.typeOf(right)
+	if n, ok := l.(*types.Nullable); ok {
+		l = n.Type
+	}
+	if n, ok := r.(*types.Nullable); ok {
+		r = n.Type
+	}
+	if l == nil || r == nil || l == types.Generic || r == types.Generic || hasTypeParams(l) || hasTypeParams(r) {
+		return
+	}
+	if l == types.String && r == types.Int && (op == string(lx.Times) || op == string(lx.TimesEq)) {
+		// a string can be repeated
+		return
+	}
+	if !types.Equal(l, r) {
+		c.report(`invalid operation: %s %s %s (mismatched types %s and %s)`, left, op, right, l, r)
+	}
+}
+
+// check_type reports an error if the type [from] of [value] is known
+// and isn't assignable to the type [t] it is used as in [context]
+func (c *checker) check_type(value core.Expression, from, t types.Type, context string) {
+	if t == nil || from == nil || isNull(value) || hasTypeParams(t) || hasTypeParams(from) {
+		// type parameters are checked by inferring their types
+		return
+	}
+	if n, ok := from.(*types.Nullable); ok {
+		// using null where it isn't allowed is reported by check_assignable
+		from = n.Type
+	}
+	if !assignable(from, t) {
+		c.report(`cannot use %s (%s) as %s in %s`, value, from, t, context)
+	}
+}
+
+// assignable returns true if a value of type [from] can be used
+// as a value of type [to]. Generic values can be used as any value
+// and any value can be used as a generic value. A value of type T
+// can be used as a T? value
+func assignable(from, to types.Type) bool {
+	if from == types.Generic || to == types.Generic {
+		return true
+	}
+	switch t := to.(type) {
+	case *types.Nullable:
+		if n, ok := from.(*types.Nullable); ok {
+			from = n.Type
+		}
+		return assignable(from, t.Type)
+	case *types.List:
+		l, ok := from.(*types.List)
+		return ok && assignable(l.Elem, t.Elem)
+	case *types.Map:
+		m, ok := from.(*types.Map)
+		return ok && assignable(m.Key, t.Key) && assignable(m.Value, t.Value)
+	case *types.Signature:
+		// the signatures of lambdas aren't known
+		return from == types.Func || types.Equal(from, to)
+	}
+	if _, ok := from.(*types.Signature); ok && to == types.Func {
+		return true
+	}
+	return types.Equal(from, to)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/amupitan/hero/lint"
+	"github.com/amupitan/hero/lsp"
+)
+
+const usage = `usage: hero <command>
+
+commands:
+	lsp	run a language server over stdin and stdout
+	lint	report likely mistakes in hero files`
+
+// lintConfig is the config file used by lint if it exists
+// and no other config file is given
+const lintConfig = `.herolint.json`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case `lsp`:
+		if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case `lint`:
+		os.Exit(runLint(os.Args[2:]))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %s\n%s\n", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// runLint lints the files in [args] and returns the exit status.
+// The status is 1 if a problem is found or a file can't be linted
+func runLint(args []string) int {
+	flags := flag.NewFlagSet(`lint`, flag.ContinueOnError)
+	configFile := flags.String(`config`, ``, `the config file enabling or disabling rules (default `+lintConfig+` if it exists)`)
+	list := flags.Bool(`rules`, false, `list the rules and exit`)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, `usage: hero lint [-config file] [-rules] file...`)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *list {
+		for _, rule := range lint.Rules {
+			fmt.Printf("%s\t%s\n", rule.Name, rule.Doc)
+		}
+		return 0
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	config := &lint.Config{}
+	path := *configFile
+	if _, err := os.Stat(lintConfig); path == `` && err == nil {
+		path = lintConfig
+	}
+	if path != `` {
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			config, err = lint.ParseConfig(data)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	status := 0
+	for _, file := range flags.Args() {
+		source, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		problems, err := lint.Lint(string(source), *config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			status = 1
+			continue
+		}
+		for _, p := range problems {
+			fmt.Printf("%s:%s\n", file, p)
+			status = 1
+		}
+	}
+	return status
+}
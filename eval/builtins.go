@@ -0,0 +1,317 @@
+package eval
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amupitan/hero/ast"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/types"
+)
+
+// Builtin is a function implemented in Go. Func describes its
+// parameters so calls to it are bound like calls to closures
+type Builtin struct {
+	Func *ast.Function
+
+	// call runs the function with its arguments in the order of its
+	// parameters. The arguments of a variadic parameter are in a list
+	call func(e *Evaluator, args []Value) Value
+
+	// capability is the capability needed to call the function if any
+	capability types.Capability
+}
+
+// NewBuiltin returns a builtin function named [name] with the
+// signature [s] implemented by [call]. The arguments passed to call
+// are in the order of the parameters of [s] and the arguments of a
+// variadic parameter are in a list. An error returned by call is
+// thrown as a runtime error
+func NewBuiltin(name string, s *types.Signature, call func(args []Value) (Value, error)) *Builtin {
+	return &Builtin{
+		Func: ast.FunctionOf(name, s),
+		call: func(e *Evaluator, args []Value) Value {
+			v, err := call(args)
+			if err != nil {
+				report(`%s`, err)
+			}
+			return v
+		},
+	}
+}
+
+// natives holds the implementations of the builtin
+// functions described by types.Functions
+var natives = map[string]func(e *Evaluator, args []Value) Value{
+	`print`: func(e *Evaluator, args []Value) Value {
+		fmt.Fprint(e.Stdout, joinValues(args[0].(*List)))
+		return nil
+	},
+	`println`: func(e *Evaluator, args []Value) Value {
+		fmt.Fprintln(e.Stdout, joinValues(args[0].(*List)))
+		return nil
+	},
+
+	`len`: func(e *Evaluator, args []Value) Value {
+		switch v := args[0].(type) {
+		case string:
+			return int64(utf8.RuneCountInString(v))
+		case *List:
+			return int64(len(v.Elements))
+		case *Map:
+			return int64(v.Len())
+		}
+		report(`invalid argument for len: %s has no length`, typeName(args[0]))
+		return nil
+	},
+	`append`: func(e *Evaluator, args []Value) Value {
+		xs, items := args[0].(*List), args[1].(*List)
+		elements := make([]Value, 0, len(xs.Elements)+len(items.Elements))
+		elements = append(elements, xs.Elements...)
+		return &List{Elements: append(elements, items.Elements...)}
+	},
+
+	`split`: func(e *Evaluator, args []Value) Value {
+		parts := strings.Split(args[0].(string), args[1].(string))
+		elements := make([]Value, 0, len(parts))
+		for _, part := range parts {
+			elements = append(elements, part)
+		}
+		return &List{Elements: elements}
+	},
+	`join`: func(e *Evaluator, args []Value) Value {
+		xs := args[0].(*List)
+		parts := make([]string, 0, len(xs.Elements))
+		for _, x := range xs.Elements {
+			parts = append(parts, x.(string))
+		}
+		return strings.Join(parts, args[1].(string))
+	},
+	`contains`: func(e *Evaluator, args []Value) Value {
+		return strings.Contains(args[0].(string), args[1].(string))
+	},
+	`upper`: func(e *Evaluator, args []Value) Value {
+		return strings.ToUpper(args[0].(string))
+	},
+	`lower`: func(e *Evaluator, args []Value) Value {
+		return strings.ToLower(args[0].(string))
+	},
+	`trim`: func(e *Evaluator, args []Value) Value {
+		return strings.TrimSpace(args[0].(string))
+	},
+
+	`abs`: func(e *Evaluator, args []Value) Value {
+		switch x := args[0].(type) {
+		case int64:
+			if x < 0 {
+				return -x
+			}
+			return x
+		case float64:
+			return math.Abs(x)
+		}
+		return nil
+	},
+	`sqrt`: func(e *Evaluator, args []Value) Value {
+		return math.Sqrt(args[0].(float64))
+	},
+	`pow`: func(e *Evaluator, args []Value) Value {
+		return math.Pow(args[0].(float64), args[1].(float64))
+	},
+	`floor`: func(e *Evaluator, args []Value) Value {
+		return math.Floor(args[0].(float64))
+	},
+	`min`: func(e *Evaluator, args []Value) Value {
+		return extreme(lx.LessThan, args[0], args[1].(*List))
+	},
+	`max`: func(e *Evaluator, args []Value) Value {
+		return extreme(lx.GreaterThan, args[0], args[1].(*List))
+	},
+
+	`str`: func(e *Evaluator, args []Value) Value {
+		return Stringify(args[0])
+	},
+	`parse_int`: func(e *Evaluator, args []Value) Value {
+		n, err := strconv.ParseInt(args[0].(string), 10, 64)
+		if err != nil {
+			report(`cannot parse %q as int`, args[0])
+		}
+		return n
+	},
+	`parse_float`: func(e *Evaluator, args []Value) Value {
+		f, err := strconv.ParseFloat(args[0].(string), 64)
+		if err != nil {
+			report(`cannot parse %q as float`, args[0])
+		}
+		return f
+	},
+
+	`read_file`: func(e *Evaluator, args []Value) Value {
+		data, err := ioutil.ReadFile(args[0].(string))
+		if err != nil {
+			report(`cannot read file: %s`, err)
+		}
+		return string(data)
+	},
+	`write_file`: func(e *Evaluator, args []Value) Value {
+		if err := ioutil.WriteFile(args[0].(string), []byte(args[1].(string)), 0644); err != nil {
+			report(`cannot write file: %s`, err)
+		}
+		return nil
+	},
+
+	`getenv`: func(e *Evaluator, args []Value) Value {
+		return os.Getenv(args[0].(string))
+	},
+
+	`now`: func(e *Evaluator, args []Value) Value {
+		return time.Now().UnixNano() / int64(time.Millisecond)
+	},
+	`sleep`: func(e *Evaluator, args []Value) Value {
+		d := time.Duration(args[0].(int64)) * time.Millisecond
+		if e.Context == nil {
+			time.Sleep(d)
+			return nil
+		}
+		// a sleep is cut short when the context is done
+		select {
+		case <-time.After(d):
+		case <-e.Context.Done():
+			e.step()
+		}
+		return nil
+	},
+
+	`random`: func(e *Evaluator, args []Value) Value {
+		return rand.Float64()
+	},
+	`random_int`: func(e *Evaluator, args []Value) Value {
+		n := args[0].(int64)
+		if n <= 0 {
+			report(`invalid argument for random_int: %d is not positive`, n)
+		}
+		return rand.Int63n(n)
+	},
+}
+
+// joinValues returns the string representations
+// of the elements of [l] separated by spaces
+func joinValues(l *List) string {
+	s := make([]string, 0, len(l.Elements))
+	for _, v := range l.Elements {
+		s = append(s, Stringify(v))
+	}
+	return strings.Join(s, ` `)
+}
+
+// extreme returns the value of [first] and [rest] that is
+// before the others in the order of the comparison [op]
+func extreme(op lx.TokenType, first Value, rest *List) Value {
+	result := first
+	for _, v := range rest.Elements {
+		if binaryOperation(lx.Token{Type: op, Value: string(op)}, v, result).(bool) {
+			result = v
+		}
+	}
+	return result
+}
+
+// call_builtin binds the arguments of a call to the parameters of
+// [b], checks they have the types of the parameters and runs [b]. A
+// builtin needing a capability the evaluator doesn't allow can't run
+func (e *Evaluator) call_builtin(b *Builtin, args []Value, names []string, spread bool) Value {
+	if e.Allowed != nil && b.capability != `` && !e.Allowed[b.capability] {
+		report(`call to %s is not allowed: it needs the %s capability`, b.Func.Name, b.capability)
+	}
+
+	env := newEnvironment(nil)
+	e.bind(b.Func, args, names, spread, env)
+
+	params := make([]Value, 0, len(b.Func.Parameters))
+	for _, param := range b.Func.Parameters {
+		v, _ := env.lookup(param.Name)
+		if param.Variadic {
+			for _, el := range v.(*List).Elements {
+				checkArgument(b.Func, param.Type, el)
+			}
+		} else {
+			checkArgument(b.Func, param.Type, v)
+		}
+		params = append(params, v)
+	}
+	return e.sized(b.call(e, params))
+}
+
+// checkArgument reports an error if [v] isn't a value
+// of type [t] where it is passed to [f]
+func checkArgument(f *ast.Function, t types.Type, v Value) {
+	if !hasType(v, t) {
+		report(`cannot use %s as %s in argument to %s`, typeName(v), t, f.DisplayName())
+	}
+}
+
+// hasType returns true if [v] is a value of type [t]. A type
+// parameter stands for the types that satisfy its constraint
+func hasType(v Value, t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		if i, ok := t.Constraint.(*types.Interface); ok && len(i.Types) > 0 {
+			for _, member := range i.Types {
+				if hasType(v, member) {
+					return true
+				}
+			}
+			return false
+		}
+		return true
+	case *types.Nullable:
+		return v == nil || hasType(v, t.Type)
+	case *types.List:
+		l, ok := v.(*List)
+		if !ok {
+			return false
+		}
+		for _, el := range l.Elements {
+			if !hasType(el, t.Elem) {
+				return false
+			}
+		}
+		return true
+	case *types.Signature:
+		switch v.(type) {
+		case *Closure, *Builtin:
+			return true
+		}
+		return false
+	case *types.Map:
+		m, ok := v.(*Map)
+		if !ok {
+			return false
+		}
+		for _, k := range m.Keys() {
+			if value, _ := m.Get(k); !hasType(k, t.Key) || !hasType(value, t.Value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch t {
+	case types.Generic:
+		return true
+	case types.Func:
+		switch v.(type) {
+		case *Closure, *Builtin:
+			return true
+		}
+		return false
+	}
+	return v != nil && typeName(v) == t.String()
+}
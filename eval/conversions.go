@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/amupitan/hero/ast"
+)
+
+// eval_conversion evaluates a conversion to a builtin type.
+// Failed conversions are reported at the type name
+func (e *Evaluator) eval_conversion(c *ast.Conversion, env *environment) Value {
+	v := single(e.eval_expression(c.Value, env))
+	converted, ok := convert(v, c.Type.String())
+	if !ok {
+		if s, isString := v.(string); isString {
+			reportAt(c.Token, `cannot convert %q to %s`, s, c.Type)
+		}
+		reportAt(c.Token, `cannot convert %s to %s`, typeName(v), c.Type)
+	}
+	return signOrNegate(converted, ast.PosOf(c.Token), c.Negated, c.Signed, c.Complemented)
+}
+
+// convert converts [v] to the builtin type named [to]. It
+// returns false if [v] can't be converted
+func convert(v Value, to string) (Value, bool) {
+	switch to {
+	case `int`:
+		switch val := v.(type) {
+		case int64:
+			return val, true
+		case float64:
+			return int64(val), true
+		case rune:
+			return int64(val), true
+		case string:
+			n, err := strconv.ParseInt(val, 10, 64)
+			return n, err == nil
+		}
+	case `float`:
+		switch val := v.(type) {
+		case int64:
+			return float64(val), true
+		case float64:
+			return val, true
+		case rune:
+			return float64(val), true
+		case string:
+			f, err := strconv.ParseFloat(val, 64)
+			return f, err == nil
+		}
+	case `rune`:
+		switch val := v.(type) {
+		case int64:
+			return rune(val), true
+		case rune:
+			return val, true
+		case string:
+			// only a string of one character is a rune
+			r, size := utf8.DecodeRuneInString(val)
+			return r, size > 0 && size == len(val) && r != utf8.RuneError
+		}
+	case `bool`:
+		switch val := v.(type) {
+		case bool:
+			return val, true
+		case string:
+			return val == `true`, val == `true` || val == `false`
+		}
+	case `string`:
+		switch v.(type) {
+		case int64, float64, bool, string, rune:
+			return Stringify(v), true
+		}
+	case `error`:
+		switch val := v.(type) {
+		case string:
+			return &Error{Message: val}, true
+		case *Error:
+			return val, true
+		}
+	case `generic`:
+		return v, true
+	}
+	return nil, false
+}
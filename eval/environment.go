@@ -0,0 +1,87 @@
+package eval
+
+// environment holds the variables of a scope. Each variable
+// is stored in a cell so closures can share it with the
+// scope it was defined in
+type environment struct {
+	parent *environment
+	values map[string]*Value
+
+	// function is set on the scope holding the parameters of
+	// a function call. deferred holds the calls deferred in it
+	function bool
+	deferred []func()
+}
+
+// newEnvironment returns a scope nested in [parent]
+func newEnvironment(parent *environment) *environment {
+	return &environment{
+		parent: parent,
+		values: map[string]*Value{},
+	}
+}
+
+// define creates a variable in the current scope
+func (e *environment) define(name string, value Value) {
+	// `_` discards values
+	if name == `_` {
+		return
+	}
+	e.values[name] = &value
+}
+
+// cell returns the cell of a variable from the closest
+// scope it was defined in and true if it was found
+func (e *environment) cell(name string) (*Value, bool) {
+	for env := e; env != nil; env = env.parent {
+		if c, ok := env.values[name]; ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// lookup returns the value of a variable from the closest
+// scope it was defined in and true if it was found
+func (e *environment) lookup(name string) (Value, bool) {
+	if c, ok := e.cell(name); ok {
+		return *c, true
+	}
+	return nil, false
+}
+
+// assign updates a variable in the closest scope it was
+// defined in. It returns false if the variable doesn't exist
+func (e *environment) assign(name string, value Value) bool {
+	if c, ok := e.cell(name); ok {
+		*c = value
+		return true
+	}
+	return false
+}
+
+// deferCall adds [f] to the calls deferred by the
+// function call enclosing the current scope
+func (e *environment) deferCall(f func()) {
+	for env := e; env != nil; env = env.parent {
+		if env.function {
+			env.deferred = append(env.deferred, f)
+			return
+		}
+	}
+	report(`defer is not in a function`)
+}
+
+// runDeferred runs the calls deferred in a function call in the
+// reverse order they were deferred. The remaining calls still
+// run if one of them fails
+func (e *environment) runDeferred() {
+	n := len(e.deferred)
+	if n == 0 {
+		return
+	}
+	f := e.deferred[n-1]
+	e.deferred = e.deferred[:n-1]
+	defer e.runDeferred()
+	f()
+}
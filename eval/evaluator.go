@@ -0,0 +1,350 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/resolver"
+	"github.com/amupitan/hero/types"
+)
+
+// signal represents a change in control flow
+type signal int
+
+const (
+	// returnSignal is raised by a return statement
+	returnSignal signal = iota + 1
+
+	// breakSignal is raised by a break statement
+	breakSignal
+
+	// continueSignal is raised by a continue statement
+	continueSignal
+)
+
+// control carries a control flow [signal] up from the
+// statement that raised it to the statement that handles it
+type control struct {
+	signal signal
+
+	// values holds the values of a return statement
+	values []Value
+
+	// label is the name of the loop a break or continue targets
+	label string
+}
+
+// Evaluator evaluates a parsed program
+type Evaluator struct {
+	globals *environment
+
+	// Stdout is where print and println write to
+	Stdout io.Writer
+
+	// Context stops the evaluation when it is done if it is set
+	Context context.Context
+
+	// Limits bounds the resources used by the evaluation
+	Limits Limits
+
+	// Allowed holds the capabilities the program can use. Calls to
+	// builtin functions needing other capabilities are runtime
+	// errors. All capabilities can be used if it is nil
+	Allowed map[types.Capability]bool
+
+	// steps counts the steps of the current run or call, depth
+	// counts the nested calls and pos is the position of the last
+	// call, operator or loop evaluated
+	steps, depth int
+	pos          lx.Token
+}
+
+// New returns a new evaluator. The builtin functions are defined
+// in a scope enclosing the globals so programs can redefine them
+func New() *Evaluator {
+	builtins := newEnvironment(nil)
+	for name, sig := range types.Functions {
+		builtins.define(name, &Builtin{Func: ast.FunctionOf(name, sig), call: natives[name], capability: types.Capabilities[name]})
+	}
+	return &Evaluator{
+		globals: newEnvironment(builtins),
+		Stdout:  os.Stdout,
+	}
+}
+
+// Run evaluates the program in [r] and returns the value of a
+// top-level return statement if there is one, or the runtime
+// error that stopped evaluation. A program that exceeds its limits
+// is stopped with a *LimitError
+func (e *Evaluator) Run(r *core.Runtime) (result Value, err error) {
+	program, ok := r.Body.(*ast.Program)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf(`expected a program but found %s`, r.Body)}
+	}
+
+	resolver.Resolve(program)
+	return e.Exec(program)
+}
+
+// Exec evaluates a program that has already been resolved. Unlike
+// Run it doesn't modify the program, so a program can be executed
+// by several evaluators at once
+func (e *Evaluator) Exec(program *ast.Program) (result Value, err error) {
+	defer recoverError(&err)
+	e.steps, e.pos = 0, lx.Token{}
+
+	if c := e.exec_block(program.Body, e.globals); c != nil && c.signal == returnSignal {
+		result = returnValue(c.values)
+	}
+	return result, nil
+}
+
+// Call calls the function named [name] with [args] and returns
+// its result or the runtime error that stopped it. The arguments
+// are checked against the types of the parameters of the function
+// since they weren't checked statically. The steps of the call are
+// counted separately from those of previous runs and calls
+func (e *Evaluator) Call(name string, args ...Value) (result Value, err error) {
+	defer recoverError(&err)
+	e.steps, e.pos = 0, lx.Token{}
+
+	f, ok := e.globals.lookup(name)
+	if !ok {
+		report(`undefined: %s`, name)
+	}
+	if c, ok := f.(*Closure); ok {
+		checkArguments(c.Func, args)
+	}
+	return e.call(f, args, nil, false), nil
+}
+
+// checkArguments reports an error if the positional
+// arguments [args] don't match the parameters of [f]
+func checkArguments(f *ast.Function, args []Value) {
+	bound, err := f.BindArgs(len(args), nil, false)
+	if err != nil {
+		report(`%s`, err)
+	}
+	for i, param := range f.Parameters {
+		for _, arg := range bound[i] {
+			checkArgument(f, param.Type, args[arg])
+		}
+	}
+}
+
+// Define defines a global variable. It is used to
+// provide values to a program before it is run
+func (e *Evaluator) Define(name string, v Value) {
+	e.globals.define(name, v)
+}
+
+// Global returns the value of a global variable and
+// true if it is defined
+func (e *Evaluator) Global(name string) (Value, bool) {
+	if c, ok := e.globals.values[name]; ok {
+		return *c, true
+	}
+	return nil, false
+}
+
+// exec_block executes the statements of a block in [env] and
+// returns the control flow change that stopped it, if any
+func (e *Evaluator) exec_block(b *ast.Block, env *environment) *control {
+	// every loop iteration and call executes a block
+	e.step()
+	for _, s := range b.Statements {
+		e.step()
+		if c := e.exec_statement(s, env); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// exec_statement executes any statement
+func (e *Evaluator) exec_statement(s core.Statement, env *environment) *control {
+	switch stmt := s.(type) {
+	case *ast.Function:
+		if stmt.Lambda {
+			// a lambda on its own has no effect
+			return nil
+		}
+		// the function is defined before its closure is
+		// created so it can capture itself
+		env.define(stmt.Name, nil)
+		env.assign(stmt.Name, e.newClosure(stmt, env))
+	case *ast.Definition:
+		e.exec_definition(stmt, env)
+	case *ast.MultiAssignment:
+		e.exec_multi_assignment(stmt, env)
+	case *ast.Block:
+		return e.exec_block(stmt, newEnvironment(env))
+	case *ast.If:
+		return e.exec_if(stmt, env)
+	case *ast.Switch:
+		return e.exec_switch(stmt, env)
+	case *ast.ForLoop:
+		return e.exec_for_loop(stmt, env)
+	case *ast.RangeLoop:
+		return e.exec_range_loop(stmt, env)
+	case *ast.Return:
+		return e.exec_return(stmt, env)
+	case *ast.Break:
+		return &control{signal: breakSignal, label: stmt.Label}
+	case *ast.Continue:
+		return &control{signal: continueSignal, label: stmt.Label}
+	case *ast.Try:
+		return e.exec_try(stmt, env)
+	case *ast.Throw:
+		e.exec_throw(stmt, env)
+	case *ast.Defer:
+		e.exec_defer(stmt, env)
+	case *ast.Interface:
+		// interfaces only constrain type parameters when checking
+	default:
+		e.eval_expression(stmt, env)
+	}
+	return nil
+}
+
+// eval_expression evaluates any expression
+func (e *Evaluator) eval_expression(exp core.Expression, env *environment) Value {
+	switch ex := exp.(type) {
+	case *ast.Atom:
+		return e.eval_atom(ex, env)
+	case *ast.Binary:
+		return e.eval_binary(ex, env)
+	case *ast.Conditional:
+		if e.eval_condition(ex.Condition, env) {
+			return e.eval_expression(ex.Then, env)
+		}
+		return e.eval_expression(ex.Else, env)
+	case *ast.Assignment:
+		return e.eval_assignment(ex, env)
+	case *ast.Call:
+		return e.eval_call(ex, env)
+	case *ast.Conversion:
+		return e.eval_conversion(ex, env)
+	case *ast.Function:
+		return e.newClosure(ex, env)
+	case *ast.List:
+		return e.eval_list(ex, env)
+	case *ast.Map:
+		return e.eval_map(ex, env)
+	case *ast.Selector:
+		return e.eval_selector(ex, env)
+	case *ast.Index:
+		return e.eval_index(ex, env)
+	}
+
+	reportAtPos(ast.Start(exp), `cannot evaluate %s`, exp)
+
+	// report panics so this will never be hit
+	return nil
+}
+
+// newClosure creates a closure of [f] in [env]. If the free
+// variables of [f] were recorded by the resolver, the closure only
+// keeps those variables and the globals alive rather than all of
+// [env]. Variables are captured by reference so changes made by the
+// closure or the scope that defined them are visible to both
+func (e *Evaluator) newClosure(f *ast.Function, env *environment) *Closure {
+	if f.Captures == nil {
+		return &Closure{Func: f, env: env}
+	}
+
+	captured := newEnvironment(e.globals)
+	for _, name := range f.Captures {
+		if c, ok := env.cell(name); ok {
+			captured.values[name] = c
+		}
+	}
+	return &Closure{Func: f, env: captured}
+}
+
+// call calls a function value with [args]. The final [names]
+// arguments are passed by name. If [spread] is set the final
+// positional argument is a list spread into a variadic parameter
+func (e *Evaluator) call(callee Value, args []Value, names []string, spread bool) Value {
+	if b, ok := callee.(*Builtin); ok {
+		return e.call_builtin(b, args, names, spread)
+	}
+
+	closure, ok := callee.(*Closure)
+	if !ok {
+		report(`cannot call non-function %s`, typeName(callee))
+	}
+
+	f := closure.Func
+	env := newEnvironment(closure.env)
+	env.function = true
+	defer env.runDeferred()
+	e.bind(f, args, names, spread, env)
+
+	if c := e.exec_block(f.Body, env); c != nil && c.signal == returnSignal {
+		return returnValue(c.values)
+	}
+	return nil
+}
+
+// bind defines the parameters of [f] in [env] from the arguments
+// of a call. The trailing arguments of a variadic function are
+// packed into a list. Parameters without arguments take their
+// default values, which are evaluated in [env] so they can refer
+// to the parameters before them
+func (e *Evaluator) bind(f *ast.Function, args []Value, names []string, spread bool, env *environment) {
+	// arguments that don't match the parameters
+	// are reported at the call being made
+	at := e.pos
+	bound, err := f.BindArgs(len(args)-len(names), names, spread)
+	if err != nil {
+		reportAt(at, `%s`, err)
+	}
+
+	for i, param := range f.Parameters {
+		switch {
+		case param.Variadic && spread:
+			list, ok := args[bound[i][0]].(*List)
+			if !ok {
+				reportAt(at, `cannot spread %s into %s`, typeName(args[bound[i][0]]), f.DisplayName())
+			}
+			env.define(param.Name, list)
+		case param.Variadic:
+			rest := &List{Elements: make([]Value, 0, len(bound[i]))}
+			for _, arg := range bound[i] {
+				rest.Elements = append(rest.Elements, args[arg])
+			}
+			env.define(param.Name, rest)
+		case bound[i] == nil:
+			env.define(param.Name, single(e.eval_expression(param.Default, env)))
+		default:
+			env.define(param.Name, args[bound[i][0]])
+		}
+	}
+}
+
+// returnValue returns the value of a function call
+// from the values of its return statement
+func returnValue(values []Value) Value {
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return values[0]
+	}
+	return Tuple(values)
+}
+
+// single reports an error if [v] holds multiple values
+// in a context where only one value can be used
+func single(v Value) Value {
+	if t, ok := v.(Tuple); ok {
+		report(`%d values used as a single value`, len(t))
+	}
+	return v
+}
@@ -0,0 +1,1638 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/types"
+)
+
+// run parses and evaluates [input] and returns
+// the value of its top-level return
+func run(input string) (v Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	return New().Run(parser.New(input).Parse())
+}
+
+func TestEvaluator_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name:  `arithmetic`,
+			input: `return 1 + 2 * 3`,
+			want:  int64(7),
+		},
+		{
+			name: `definitions and assignments`,
+			input: `
+			var x int
+			y := 4
+			x = y * 2
+			x += 1
+			x++
+			return x`,
+			want: int64(10),
+		},
+		{
+			name: `function call`,
+			input: `
+			func add(x, y int) int {
+				return x + y
+			}
+			return add(2, -3)`,
+			want: int64(-1),
+		},
+		{
+			name: `if else-if else`,
+			input: `
+			x := 5
+			var s string
+			if x < 3 {
+				s = "small"
+			} else if x < 10 {
+				s = "medium"
+			} else {
+				s = "large"
+			}
+			return s`,
+			want: `medium`,
+		},
+		{
+			name: `for loop`,
+			input: `
+			total := 0
+			for i := 0; i < 5; i++ {
+				total += i
+			}
+			return total`,
+			want: int64(10),
+		},
+		{
+			name: `variadic function`,
+			input: `
+			func sum(nums ...int) int {
+				total := 0
+				for _, n in nums {
+					total += n
+				}
+				return total
+			}
+			xs := [4, 5]
+			return sum() + sum(1) + sum(1, 2, 3) * 10 + sum(xs...) * 100`,
+			want: int64(961),
+		},
+		{
+			name: `variadic function with leading parameters`,
+			input: `
+			func join(sep string, parts ...string) string {
+				s := ""
+				for i, p in parts {
+					if i > 0 {
+						s = s + sep
+					}
+					s = s + p
+				}
+				return s
+			}
+			return join("-", "a", "b", "c")`,
+			want: `a-b-c`,
+		},
+		{
+			name: `too few arguments to variadic function`,
+			input: `
+			func f(a int, rest ...int) {}
+			f()`,
+			wantErr: true,
+		},
+		{
+			name: `spread into non-variadic function`,
+			input: `
+			func f(a int) {}
+			f([1]...)`,
+			wantErr: true,
+		},
+		{
+			name: `spread non-list`,
+			input: `
+			func f(rest ...int) {}
+			x := 1
+			f(x...)`,
+			wantErr: true,
+		},
+		{
+			name: `default and named arguments`,
+			input: `
+			func connect(host string, port int = 8080, secure bool = port == 443) string {
+				s := host + ":" + "x" * (port / 1000)
+				if secure {
+					s = s + "!"
+				}
+				return s
+			}
+			return connect("a") + connect("b", port: 9000) + connect(port: 443, host: "c")`,
+			want: `a:xxxxxxxxb:xxxxxxxxxc:!`,
+		},
+		{
+			name: `defaults can use captured variables`,
+			input: `
+			base := 10
+			add := func(x int, y int = base) int { return x + y }
+			return add(1) + add(1, y: 1)`,
+			want: int64(13),
+		},
+		{
+			name: `unknown named argument`,
+			input: `
+			func f(x int = 1) {}
+			f(y: 2)`,
+			wantErr: true,
+		},
+		{
+			name: `duplicate argument`,
+			input: `
+			func f(x int = 1) {}
+			f(1, x: 2)`,
+			wantErr: true,
+		},
+		{
+			name: `missing required argument`,
+			input: `
+			func f(x int, y int = 1) {}
+			f(y: 2)`,
+			wantErr: true,
+		},
+		{
+			name: `null and null coalescing`,
+			input: `
+			var name string? = null
+			var other string?
+			greeting := "hi " + (name ?? other ?? "anon")
+			if name == null {
+				name = "x"
+			}
+			return greeting + " " + name`,
+			want: `hi anon x`,
+		},
+		{
+			name: `null coalescing short-circuits`,
+			input: `
+			calls := 0
+			f := func() int {
+				calls++
+				return 1
+			}
+			x := 5 ?? f()
+			return calls`,
+			want: int64(0),
+		},
+		{
+			name: `field access on null`,
+			input: `
+			var cfg string?
+			return cfg.name`,
+			wantErr: true,
+		},
+		{
+			name:    `undefined variable`,
+			input:   `return x`,
+			wantErr: true,
+		},
+		{
+			name:    `mixed number types`,
+			input:   `return 1 + 2.5`,
+			wantErr: true,
+		},
+		{
+			name:    `integer division by zero`,
+			input:   `return 1 / 0`,
+			wantErr: true,
+		},
+		{
+			name: `bitwise operators`,
+			input: `
+			x := (12 & 10) * 1000 + (12 | 10) * 10 + (12 ^ 10)
+			return x`,
+			want: int64(8146),
+		},
+		{
+			name:  `shifts`,
+			input: `return 1 << 4 + 1 >> 1`,
+			want:  int64(16),
+		},
+		{
+			name: `complement and compound assignments`,
+			input: `
+			x := 5
+			flags := ~x
+			flags &= 255
+			flags |= 1
+			flags ^= 2
+			return flags`,
+			want: int64(249),
+		},
+		{
+			name:  `returned unary expressions`,
+			input: `return ~5, -5, !true`,
+			want:  Tuple{int64(-6), int64(-5), false},
+		},
+		{
+			name: `generic functions`,
+			input: `
+			interface Number { int | float }
+			func first<T>(xs list[T]) T {
+				return xs[0]
+			}
+			func sum<T Number>(total T, nums ...T) T {
+				for _, n in nums {
+					total += n
+				}
+				return total
+			}
+			var empty list[string]
+			return sum(1, 2, 3), sum(1.5, 2.5), first(["hi"]), empty`,
+			want: Tuple{int64(6), float64(4), `hi`, &List{}},
+		},
+		{
+			name: `conversions`,
+			input: `
+			x := 7
+			half := float(x) / 2.0
+			n := int("42") + int(2.9) + int('a')
+			s := string('h') + string(1) + string(true) + string(half)
+			neg := -int("5")
+			return half, n, s, rune("z"), rune(97), bool("true"), neg`,
+			want: Tuple{float64(3.5), int64(141), `h1true3.5`, 'z', 'a', true, int64(-5)},
+		},
+		{
+			name:    `invalid int string`,
+			input:   `return int("4x2")`,
+			wantErr: true,
+		},
+		{
+			name:    `invalid rune string`,
+			input:   `return rune("ab")`,
+			wantErr: true,
+		},
+		{
+			name:    `conversion of null`,
+			input:   `return float(null)`,
+			wantErr: true,
+		},
+		{
+			name: `switch`,
+			input: `
+			func kind(c rune) string {
+				switch c {
+				case 'a', 'e', 'i', 'o', 'u':
+					return "vowel"
+				case 'a'..'z':
+					return "consonant"
+				case '0'..'9':
+					return "digit"
+				default:
+					return "other"
+				}
+			}
+			s := ""
+			for i := 0; i < 6; i++ {
+				switch i {
+				case 0..1:
+					s += "low "
+				case 4:
+					continue
+				case 5:
+					break
+				default:
+					s += "mid "
+				}
+			}
+			return kind('e'), kind('q'), kind('7'), kind('!'), s`,
+			want: Tuple{`vowel`, `consonant`, `digit`, `other`, `low low mid mid `},
+		},
+		{
+			name: `break in switch`,
+			input: `
+			s := ""
+			outer:
+			for i := 0; i < 5; i++ {
+				switch i {
+				case 1:
+					break
+				case 3:
+					break outer
+				}
+				s += string(i)
+			}
+			return s`,
+			want: `012`,
+		},
+		{
+			name: `conditionals and if definitions`,
+			input: `
+			func abs(x int) int {
+				return x < 0 ? -x : x
+			}
+			s := ""
+			if n := abs(-4); n > 5 {
+				s = "big"
+			} else if m := n * 2; m > 5 {
+				s = "doubled " + string(m)
+			}
+			return abs(-3), abs(2), s`,
+			want: Tuple{int64(3), int64(2), `doubled 8`},
+		},
+		{
+			name:    `negative shift count`,
+			input:   `return 1 << (0 - 1)`,
+			wantErr: true,
+		},
+		{
+			name:    `bitwise operator on floats`,
+			input:   `return 1.5 & 2.5`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_exec_range_loop(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name: `list - index and value`,
+			input: `
+			s := ""
+			for i, v in ["a", "b", "c"] {
+				s = s + v
+				s = s + "a" * i
+			}
+			return s`,
+			want: `abacaa`,
+		},
+		{
+			name: `list - index only`,
+			input: `
+			total := 0
+			for i in [7, 7, 7] {
+				total += i
+			}
+			return total`,
+			want: int64(3),
+		},
+		{
+			name: `list - discarded index`,
+			input: `
+			total := 0
+			for _, v in [1, 2, 3] {
+				total += v
+			}
+			return total`,
+			want: int64(6),
+		},
+		{
+			name: `map - key and value in insertion order`,
+			input: `
+			s := ""
+			for k, v in ["z": "1", "a": "2"] {
+				s = s + k + v
+			}
+			return s`,
+			want: `z1a2`,
+		},
+		{
+			name: `map - field access`,
+			input: `
+			cfg := ["entries": [1: 10, 2: 20]]
+			total := 0
+			for k, v in cfg.entries {
+				total += k * v
+			}
+			return total`,
+			want: int64(50),
+		},
+		{
+			name: `string - rune positions`,
+			input: `
+			positions := 0
+			found := false
+			for i, r in "h爱llo" {
+				positions += i
+				if r == '爱' {
+					found = true
+				}
+			}
+			return found && positions == 10`,
+			want: true,
+		},
+		{
+			name: `call result`,
+			input: `
+			func getItems() int {
+				return [4, 5]
+			}
+			total := 0
+			for _, v in getItems() {
+				total += v
+			}
+			return total`,
+			want: int64(9),
+		},
+		{
+			name: `return from loop body`,
+			input: `
+			func find(xs int, x int) int {
+				for i, v in xs {
+					if v == x {
+						return i
+					}
+				}
+				return 0 - 1
+			}
+			return find([3, 4, 5], 5)`,
+			want: int64(2),
+		},
+		{
+			name:    `non-iterable`,
+			input:   `for i in 5 {}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.exec_range_loop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.exec_range_loop() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_break_and_continue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{
+			name: `break`,
+			input: `
+			i := 0
+			for {
+				if i == 3 {
+					break
+				}
+				i++
+			}
+			return i`,
+			want: int64(3),
+		},
+		{
+			name: `continue runs post iteration`,
+			input: `
+			total := 0
+			for i := 0; i < 6; i++ {
+				if i % 2 == 0 {
+					continue
+				}
+				total += i
+			}
+			return total`,
+			want: int64(9),
+		},
+		{
+			name: `break in range loop`,
+			input: `
+			s := ""
+			for _, c in "hero" {
+				if c == 'r' {
+					break
+				}
+				s = s + "."
+			}
+			return s`,
+			want: `..`,
+		},
+		{
+			name: `labeled break`,
+			input: `
+			count := 0
+			outer:
+			for i in [0, 1, 2] {
+				for j in [0, 1, 2] {
+					if j == 2 {
+						continue outer
+					}
+					if i == 2 {
+						break outer
+					}
+					count++
+				}
+			}
+			return count`,
+			want: int64(4),
+		},
+		{
+			name: `unlabeled break only stops innermost loop`,
+			input: `
+			count := 0
+			for i := 0; i < 3; i++ {
+				for {
+					break
+				}
+				count++
+			}
+			return count`,
+			want: int64(3),
+		},
+		{
+			name: `return from nested loops`,
+			input: `
+			func find() int {
+				for i := 0; i < 10; i++ {
+					for j := 0; j < 10; j++ {
+						if i * j == 12 {
+							return i * 10 + j
+						}
+					}
+				}
+				return 0
+			}
+			return find()`,
+			want: int64(26),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if err != nil {
+				t.Fatalf("Evaluator.Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_postfix(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name: `field access chain`,
+			input: `
+			a := ["b": ["c": 42]]
+			return a.b.c`,
+			want: int64(42),
+		},
+		{
+			name: `field access on index`,
+			input: `
+			list := [["name": "hero"]]
+			return list[0].name`,
+			want: `hero`,
+		},
+		{
+			name: `method call on call result`,
+			input: `
+			func f(x int) map[string]func(int) int {
+				return ["g": func(y int) int { return x * y }]
+			}
+			return f(3).g(4)`,
+			want: int64(12),
+		},
+		{
+			name: `call on call result`,
+			input: `
+			func makeAdder(x int) func(int) int {
+				return func(y int) int { return x + y }
+			}
+			return makeAdder(1)(2)`,
+			want: int64(3),
+		},
+		{
+			name:  `index into string`,
+			input: `return "hero"[2]`,
+			want:  'r',
+		},
+		{
+			name: `negated index`,
+			input: `
+			x := ![true, false][1]
+			return x`,
+			want: true,
+		},
+		{
+			name:    `index out of range`,
+			input:   `return [1, 2][2]`,
+			wantErr: true,
+		},
+		{
+			name:    `missing map key`,
+			input:   `return ["a": 1]["b"]`,
+			wantErr: true,
+		},
+		{
+			name:    `missing field`,
+			input:   `return ["a": 1].b`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_closures(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name: `returned lambda keeps its environment alive`,
+			input: `
+			func makeCounter() func() int {
+				count := 0
+				return func() int {
+					count++
+					return count
+				}
+			}
+			counter := makeCounter()
+			counter()
+			counter()
+			return counter()`,
+			want: int64(3),
+		},
+		{
+			name: `counters have separate environments`,
+			input: `
+			func makeCounter() func() int {
+				count := 0
+				return func() int {
+					count++
+					return count
+				}
+			}
+			a := makeCounter()
+			b := makeCounter()
+			a()
+			a()
+			return b()`,
+			want: int64(1),
+		},
+		{
+			name: `variables are captured by reference`,
+			input: `
+			x := 1
+			get := func() int { return x }
+			x = 5
+			return get()`,
+			want: int64(5),
+		},
+		{
+			name: `lambda assignments are visible outside`,
+			input: `
+			total := 0
+			add := func(n int) { total += n }
+			add(2)
+			add(3)
+			return total`,
+			want: int64(5),
+		},
+		{
+			name: `closures share captured variables`,
+			input: `
+			func pair() map[string]func {
+				n := 0
+				return ["inc": func() { n++ }, "get": func() int { return n }]
+			}
+			p := pair()
+			p.inc()
+			p.inc()
+			return p.get()`,
+			want: int64(2),
+		},
+		{
+			name: `nested recursive function`,
+			input: `
+			func run() int {
+				func fib(n int) int {
+					if n < 2 {
+						return n
+					}
+					return fib(n - 1) + fib(n - 2)
+				}
+				return fib(10)
+			}
+			return run()`,
+			want: int64(55),
+		},
+		{
+			name: `top-level functions can call functions defined after them`,
+			input: `
+			func a() int {
+				return b()
+			}
+			func b() int {
+				return 7
+			}
+			return a()`,
+			want: int64(7),
+		},
+		{
+			name: `uncaptured locals are not visible`,
+			input: `
+			func run() int {
+				f := func() int { return later }
+				later := 1
+				return f()
+			}
+			return run()`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_multiple_returns(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name: `destructure call`,
+			input: `
+			func divmod(a, b int) (int, int) {
+				return a / b, a % b
+			}
+			q, r := divmod(7, 2)
+			return q * 10 + r`,
+			want: int64(31),
+		},
+		{
+			name: `swap`,
+			input: `
+			a := 1
+			b := 2
+			a, b = b, a
+			return a * 10 + b`,
+			want: int64(21),
+		},
+		{
+			name: `discard`,
+			input: `
+			func divmod(a, b int) (int, int) {
+				return a / b, a % b
+			}
+			_, r := divmod(7, 2)
+			return r`,
+			want: int64(1),
+		},
+		{
+			name: `assign to captured variables`,
+			input: `
+			lo := 0
+			hi := 0
+			set := func(a, b int) { lo, hi = a, b }
+			set(3, 4)
+			return lo + hi`,
+			want: int64(7),
+		},
+		{
+			name:  `top-level return of multiple values`,
+			input: `return 1, "a"`,
+			want:  Tuple{int64(1), `a`},
+		},
+		{
+			name: `too few values returned`,
+			input: `
+			func one() int {
+				return 1
+			}
+			a, b := one()`,
+			wantErr: true,
+		},
+		{
+			name: `multiple values in single value context`,
+			input: `
+			func two() (int, int) {
+				return 1, 2
+			}
+			a := two()`,
+			wantErr: true,
+		},
+		{
+			name:    `assign to undefined variable`,
+			input:   `a, b = 1, 2`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_error_positions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  `conversion`,
+			input: "x := 1\ny := 2 + int(\"abc\")",
+			want:  `2:10: cannot convert "abc" to int`,
+		},
+		{
+			name:  `index out of range`,
+			input: "xs := [1, 2]\ny := xs[5]",
+			want:  `2:9: index 5 out of range with length 2`,
+		},
+		{
+			name:  `missing key`,
+			input: "m := [\"a\": 1]\ny := m[\"b\"]",
+			want:  `2:8: key b not found in map`,
+		},
+		{
+			name:  `undefined name`,
+			input: "x := 1\ny := x + z",
+			want:  `2:10: undefined: z`,
+		},
+		{
+			name:  `undefined assignment`,
+			input: "x := 1\n  z = x",
+			want:  `2:3: undefined: z`,
+		},
+		{
+			name:  `bad operand`,
+			input: "s := \"a\"\ny := -s",
+			want:  `2:7: cannot specify sign of string`,
+		},
+		{
+			name:  `bad condition`,
+			input: "x := 1\nif x {}",
+			want:  `2:4: x is used as a condition but is int not bool`,
+		},
+		{
+			name:  `non-function call`,
+			input: "x := 1\ny := x()",
+			want:  `2:6: cannot call non-function int`,
+		},
+		{
+			name:  `builtin error`,
+			input: "x := 1\ny := len(x)",
+			want:  "2:6: invalid argument for len: int has no length\n\tin len called at 2:6",
+		},
+		{
+			name:  `argument count`,
+			input: "func f(a int) {}\nf(1, 2)",
+			want:  "2:1: f expects 1 arguments but received 2\n\tin f called at 2:1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(tt.input)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Evaluator.Run() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_try(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name: `catch a thrown string`,
+			input: `
+			x := 0
+			try {
+				throw "boom"
+				x = 1
+			} catch err {
+				return err.message
+			}
+			return x`,
+			want: `boom`,
+		},
+		{
+			name: `no error skips the catch`,
+			input: `
+			x := 0
+			try {
+				x = 1
+			} catch {
+				x = 2
+			}
+			return x`,
+			want: int64(1),
+		},
+		{
+			name: `catch a runtime error`,
+			input: `
+			zero := 0
+			try {
+				x := 1 / zero
+			} catch err {
+				return err.message
+			}`,
+			want: `integer division by zero`,
+		},
+		{
+			name: `catch an error thrown by a call`,
+			input: `
+			func check(n int) int {
+				if n < 0 {
+					throw error("negative")
+				}
+				return n
+			}
+			try {
+				check(-1)
+			} catch e {
+				return e.message
+			}`,
+			want: `negative`,
+		},
+		{
+			name: `rethrow`,
+			input: `
+			try {
+				try {
+					throw "inner"
+				} catch err {
+					throw err
+				}
+			} catch err {
+				return err.message + " caught"
+			}`,
+			want: `inner caught`,
+		},
+		{
+			name: `return from try`,
+			input: `
+			func f() int {
+				try {
+					return 1
+				} catch {
+					return 2
+				}
+				return 3
+			}
+			return f()`,
+			want: int64(1),
+		},
+		{
+			name: `catch variable is scoped to the catch`,
+			input: `
+			try { throw "x" } catch err {}
+			return err`,
+			wantErr: true,
+		},
+		{
+			name:    `uncaught error`,
+			input:   `throw "boom"`,
+			wantErr: true,
+		},
+		{
+			name:    `throw a non-error`,
+			input:   `throw 1`,
+			wantErr: true,
+		},
+		{
+			name: `unknown error field`,
+			input: `
+			try { throw "x" } catch err {
+				return err.code
+			}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_stack_trace(t *testing.T) {
+	_, err := run("func inner() {\n\tthrow \"boom\"\n}\nfunc outer() {\n\tinner()\n}\nouter()")
+	rerr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Evaluator.Run() error = %v, want an *Error", err)
+	}
+	want := "2:2: boom\n\tin inner called at 5:2\n\tin outer called at 7:1"
+	if got := rerr.Error(); got != want {
+		t.Errorf("Error.Error() = %q, want %q", got, want)
+	}
+}
+
+func TestEvaluator_rethrow_trace(t *testing.T) {
+	got, err := run(`
+	func inner() {
+		throw "boom"
+	}
+	func rethrow(err error) {
+		throw err
+	}
+	first := error("none")
+	try {
+		inner()
+	} catch err {
+		first = err
+	}
+	try {
+		rethrow(first)
+	} catch err {
+		if err.message != "boom" {
+			throw "rethrown " + err.message
+		}
+	}
+	return first`)
+	if err != nil {
+		t.Fatalf("Evaluator.Run() error = %v", err)
+	}
+	want := "3:3: boom\n\tin inner called at 10:3"
+	if got := got.(*Error).Error(); got != want {
+		t.Errorf("Error.Error() = %q, want %q", got, want)
+	}
+}
+
+func TestEvaluator_defer(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name: `deferred calls run in reverse order`,
+			input: `
+			log := ""
+			func f() {
+				defer func() { log = log + "a" }()
+				defer func() { log = log + "b" }()
+				log = log + "body "
+			}
+			f()
+			return log`,
+			want: `body ba`,
+		},
+		{
+			name: `deferred calls run on early return`,
+			input: `
+			log := ""
+			func f(n int) int {
+				defer func() { log = log + "done" }()
+				if n > 0 {
+					return n
+				}
+				log = log + "zero "
+				return 0
+			}
+			return f(1), log`,
+			want: Tuple{int64(1), `done`},
+		},
+		{
+			name: `arguments are evaluated when deferred`,
+			input: `
+			log := ""
+			func add(s string) {
+				log = log + s
+			}
+			func f() {
+				s := "first"
+				defer add(s)
+				s = "second"
+			}
+			f()
+			return log`,
+			want: `first`,
+		},
+		{
+			name: `return values are evaluated before deferred calls`,
+			input: `
+			func f() int {
+				x := 1
+				defer func() { x = 2 }()
+				return x
+			}
+			return f()`,
+			want: int64(1),
+		},
+		{
+			name: `deferred calls run when an error unwinds`,
+			input: `
+			log := ""
+			func f() {
+				defer func() { log = log + "cleanup " }()
+				throw "boom"
+			}
+			try {
+				f()
+			} catch err {
+				log = log + err.message
+			}
+			return log`,
+			want: `cleanup boom`,
+		},
+		{
+			name: `a failing deferred call doesn't stop the others`,
+			input: `
+			log := ""
+			func f() {
+				defer func() { log = log + "a" }()
+				defer func() { throw "b" }()
+			}
+			try {
+				f()
+			} catch err {
+				log = log + err.message
+			}
+			return log`,
+			want: `ab`,
+		},
+		{
+			name: `deferred calls belong to their own function`,
+			input: `
+			log := ""
+			func f() {
+				defer func() { log = log + "f" }()
+				func() {
+					defer func() { log = log + "lambda " }()
+				}()
+				log = log + "body "
+			}
+			f()
+			return log`,
+			want: `lambda body f`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_builtins(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name:  `len`,
+			input: `return len("héllo"), len([1, 2]), len(["a": 1])`,
+			want:  Tuple{int64(5), int64(2), int64(1)},
+		},
+		{
+			name: `append copies the list`,
+			input: `
+			xs := [1]
+			ys := append(xs, 2, 3)
+			return len(xs), ys`,
+			want: Tuple{int64(1), &List{Elements: []Value{int64(1), int64(2), int64(3)}}},
+		},
+		{
+			name:  `append spread`,
+			input: `return append([1], [2, 3]...)`,
+			want:  &List{Elements: []Value{int64(1), int64(2), int64(3)}},
+		},
+		{
+			name:  `strings`,
+			input: `return join(split("a,b,c", ","), "-"), contains("hero", "er"), upper("a"), lower("B"), trim("  x ")`,
+			want:  Tuple{`a-b-c`, true, `A`, `b`, `x`},
+		},
+		{
+			name: `math`,
+			input: `
+			n := 0 - 3
+			return abs(n), abs(-2.5), sqrt(16.0), pow(2.0, 3.0), floor(1.7)`,
+			want: Tuple{int64(3), 2.5, 4.0, 8.0, 1.0},
+		},
+		{
+			name:  `min and max`,
+			input: `return min(3, 1, 2), max("a", "c", "b"), max(1)`,
+			want:  Tuple{int64(1), `c`, int64(1)},
+		},
+		{
+			name:  `conversion helpers`,
+			input: `return str([1, 2]), parse_int("42"), parse_float("1.5")`,
+			want:  Tuple{`[1, 2]`, int64(42), 1.5},
+		},
+		{
+			name: `parse errors can be caught`,
+			input: `
+			try {
+				parse_int("x")
+			} catch err {
+				return err.message
+			}`,
+			want: `cannot parse "x" as int`,
+		},
+		{
+			name: `builtins can be redefined`,
+			input: `
+			func len(s string) int {
+				return 0
+			}
+			return len("abc")`,
+			want: int64(0),
+		},
+		{
+			name: `builtins are values`,
+			input: `
+			f := upper
+			return f("a")`,
+			want: `A`,
+		},
+		{
+			name:    `wrong argument type`,
+			input:   `upper(1)`,
+			wantErr: true,
+		},
+		{
+			name:    `argument doesn't satisfy constraint`,
+			input:   `min(true, false)`,
+			wantErr: true,
+		},
+		{
+			name:    `wrong number of arguments`,
+			input:   `contains("a")`,
+			wantErr: true,
+		},
+		{
+			name:    `len of a value without length`,
+			input:   `len(1)`,
+			wantErr: true,
+		},
+		{
+			name:  `random numbers`,
+			input: `return random() < 1.0, random_int(1)`,
+			want:  Tuple{true, int64(0)},
+		},
+		{
+			name:    `random int of a non-positive number`,
+			input:   `random_int(0)`,
+			wantErr: true,
+		},
+		{
+			name:  `getenv of an unset variable`,
+			input: `return getenv("HERO_TEST_UNSET")`,
+			want:  ``,
+		},
+		{
+			name:    `read a missing file`,
+			input:   `read_file("/hero/test/missing")`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_print(t *testing.T) {
+	out := &bytes.Buffer{}
+	e := New()
+	e.Stdout = out
+	if _, err := e.Run(parser.New(`print("a", 1)
+println(" b", [true])
+println()`).Parse()); err != nil {
+		t.Fatalf("Evaluator.Run() error = %v", err)
+	}
+	if want := "a 1 b [true]\n\n"; out.String() != want {
+		t.Errorf("Evaluator.Run() printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestNatives(t *testing.T) {
+	for name := range types.Functions {
+		if natives[name] == nil {
+			t.Errorf("builtin function %s is not implemented", name)
+		}
+	}
+}
+
+func TestEvaluator_limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		input  string
+		want   *LimitError
+	}{
+		{
+			name:   `infinite loop`,
+			limits: Limits{Steps: 100},
+			input:  "x := 1\nfor {}",
+			want:   &LimitError{Limit: `steps`, Max: 100, Line: 2, Column: 1},
+		},
+		{
+			name:   `within step limit`,
+			limits: Limits{Steps: 100},
+			input:  `for i := 0; i < 3; i++ {}`,
+		},
+		{
+			name:   `unbounded recursion`,
+			limits: Limits{CallDepth: 50},
+			input:  "func f(n int) int {\n\treturn f(n + 1)\n}\nf(0)",
+			want:   &LimitError{Limit: `call depth`, Max: 50, Line: 2, Column: 9},
+		},
+		{
+			name:   `recursion within call depth`,
+			limits: Limits{CallDepth: 50},
+			input: `
+			func f(n int) int {
+				if n == 0 {
+					return 0
+				}
+				m := n - 1
+				return f(m)
+			}
+			f(10)`,
+		},
+		{
+			name:  `default call depth`,
+			input: "func f(n int) int {\n\treturn f(n + 1)\n}\nf(0)",
+			want:  &LimitError{Limit: `call depth`, Max: DefaultCallDepth, Line: 2, Column: 9},
+		},
+		{
+			name:   `large list`,
+			limits: Limits{CollectionSize: 3},
+			input:  `xs := [1, 2, 3, 4]`,
+			want:   &LimitError{Limit: `collection size`, Max: 3, Line: 1, Column: 7},
+		},
+		{
+			name:   `large map`,
+			limits: Limits{CollectionSize: 1},
+			input:  "x := 1\nm := [\"a\": 1, \"b\": 2]",
+			want:   &LimitError{Limit: `collection size`, Max: 1, Line: 2, Column: 6},
+		},
+		{
+			name:   `growing string`,
+			limits: Limits{CollectionSize: 10},
+			input: `
+			s := "ab"
+			for {
+				s += s
+			}`,
+			want: &LimitError{Limit: `collection size`, Max: 10, Line: 4, Column: 5},
+		},
+		{
+			name:   `growing list`,
+			limits: Limits{CollectionSize: 3},
+			input: `
+			xs := [1]
+			for i := 0; i < 5; i++ {
+				xs = append(xs, i)
+			}`,
+			want: &LimitError{Limit: `collection size`, Max: 3, Line: 4, Column: 10},
+		},
+		{
+			name:   `repeated string`,
+			limits: Limits{CollectionSize: 10},
+			input:  `s := "ab" * 1000000000`,
+			want:   &LimitError{Limit: `collection size`, Max: 10, Line: 1, Column: 11},
+		},
+		{
+			name:   `limits can't be caught`,
+			limits: Limits{Steps: 100},
+			input: `
+			try {
+				for {}
+			} catch {}`,
+			want: &LimitError{Limit: `steps`, Max: 100, Line: 3, Column: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Limits = tt.limits
+			_, err := e.Run(parser.New(tt.input).Parse())
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Evaluator.Run() error = %v", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("Evaluator.Run() error = %#v, want %#v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_time_limit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	e := New()
+	e.Context = ctx
+	_, err := e.Run(parser.New(`for {}`).Parse())
+	if lerr, ok := err.(*LimitError); !ok || lerr.Limit != `time` {
+		t.Errorf("Evaluator.Run() error = %v, want a time limit error", err)
+	}
+
+	// a sleep is cut short by the deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	e.Context = ctx
+	start := time.Now()
+	_, err = e.Run(parser.New(`sleep(60000)`).Parse())
+	if lerr, ok := err.(*LimitError); !ok || lerr.Limit != `time` || time.Since(start) > time.Second {
+		t.Errorf("Evaluator.Run() error = %v, want a time limit error", err)
+	}
+}
+
+func TestEvaluator_files(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `hero`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, `f.txt`)
+	got, err := run(`write_file("` + path + `", "hello")
+	return read_file("` + path + `")`)
+	if err != nil {
+		t.Fatalf("Evaluator.Run() error = %v", err)
+	}
+	if got != `hello` {
+		t.Errorf("Evaluator.Run() = %v, want hello", got)
+	}
+}
+
+func TestEvaluator_capabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed map[types.Capability]bool
+		input   string
+		want    Value
+		wantErr string
+	}{
+		{
+			name:  `all capabilities allowed without an allowlist`,
+			input: `return now() > 0`,
+			want:  true,
+		},
+		{
+			name:    `disallowed call`,
+			allowed: map[types.Capability]bool{},
+			input:   `now()`,
+			wantErr: `call to now is not allowed: it needs the time capability`,
+		},
+		{
+			name:    `disallowed dynamic call`,
+			allowed: map[types.Capability]bool{types.Time: true},
+			input: `
+			f := random
+			f()`,
+			wantErr: `call to random is not allowed: it needs the random capability`,
+		},
+		{
+			name:    `allowed call`,
+			allowed: map[types.Capability]bool{types.Env: true},
+			input:   `return getenv("HERO_TEST_UNSET")`,
+			want:    ``,
+		},
+		{
+			name:    `builtins needing no capability`,
+			allowed: map[types.Capability]bool{},
+			input:   `return len("abc")`,
+			want:    int64(3),
+		},
+		{
+			name:    `redefined builtin`,
+			allowed: map[types.Capability]bool{},
+			input: `
+			func println(s string) string {
+				return s
+			}
+			return println("a")`,
+			want: `a`,
+		},
+		{
+			name:    `disallowed call caught`,
+			allowed: map[types.Capability]bool{},
+			input: `
+			try {
+				print("a")
+			} catch err {
+				return err.message
+			}`,
+			want: `call to print is not allowed: it needs the stdout capability`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Allowed = tt.allowed
+			got, err := e.Run(parser.New(tt.input).Parse())
+			if tt.wantErr != `` {
+				rerr, ok := err.(*Error)
+				if !ok || rerr.Message != tt.wantErr {
+					t.Fatalf("Evaluator.Run() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluator.Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluator.Run() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
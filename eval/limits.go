@@ -0,0 +1,153 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amupitan/hero/ast"
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// DefaultCallDepth is the call depth limit of evaluators whose
+// CallDepth is zero. Deeper recursion would overflow the Go stack
+// which crashes the host instead of returning an error
+const DefaultCallDepth = 10000
+
+// Limits bounds the resources a program can use. A limit that
+// is zero isn't enforced except for CallDepth. The wall-clock time
+// of a program is limited by the deadline of the evaluator's context
+type Limits struct {
+	// Steps is the maximum number of statements and blocks
+	// executed by a run of a program or a call into it
+	Steps int
+
+	// CallDepth is the maximum number of nested function calls. It
+	// is DefaultCallDepth if it is zero and isn't enforced if it is
+	// negative, which risks crashing the host on runaway recursion
+	CallDepth int
+
+	// CollectionSize is the maximum number of elements in a list,
+	// entries in a map or bytes in a string created by the program
+	CollectionSize int
+}
+
+// LimitError is the error returned when a program exceeds one of
+// its limits. Unlike runtime errors, it can't be caught by the program
+type LimitError struct {
+	// Limit is the name of the limit that was exceeded:
+	// steps, call depth, collection size or time
+	Limit string
+
+	// Max is the value of the limit. It is zero for the time limit
+	Max int
+
+	// Line and Column locate the last call, operator, literal or loop
+	// evaluated before the limit was exceeded if any was
+	Line, Column int
+}
+
+func (l *LimitError) Error() string {
+	msg := fmt.Sprintf(`exceeded %s limit`, l.Limit)
+	if l.Max > 0 {
+		msg = fmt.Sprintf(`exceeded %s limit of %d`, l.Limit, l.Max)
+	}
+	if l.Line > 0 {
+		return fmt.Sprintf("%d:%d: %s", l.Line, l.Column, msg)
+	}
+	return msg
+}
+
+// exceed stops the evaluation because the limit named [limit]
+// whose value is [max] was exceeded
+func (e *Evaluator) exceed(limit string, max int) {
+	panic(interrupt{&LimitError{Limit: limit, Max: max, Line: e.pos.Line, Column: e.pos.Column}})
+}
+
+// step counts a step of the evaluation and stops it if it has run
+// out of steps or its context is done. A context whose deadline
+// has passed stops the evaluation with the time limit
+func (e *Evaluator) step() {
+	e.steps++
+	if e.Limits.Steps > 0 && e.steps > e.Limits.Steps {
+		e.exceed(`steps`, e.Limits.Steps)
+	}
+
+	if e.Context == nil {
+		return
+	}
+	select {
+	case <-e.Context.Done():
+		if e.Context.Err() == context.DeadlineExceeded {
+			e.exceed(`time`, 0)
+		}
+		panic(interrupt{e.Context.Err()})
+	default:
+	}
+}
+
+// enter records a function call made at [at] and stops the
+// evaluation if it is nested too deeply. Calls to enter are
+// paired with calls to leave
+func (e *Evaluator) enter(at lx.Token) {
+	if at.Line > 0 {
+		e.pos = at
+	}
+	max := e.Limits.CallDepth
+	if max == 0 {
+		max = DefaultCallDepth
+	}
+	e.depth++
+	if max > 0 && e.depth > max {
+		e.depth--
+		e.exceed(`call depth`, max)
+	}
+}
+
+// leave records the return of the last call
+func (e *Evaluator) leave() {
+	e.depth--
+}
+
+// at records [pos] as the position of the evaluation
+// unless it is unknown
+func (e *Evaluator) at(pos ast.Pos) {
+	if pos.IsValid() {
+		e.pos = lx.Token{Line: pos.Line, Column: pos.Column}
+	}
+}
+
+// sized returns [v] and stops the evaluation if it is a
+// collection or string larger than the collection size limit
+func (e *Evaluator) sized(v Value) Value {
+	max := e.Limits.CollectionSize
+	if max == 0 {
+		return v
+	}
+
+	size := 0
+	switch val := v.(type) {
+	case *List:
+		size = len(val.Elements)
+	case *Map:
+		size = val.Len()
+	case string:
+		size = len(val)
+	}
+	if size > max {
+		e.exceed(`collection size`, max)
+	}
+	return v
+}
+
+// operate applies a binary operator like binaryOperation and stops
+// the evaluation if the result is larger than the collection size
+// limit. Repeated strings are checked before they are created
+func (e *Evaluator) operate(op lx.Token, left, right Value) Value {
+	e.pos = op
+	if s, ok := left.(string); ok && e.Limits.CollectionSize > 0 {
+		if n, ok := right.(int64); ok && n > 0 && int64(len(s)) > int64(e.Limits.CollectionSize)/n {
+			e.exceed(`collection size`, e.Limits.CollectionSize)
+		}
+	}
+	return e.sized(binaryOperation(op, left, right))
+}
@@ -0,0 +1,154 @@
+package eval
+
+import (
+	"strings"
+
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// binaryOperation applies the operator [op] to [left] and [right].
+// Both operands must have the same type
+func binaryOperation(op lx.Token, left, right Value) Value {
+	switch op.Type {
+	case lx.Equal:
+		return left == right
+	case lx.NotEqual:
+		return left != right
+	}
+
+	switch l := left.(type) {
+	case int64:
+		if r, ok := right.(int64); ok {
+			return intOperation(op, l, r)
+		}
+	case float64:
+		if r, ok := right.(float64); ok {
+			return floatOperation(op, l, r)
+		}
+	case string:
+		switch r := right.(type) {
+		case string:
+			return stringOperation(op, l, r)
+		case int64:
+			// a string can be repeated
+			if op.Type == lx.Times && r >= 0 {
+				return strings.Repeat(l, int(r))
+			}
+		}
+	case rune:
+		if r, ok := right.(rune); ok {
+			return runeOperation(op, l, r)
+		}
+	}
+
+	reportAt(op, `invalid operation: %s %s %s`, typeName(left), op.Value, typeName(right))
+
+	// reportAt panics so this will never be hit
+	return nil
+}
+
+// intOperation applies an arithmetic, bitwise or comparison operator to ints
+func intOperation(op lx.Token, l, r int64) Value {
+	switch op.Type {
+	case lx.Plus:
+		return l + r
+	case lx.Minus:
+		return l - r
+	case lx.Times:
+		return l * r
+	case lx.Div, lx.Mod:
+		if r == 0 {
+			reportAt(op, `integer division by zero`)
+		}
+		if op.Type == lx.Div {
+			return l / r
+		}
+		return l % r
+	case lx.BitAnd:
+		return l & r
+	case lx.BitOr:
+		return l | r
+	case lx.BitXor:
+		return l ^ r
+	case lx.BitLeftShift, lx.BitRightShift:
+		if r < 0 {
+			reportAt(op, `negative shift count %d`, r)
+		}
+		if op.Type == lx.BitLeftShift {
+			return l << uint64(r)
+		}
+		return l >> uint64(r)
+	case lx.LessThan:
+		return l < r
+	case lx.GreaterThan:
+		return l > r
+	case lx.LessThanOrEqual:
+		return l <= r
+	case lx.GreaterThanOrEqual:
+		return l >= r
+	}
+
+	reportAt(op, `invalid operation: int %s int`, op.Value)
+	return nil
+}
+
+// floatOperation applies an arithmetic or comparison operator to floats
+func floatOperation(op lx.Token, l, r float64) Value {
+	switch op.Type {
+	case lx.Plus:
+		return l + r
+	case lx.Minus:
+		return l - r
+	case lx.Times:
+		return l * r
+	case lx.Div:
+		return l / r
+	case lx.LessThan:
+		return l < r
+	case lx.GreaterThan:
+		return l > r
+	case lx.LessThanOrEqual:
+		return l <= r
+	case lx.GreaterThanOrEqual:
+		return l >= r
+	}
+
+	reportAt(op, `invalid operation: float %s float`, op.Value)
+	return nil
+}
+
+// stringOperation applies concatenation or a comparison operator to strings
+func stringOperation(op lx.Token, l, r string) Value {
+	switch op.Type {
+	case lx.Plus:
+		return l + r
+	case lx.LessThan:
+		return l < r
+	case lx.GreaterThan:
+		return l > r
+	case lx.LessThanOrEqual:
+		return l <= r
+	case lx.GreaterThanOrEqual:
+		return l >= r
+	}
+
+	reportAt(op, `invalid operation: string %s string`, op.Value)
+	return nil
+}
+
+// runeOperation applies a comparison operator to runes
+func runeOperation(op lx.Token, l, r rune) Value {
+	switch op.Type {
+	case lx.LessThan:
+		return l < r
+	case lx.GreaterThan:
+		return l > r
+	case lx.LessThanOrEqual:
+		return l <= r
+	case lx.GreaterThanOrEqual:
+		return l >= r
+	}
+
+	reportAt(op, `invalid operation: rune %s rune`, op.Value)
+	return nil
+}
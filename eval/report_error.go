@@ -0,0 +1,78 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// Error is a runtime error. Errors are also the values of
+// type error in hero, so they can be thrown and caught
+type Error struct {
+	Message      string
+	Line, Column int
+
+	// Trace holds the calls the error was thrown through,
+	// innermost first
+	Trace []Frame
+}
+
+// Frame is a call of Function at Line:Column
+type Frame struct {
+	Function     string
+	Line, Column int
+}
+
+// Error returns the message of the error located at its position
+// if it has one, followed by the calls it was thrown through
+func (e *Error) Error() string {
+	s := strings.Builder{}
+	if e.Line > 0 {
+		fmt.Fprintf(&s, "%d:%d: ", e.Line, e.Column)
+	}
+	s.WriteString(e.Message)
+	for _, f := range e.Trace {
+		fmt.Fprintf(&s, "\n\tin %s called at %d:%d", f.Function, f.Line, f.Column)
+	}
+	return s.String()
+}
+
+// report creates a runtime error with a formatted message and panics
+func report(format string, args ...interface{}) {
+	panic(&Error{Message: fmt.Sprintf(format, args...)})
+}
+
+// reportAt creates a runtime error at the position of
+// token [t] with a formatted message and panics
+func reportAt(t lx.Token, format string, args ...interface{}) {
+	panic(&Error{Message: fmt.Sprintf(format, args...), Line: t.Line, Column: t.Column})
+}
+
+// reportAtPos creates a runtime error at [pos]
+// with a formatted message and panics
+func reportAtPos(pos ast.Pos, format string, args ...interface{}) {
+	panic(&Error{Message: fmt.Sprintf(format, args...), Line: pos.Line, Column: pos.Column})
+}
+
+// interrupt stops the evaluation with an error that isn't a runtime
+// error of the program e.g. when its context is canceled. Unlike
+// runtime errors, interrupts can't be caught by try statements
+type interrupt struct {
+	err error
+}
+
+// recoverError stores the runtime error or interrupt that
+// stopped the evaluation in [err]. Other panics are repanicked
+func recoverError(err *error) {
+	switch rec := recover().(type) {
+	case nil:
+	case *Error:
+		*err = rec
+	case interrupt:
+		*err = rec.err
+	default:
+		panic(rec)
+	}
+}
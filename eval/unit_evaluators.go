@@ -0,0 +1,615 @@
+package eval
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// exec_definition defines a variable from a definition
+func (e *Evaluator) exec_definition(d *ast.Definition, env *environment) {
+	if d.Value == nil {
+		env.define(d.Name, zeroValue(d.Type))
+		return
+	}
+	env.define(d.Name, single(e.eval_expression(d.Value, env)))
+}
+
+// exec_multi_assignment defines or assigns multiple identifiers.
+// All values are evaluated before any identifier is updated so
+// values can be swapped e.g. a, b = b, a
+func (e *Evaluator) exec_multi_assignment(m *ast.MultiAssignment, env *environment) {
+	var values []Value
+	if len(m.Values) == 1 {
+		// a single value must be a call returning a value per identifier
+		v := e.eval_expression(m.Values[0], env)
+		t, ok := v.(Tuple)
+		if !ok {
+			t = Tuple{v}
+		}
+		if len(t) != len(m.Identifiers) {
+			reportAtPos(ast.Start(m), `assignment mismatch: %d variables but %s returns %d values`, len(m.Identifiers), m.Values[0], len(t))
+		}
+		values = t
+	} else {
+		values = make([]Value, 0, len(m.Values))
+		for _, v := range m.Values {
+			values = append(values, single(e.eval_expression(v, env)))
+		}
+	}
+
+	for i, name := range m.Identifiers {
+		switch {
+		case name == `_`:
+			// discarded value
+		case m.Define:
+			env.define(name, values[i])
+		case !env.assign(name, values[i]):
+			reportAtPos(m.Positions[i], `undefined: %s`, name)
+		}
+	}
+}
+
+// exec_return evaluates the values of a return statement
+func (e *Evaluator) exec_return(r *ast.Return, env *environment) *control {
+	values := make([]Value, 0, len(r.Values))
+	for _, v := range r.Values {
+		values = append(values, e.eval_expression(v, env))
+	}
+	return &control{signal: returnSignal, values: values}
+}
+
+// exec_if executes the first branch of an if statement
+// whose condition is true
+func (e *Evaluator) exec_if(i *ast.If, env *environment) *control {
+	for branch := i; branch != nil; branch = branch.Else {
+		// variables defined before a condition are visible
+		// in the rest of the if statement
+		if branch.Definition != nil {
+			env = newEnvironment(env)
+			e.exec_statement(branch.Definition, env)
+		}
+
+		// an else-only branch has no condition
+		if branch.Condition == nil || e.eval_condition(branch.Condition, env) {
+			return e.exec_block(branch.Body, newEnvironment(env))
+		}
+	}
+	return nil
+}
+
+// exec_switch executes the body of the first case that matches
+// the value of the switch or its default body if none matches
+func (e *Evaluator) exec_switch(s *ast.Switch, env *environment) *control {
+	v := single(e.eval_expression(s.Value, env))
+	for _, c := range s.Cases {
+		for _, value := range c.Values {
+			if e.matches(v, value, env) {
+				return switchControl(e.exec_block(c.Body, newEnvironment(env)))
+			}
+		}
+	}
+	if s.Default != nil {
+		return switchControl(e.exec_block(s.Default, newEnvironment(env)))
+	}
+	return nil
+}
+
+// switchControl returns the control flow change [c] raised by a
+// case to pass on to the statement enclosing the switch. Like in
+// Go, an unlabeled break only ends the switch
+func switchControl(c *control) *control {
+	if c != nil && c.signal == breakSignal && c.label == `` {
+		return nil
+	}
+	return c
+}
+
+// exec_try executes the body of a try statement. If an error is
+// thrown while running it, the catch body is executed with the
+// error bound to the name of the catch
+func (e *Evaluator) exec_try(t *ast.Try, env *environment) *control {
+	c, err := e.try_block(t.Body, env)
+	if err == nil {
+		return c
+	}
+
+	scope := newEnvironment(env)
+	if t.Name != `` && t.Name != `_` {
+		scope.define(t.Name, err)
+	}
+	return e.exec_block(t.Catch, scope)
+}
+
+// try_block executes [b] in a new scope and returns the
+// error thrown while executing it, if any
+func (e *Evaluator) try_block(b *ast.Block, env *environment) (c *control, err *Error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			var ok bool
+			if err, ok = rec.(*Error); !ok {
+				panic(rec)
+			}
+		}
+	}()
+	return e.exec_block(b, newEnvironment(env)), nil
+}
+
+// exec_throw throws an error. A string is thrown as the message
+// of a new error located at the throw statement and an error is
+// thrown as a copy
+func (e *Evaluator) exec_throw(t *ast.Throw, env *environment) {
+	switch v := single(e.eval_expression(t.Value, env)).(type) {
+	case string:
+		reportAt(t.Token, `%s`, v)
+	case *Error:
+		// the error is copied as the calls it is thrown through
+		// are added to its trace, which other references to a
+		// caught error shouldn't see
+		thrown := *v
+		thrown.Trace = make([]Frame, len(v.Trace))
+		copy(thrown.Trace, v.Trace)
+		if thrown.Line == 0 {
+			thrown.Line, thrown.Column = t.Token.Line, t.Token.Column
+		}
+		panic(&thrown)
+	default:
+		reportAt(t.Token, `cannot throw %s`, typeName(v))
+	}
+}
+
+// exec_defer evaluates the function and arguments of a deferred
+// call and delays the call till the enclosing function returns
+func (e *Evaluator) exec_defer(d *ast.Defer, env *environment) {
+	callee, args, names := e.eval_callee_and_args(d.Call, env)
+	env.deferCall(func() {
+		e.traced_call(d.Call, callee, args, names)
+	})
+}
+
+// matches returns true if [v] equals the case value [c] or
+// is between the bounds of [c] if it is a range
+func (e *Evaluator) matches(v Value, c core.Expression, env *environment) bool {
+	r, ok := c.(*ast.Range)
+	if !ok {
+		return v == single(e.eval_expression(c, env))
+	}
+	start, end := single(e.eval_expression(r.Start, env)), single(e.eval_expression(r.End, env))
+	return binaryOperation(lx.Token{Type: lx.GreaterThanOrEqual, Value: string(lx.GreaterThanOrEqual)}, v, start).(bool) &&
+		binaryOperation(lx.Token{Type: lx.LessThanOrEqual, Value: string(lx.LessThanOrEqual)}, v, end).(bool)
+}
+
+// exec_for_loop executes a for loop
+func (e *Evaluator) exec_for_loop(l *ast.ForLoop, env *environment) *control {
+	// variables defined before the loop live in their own scope
+	scope := newEnvironment(env)
+	if l.PreLoop != nil {
+		e.exec_statement(l.PreLoop, scope)
+	}
+
+	// a loop with no condition runs till it is stopped
+	for l.Condition == nil || e.eval_condition(l.Condition, scope) {
+		e.pos = l.Token
+		if stop, c := loopControl(l.Name, e.exec_block(l.Body, newEnvironment(scope))); stop {
+			return c
+		}
+
+		if l.PostIteration != nil {
+			e.eval_expression(l.PostIteration, scope)
+		}
+	}
+	return nil
+}
+
+// exec_range_loop executes a for-range loop. The first and second
+// identifiers are assigned as follows:
+//
+//	list: index, element
+//	map: key, value (in insertion order)
+//	string: rune position, rune
+func (e *Evaluator) exec_range_loop(l *ast.RangeLoop, env *environment) *control {
+	iterable := e.eval_expression(l.Iterable, env)
+
+	// iterate runs the body of the loop once
+	iterate := func(first, second Value) *control {
+		e.pos = l.Token
+		scope := newEnvironment(env)
+		scope.define(l.First, first)
+		if l.Second != `` {
+			scope.define(l.Second, second)
+		}
+		return e.exec_block(l.Body, scope)
+	}
+
+	switch it := iterable.(type) {
+	case *List:
+		// the elements are evaluated once so appending
+		// in the body doesn't affect the iteration
+		elements := it.Elements
+		for i := range elements {
+			if stop, c := loopControl(l.Name, iterate(int64(i), elements[i])); stop {
+				return c
+			}
+		}
+	case *Map:
+		keys := append([]Value(nil), it.Keys()...)
+		for _, key := range keys {
+			value, ok := it.Get(key)
+			if !ok {
+				continue
+			}
+			if stop, c := loopControl(l.Name, iterate(key, value)); stop {
+				return c
+			}
+		}
+	case string:
+		for i, r := range []rune(it) {
+			if stop, c := loopControl(l.Name, iterate(int64(i), r)); stop {
+				return c
+			}
+		}
+	default:
+		reportAtPos(ast.Start(l.Iterable), `cannot range over %s`, typeName(iterable))
+	}
+	return nil
+}
+
+// loopControl decides how the loop named [name] reacts to the
+// control flow change [c] raised by an iteration of its body.
+// It returns true if the loop should stop along with the control
+// flow change to pass on to the statement enclosing the loop
+func loopControl(name string, c *control) (bool, *control) {
+	if c == nil {
+		return false, nil
+	}
+
+	// returns and jumps to outer loops stop this loop
+	// and are handled further up
+	if c.signal == returnSignal || (c.label != `` && c.label != name) {
+		return true, c
+	}
+
+	return c.signal == breakSignal, nil
+}
+
+// eval_condition evaluates a condition which must be a bool
+func (e *Evaluator) eval_condition(exp core.Expression, env *environment) bool {
+	v := e.eval_expression(exp, env)
+	b, ok := v.(bool)
+	if !ok {
+		reportAtPos(ast.Start(exp), `%s is used as a condition but is %s not bool`, exp, typeName(v))
+	}
+	return b
+}
+
+// eval_atom evaluates a literal or identifier
+func (e *Evaluator) eval_atom(a *ast.Atom, env *environment) Value {
+	var v Value
+	switch a.Type {
+	case lx.Identifier:
+		var ok bool
+		if v, ok = env.lookup(a.Value); !ok {
+			reportAtPos(a.Pos, `undefined: %s`, a.Value)
+		}
+	case lx.Int:
+		n, err := strconv.ParseInt(a.Value, 10, 64)
+		if err != nil {
+			reportAtPos(a.Pos, `invalid int %s`, a.Value)
+		}
+		v = n
+	case lx.Float:
+		f, err := strconv.ParseFloat(a.Value, 64)
+		if err != nil {
+			reportAtPos(a.Pos, `invalid float %s`, a.Value)
+		}
+		v = f
+	case lx.Bool:
+		v = a.Value == `true`
+	case lx.String:
+		v = unescape(a.Value)
+	case lx.RawString:
+		v = a.Value
+	case lx.Rune:
+		r, _, _, err := strconv.UnquoteChar(a.Value, '\'')
+		if err != nil {
+			reportAtPos(a.Pos, `invalid rune %s`, a.Value)
+		}
+		v = r
+	case lx.Null:
+		v = nil
+	case lx.Underscore:
+		reportAtPos(a.Pos, `cannot use _ as value`)
+	default:
+		reportAtPos(a.Pos, `cannot evaluate %s`, a.Value)
+	}
+
+	return signOrNegate(v, a.Pos, a.Negated, a.Signed, a.Complemented)
+}
+
+// eval_binary evaluates a binary expression
+func (e *Evaluator) eval_binary(b *ast.Binary, env *environment) Value {
+	var v Value
+	switch b.Operator.Type {
+	case lx.And, lx.Or:
+		// boolean operators short-circuit
+		left, ok := e.eval_expression(b.Left, env).(bool)
+		if !ok {
+			reportAt(b.Operator, `%s is used in a boolean context but is not a bool`, b.Left)
+		}
+		if left == (b.Operator.Type == lx.Or) {
+			v = left
+			break
+		}
+		right, ok := e.eval_expression(b.Right, env).(bool)
+		if !ok {
+			reportAt(b.Operator, `%s is used in a boolean context but is not a bool`, b.Right)
+		}
+		v = right
+	case lx.NullCoalesce:
+		// the right side is only evaluated if the left side is null
+		if v = e.eval_expression(b.Left, env); v == nil {
+			v = e.eval_expression(b.Right, env)
+		}
+	default:
+		v = e.operate(b.Operator, e.eval_expression(b.Left, env), e.eval_expression(b.Right, env))
+	}
+
+	return signOrNegate(v, ast.Start(b), b.Negated, b.Signed, b.Complemented)
+}
+
+// eval_assignment evaluates an assignment and returns the assigned value
+func (e *Evaluator) eval_assignment(a *ast.Assignment, env *environment) Value {
+	var value Value
+	if op, ok := a.Value.(*ast.Operation); ok {
+		current, ok := env.lookup(a.Identifier)
+		if !ok {
+			reportAtPos(a.Pos, `undefined: %s`, a.Identifier)
+		}
+		value = e.eval_operation(op, a.Pos, current, env)
+	} else {
+		value = single(e.eval_expression(a.Value, env))
+	}
+
+	if !env.assign(a.Identifier, value) {
+		reportAtPos(a.Pos, `undefined: %s`, a.Identifier)
+	}
+	return value
+}
+
+// eval_operation applies an operation like ++ or += to [current]
+// which is the value of the identifier at [at]
+func (e *Evaluator) eval_operation(o *ast.Operation, at ast.Pos, current Value, env *environment) Value {
+	switch o.Type {
+	case lx.Increment, lx.Decrement:
+		op := lx.Token{Type: lx.Plus, Value: string(lx.Plus)}
+		if o.Type == lx.Decrement {
+			op = lx.Token{Type: lx.Minus, Value: string(lx.Minus)}
+		}
+
+		switch current.(type) {
+		case int64:
+			return binaryOperation(op, current, int64(1))
+		case float64:
+			return binaryOperation(op, current, float64(1))
+		}
+		reportAtPos(at, `cannot apply %s to %s`, o.Type, typeName(current))
+	}
+
+	// op-equals operators are their binary operator followed by `=`
+	op := lx.TokenType(strings.TrimSuffix(string(o.Type), `=`))
+	value := e.eval_expression(o.Value, env)
+	return e.operate(lx.Token{Type: op, Value: string(op), Line: at.Line, Column: at.Column}, current, value)
+}
+
+// eval_call evaluates a named, lambda or expression call
+func (e *Evaluator) eval_call(c *ast.Call, env *environment) Value {
+	callee, args, names := e.eval_callee_and_args(c, env)
+	return signOrNegate(e.traced_call(c, callee, args, names), ast.PosOf(c.Token), c.Negated, c.Signed, c.Complemented)
+}
+
+// eval_callee_and_args evaluates the function called by [c] and
+// its arguments. It also returns the names of the named arguments,
+// which come after the positional arguments
+func (e *Evaluator) eval_callee_and_args(c *ast.Call, env *environment) (callee Value, args []Value, names []string) {
+	switch {
+	case c.Func != nil:
+		callee = e.newClosure(c.Func, env)
+	case c.Callee != nil:
+		callee = e.eval_expression(c.Callee, env)
+	default:
+		var ok bool
+		if callee, ok = env.lookup(c.Name); !ok {
+			reportAt(c.Token, `undefined: %s`, c.Name)
+		}
+	}
+
+	// named arguments are evaluated after positional arguments
+	args = make([]Value, 0, len(c.Args)+len(c.Named))
+	for _, arg := range c.Args {
+		args = append(args, single(e.eval_expression(arg, env)))
+	}
+	names = make([]string, 0, len(c.Named))
+	for _, arg := range c.Named {
+		args = append(args, single(e.eval_expression(arg.Value, env)))
+		names = append(names, arg.Name)
+	}
+	return callee, args, names
+}
+
+// traced_call calls [callee] with the arguments of [c] and adds
+// the call to the stack trace of an error thrown by it
+func (e *Evaluator) traced_call(c *ast.Call, callee Value, args []Value, names []string) Value {
+	switch callee.(type) {
+	case *Closure, *Builtin:
+	default:
+		reportAt(c.Token, `cannot call non-function %s`, typeName(callee))
+	}
+
+	e.enter(c.Token)
+	defer func() {
+		e.leave()
+		if rec := recover(); rec != nil {
+			if err, ok := rec.(*Error); ok {
+				name := c.Name
+				switch f := callee.(type) {
+				case *Closure:
+					name = f.Func.DisplayName()
+				case *Builtin:
+					name = f.Func.Name
+					// builtins report their errors at the call
+					if err.Line == 0 && len(err.Trace) == 0 {
+						err.Line, err.Column = c.Token.Line, c.Token.Column
+					}
+				}
+				err.Trace = append(err.Trace, Frame{Function: name, Line: c.Token.Line, Column: c.Token.Column})
+			}
+			panic(rec)
+		}
+	}()
+	return e.call(callee, args, names, c.Spread)
+}
+
+// eval_list evaluates a list literal
+func (e *Evaluator) eval_list(l *ast.List, env *environment) Value {
+	elements := make([]Value, 0, len(l.Elements))
+	for _, el := range l.Elements {
+		elements = append(elements, e.eval_expression(el, env))
+	}
+	e.at(l.Pos)
+	return e.sized(&List{Elements: elements})
+}
+
+// eval_map evaluates a map literal
+func (e *Evaluator) eval_map(m *ast.Map, env *environment) Value {
+	result := NewMap()
+	for i := range m.Keys {
+		key := e.eval_expression(m.Keys[i], env)
+		if !isHashable(key) {
+			reportAtPos(ast.Start(m.Keys[i]), `%s cannot be used as a map key`, typeName(key))
+		}
+		result.Set(key, e.eval_expression(m.Values[i], env))
+	}
+	e.at(m.Pos)
+	return e.sized(result)
+}
+
+// eval_selector evaluates a field access
+func (e *Evaluator) eval_selector(s *ast.Selector, env *environment) Value {
+	v := selectField(e.eval_expression(s.Object, env), s.Name, ast.Start(s))
+	return signOrNegate(v, ast.Start(s), s.Negated, s.Signed, s.Complemented)
+}
+
+// eval_index evaluates an index into a list, map or string
+func (e *Evaluator) eval_index(i *ast.Index, env *environment) Value {
+	object := e.eval_expression(i.Object, env)
+	index := e.eval_expression(i.Index, env)
+
+	var v Value
+	switch o := object.(type) {
+	case *List:
+		v = o.Elements[checkIndex(index, len(o.Elements), ast.Start(i.Index))]
+	case string:
+		runes := []rune(o)
+		v = runes[checkIndex(index, len(runes), ast.Start(i.Index))]
+	case *Map:
+		var ok bool
+		if v, ok = o.Get(index); !ok {
+			reportAtPos(ast.Start(i.Index), `key %s not found in map`, Stringify(index))
+		}
+	default:
+		reportAtPos(ast.Start(i), `cannot index %s`, typeName(object))
+	}
+
+	return signOrNegate(v, ast.Start(i), i.Negated, i.Signed, i.Complemented)
+}
+
+// checkIndex returns [index] as an int if it is an int within
+// the bounds of [length]. Errors are reported at [at]
+func checkIndex(index Value, length int, at ast.Pos) int {
+	i, ok := index.(int64)
+	if !ok {
+		reportAtPos(at, `index must be int but is %s`, typeName(index))
+	}
+	if i < 0 || i >= int64(length) {
+		reportAtPos(at, `index %d out of range with length %d`, i, length)
+	}
+	return int(i)
+}
+
+// selectField returns the field [name] of [object] selected at
+// [at]. The fields of a map are its string keys
+func selectField(object Value, name string, at ast.Pos) Value {
+	if err, ok := object.(*Error); ok {
+		return errorField(err, name, at)
+	}
+
+	m, ok := object.(*Map)
+	if !ok {
+		reportAtPos(at, `%s has no field %s`, typeName(object), name)
+	}
+
+	v, ok := m.Get(name)
+	if !ok {
+		reportAtPos(at, `map has no field %s`, name)
+	}
+	return v
+}
+
+// errorField returns the field [name] of an error selected at [at].
+// An error has a message and a trace of the calls it was thrown through
+func errorField(err *Error, name string, at ast.Pos) Value {
+	switch name {
+	case `message`:
+		return err.Message
+	case `trace`:
+		return err.Error()
+	}
+	reportAtPos(at, `error has no field %s`, name)
+	return nil
+}
+
+// signOrNegate negates a bool if [negated] is set, flips the
+// sign of a number if [signed] is set or flips the bits of an
+// int if [complemented] is set. Errors are reported at [at]
+func signOrNegate(v Value, at ast.Pos, negated, signed, complemented bool) Value {
+	if negated {
+		b, ok := v.(bool)
+		if !ok {
+			reportAtPos(at, `cannot negate %s`, typeName(v))
+		}
+		return !b
+	}
+
+	if signed {
+		switch n := v.(type) {
+		case int64:
+			return -n
+		case float64:
+			return -n
+		}
+		reportAtPos(at, `cannot specify sign of %s`, typeName(v))
+	}
+
+	if complemented {
+		n, ok := v.(int64)
+		if !ok {
+			reportAtPos(at, `cannot complement %s`, typeName(v))
+		}
+		return ^n
+	}
+	return v
+}
+
+// unescape interprets the escape sequences in a string literal
+func unescape(s string) string {
+	if !strings.ContainsRune(s, '\\') {
+		return s
+	}
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+	return s
+}
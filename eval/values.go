@@ -0,0 +1,196 @@
+package eval
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+)
+
+// Value is a runtime value. Builtin types are represented
+// with Go values:
+//
+//	int -> int64, float -> float64, bool -> bool,
+//	string -> string, rune -> rune
+//
+// lists, maps and functions use the types in this file
+type Value interface{}
+
+// List is a runtime list
+type List struct {
+	Elements []Value
+}
+
+// Map is a runtime map which remembers the order
+// its keys were inserted in
+type Map struct {
+	keys    []Value
+	entries map[Value]Value
+}
+
+// Closure is a function value along with the
+// environment it was created in
+type Closure struct {
+	Func *ast.Function
+	env  *environment
+}
+
+// Tuple holds the values returned by a function
+// that returns multiple values
+type Tuple []Value
+
+// NewMap returns an empty map
+func NewMap() *Map {
+	return &Map{entries: map[Value]Value{}}
+}
+
+// Get returns the value stored for [key] and true if
+// [key] is present
+func (m *Map) Get(key Value) (Value, bool) {
+	v, ok := m.entries[key]
+	return v, ok
+}
+
+// Set stores [value] for [key]
+func (m *Map) Set(key, value Value) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+// Keys returns the keys of the map in insertion order
+func (m *Map) Keys() []Value {
+	return m.keys
+}
+
+// Len returns the number of entries in the map
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+// Stringify returns the string representation of a value
+func Stringify(v Value) string {
+	switch val := v.(type) {
+	case nil:
+		return `null`
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case string:
+		return val
+	case rune:
+		return string(val)
+	case *List:
+		s := strings.Builder{}
+		s.WriteRune('[')
+		for i, e := range val.Elements {
+			s.WriteString(Stringify(e))
+
+			// write comma if not last element
+			if i+1 < len(val.Elements) {
+				s.WriteString(`, `)
+			}
+		}
+		s.WriteRune(']')
+		return s.String()
+	case *Map:
+		if val.Len() == 0 {
+			return `[:]`
+		}
+		s := strings.Builder{}
+		s.WriteRune('[')
+		for i, k := range val.keys {
+			s.WriteString(Stringify(k))
+			s.WriteString(`: `)
+			s.WriteString(Stringify(val.entries[k]))
+
+			// write comma if not last entry
+			if i+1 < len(val.keys) {
+				s.WriteString(`, `)
+			}
+		}
+		s.WriteRune(']')
+		return s.String()
+	case *Closure:
+		return val.Func.String()
+	case *Builtin:
+		return val.Func.String()
+	case Tuple:
+		s := make([]string, 0, len(val))
+		for _, v := range val {
+			s = append(s, Stringify(v))
+		}
+		return `(` + strings.Join(s, `, `) + `)`
+	case *Error:
+		return val.Message
+	}
+	return `unknown`
+}
+
+// typeName returns the name of the type of a value
+// for error messages
+func typeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return `null`
+	case int64:
+		return `int`
+	case float64:
+		return `float`
+	case bool:
+		return `bool`
+	case string:
+		return `string`
+	case rune:
+		return `rune`
+	case *List:
+		return `list`
+	case *Map:
+		return `map`
+	case *Closure, *Builtin:
+		return `func`
+	case Tuple:
+		return `tuple`
+	case *Error:
+		return `error`
+	}
+	return `unknown`
+}
+
+// zeroValue returns the default value of a type name
+func zeroValue(typeName string) Value {
+	switch typeName {
+	case `int`:
+		return int64(0)
+	case `float`:
+		return float64(0)
+	case `bool`:
+		return false
+	case `string`:
+		return ``
+	case `rune`:
+		return rune(0)
+	}
+	switch {
+	case strings.HasSuffix(typeName, `?`):
+		return nil
+	case strings.HasPrefix(typeName, `list[`):
+		return &List{}
+	case strings.HasPrefix(typeName, `map[`):
+		return NewMap()
+	}
+	return nil
+}
+
+// isHashable returns true if a value can be used as a map key
+func isHashable(v Value) bool {
+	switch v.(type) {
+	case int64, float64, bool, string, rune:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+// Package hero embeds hero scripts in Go programs. An Engine holds
+// the Go functions and values a host provides to its scripts:
+//
+//	engine := hero.NewEngine()
+//	engine.Register(`double`, func(n int) int { return n * 2 })
+//	engine.Set(`limit`, 10)
+//
+//	script, err := engine.Compile(`func run() int { return double(limit) }`)
+//	instance, err := script.Run(ctx)
+//	result, err := instance.Call(ctx, `run`)
+//
+// Values are converted between Go and hero as follows:
+//
+//	int, uint (any size) <-> int
+//	float32, float64 <-> float
+//	bool <-> bool, string <-> string
+//	slices and arrays <-> list
+//	maps <-> map
+//
+// Hero values passed to interface{} parameters or returned to the host
+// are converted to int64, float64, bool, string, rune, []interface{}
+// or map[interface{}]interface{}
+package hero
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/checker"
+	"github.com/amupitan/hero/eval"
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/resolver"
+	"github.com/amupitan/hero/types"
+)
+
+// Engine compiles scripts that can use the functions
+// and values registered on it
+type Engine struct {
+	// values holds the registered functions as builtins and
+	// the other values as Go values. The Go values are converted
+	// when a script is run so runs don't share them
+	values map[string]interface{}
+	types  map[string]types.Type
+
+	// allowed holds the capabilities scripts can use. All
+	// capabilities can be used if it is nil
+	allowed map[types.Capability]bool
+
+	// Stdout is where scripts print to. It is os.Stdout if it isn't set
+	Stdout io.Writer
+
+	// Limits bounds the resources used by each run of a script and
+	// each call into it. The deadline of the context passed to a run
+	// or call limits its time
+	Limits Limits
+}
+
+// Limits bounds the resources a script can use. A limit that is zero
+// isn't enforced except for the call depth which is DefaultCallDepth
+type Limits = eval.Limits
+
+// DefaultCallDepth is the call depth limit of scripts whose
+// CallDepth limit is zero
+const DefaultCallDepth = eval.DefaultCallDepth
+
+// LimitError is the error returned when a script exceeds one of its
+// limits. Scripts can't catch it
+type LimitError = eval.LimitError
+
+// Capability is a group of builtin functions that reach outside a
+// script. Functions registered by the host need no capability
+type Capability = types.Capability
+
+// The capabilities of the builtin functions
+const (
+	FS     = types.FS     // read_file, write_file
+	Env    = types.Env    // getenv
+	Time   = types.Time   // now, sleep
+	Random = types.Random // random, random_int
+	Stdout = types.Stdout // print, println
+)
+
+// NewEngine returns an engine with no registered functions or values
+func NewEngine() *Engine {
+	return &Engine{
+		values: map[string]interface{}{},
+		types:  map[string]types.Type{},
+	}
+}
+
+// Register makes the Go function [fn] callable from scripts as
+// [name]. Its parameters and results must be of the types that can
+// be converted to hero values. If its final result is an error, a
+// non-nil error is thrown in the script as a runtime error
+func (e *Engine) Register(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Errorf(`hero: cannot register %T as function %s`, fn, name)
+	}
+	b, sig, err := builtin(name, v)
+	if err != nil {
+		return err
+	}
+	e.values[name] = b
+	e.types[name] = sig
+	return nil
+}
+
+// Allow restricts the builtin functions scripts can call to those
+// needing no capability or one of [caps]. Scripts can use every
+// capability until Allow is called. Calls to other builtins are
+// compile errors, or runtime errors if they aren't called by name
+func (e *Engine) Allow(caps ...Capability) {
+	if e.allowed == nil {
+		e.allowed = map[types.Capability]bool{}
+	}
+	for _, c := range caps {
+		e.allowed[c] = true
+	}
+}
+
+// Set defines the global variable [name] with the value [v] in
+// scripts. Functions are registered as if by Register
+func (e *Engine) Set(name string, v interface{}) error {
+	if reflect.ValueOf(v).Kind() == reflect.Func {
+		return e.Register(name, v)
+	}
+	if _, err := toValue(reflect.ValueOf(v)); err != nil {
+		return fmt.Errorf(`hero: cannot set %s: %s`, name, err)
+	}
+	// the type of a value that can be converted is always known
+	t, _ := typeOf(reflect.TypeOf(v))
+	e.values[name] = v
+	e.types[name] = t
+	return nil
+}
+
+// CompileError holds the errors found while compiling a script
+type CompileError struct {
+	Errors []error
+}
+
+func (c *CompileError) Error() string {
+	messages := make([]string, 0, len(c.Errors))
+	for _, err := range c.Errors {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Compile parses and checks [source]. The script can use the
+// functions and values registered on the engine so far
+func (e *Engine) Compile(source string) (s *Script, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			perr, ok := rec.(error)
+			if !ok {
+				panic(rec)
+			}
+			err = &CompileError{Errors: []error{perr}}
+		}
+	}()
+
+	p := parser.New(source)
+	if err := p.Err(); err != nil {
+		return nil, &CompileError{Errors: []error{err}}
+	}
+	program := p.Parse().Body.(*ast.Program)
+	resolver.Resolve(program)
+
+	if errs := checker.CheckWith(program, checker.Config{Host: e.types, Allowed: e.allowed}); len(errs) > 0 {
+		return nil, &CompileError{Errors: errs}
+	}
+
+	// later registrations don't change compiled scripts
+	values := make(map[string]interface{}, len(e.values))
+	for name, v := range e.values {
+		values[name] = v
+	}
+	var allowed map[types.Capability]bool
+	if e.allowed != nil {
+		allowed = make(map[types.Capability]bool, len(e.allowed))
+		for c := range e.allowed {
+			allowed[c] = true
+		}
+	}
+	return &Script{program: program, values: values, stdout: e.Stdout, limits: e.Limits, allowed: allowed}, nil
+}
+
+// Script is a compiled script. A script can be run any number
+// of times, including concurrently
+type Script struct {
+	program *ast.Program
+	values  map[string]interface{}
+	stdout  io.Writer
+	limits  Limits
+	allowed map[types.Capability]bool
+}
+
+// Run runs the top-level statements of the script and returns the
+// instance holding its globals. It stops with the error of [ctx] if
+// [ctx] is canceled or with a *LimitError if the deadline of [ctx]
+// passes before the script finishes
+func (s *Script) Run(ctx context.Context) (*Instance, error) {
+	e := eval.New()
+	e.Limits = s.limits
+	e.Allowed = s.allowed
+	if s.stdout != nil {
+		e.Stdout = s.stdout
+	}
+	for name, v := range s.values {
+		if b, ok := v.(*eval.Builtin); ok {
+			e.Define(name, b)
+			continue
+		}
+		// the value was converted when it was set
+		value, _ := toValue(reflect.ValueOf(v))
+		e.Define(name, value)
+	}
+
+	e.Context = ctx
+	result, err := e.Exec(s.program)
+	e.Context = nil
+	if err != nil {
+		return nil, err
+	}
+	return &Instance{evaluator: e, result: result}, nil
+}
+
+// Instance is a run of a script. It isn't safe for concurrent use
+type Instance struct {
+	evaluator *eval.Evaluator
+	result    eval.Value
+}
+
+// Result returns the value of the top-level return
+// statement of the script or nil if it has none
+func (i *Instance) Result() interface{} {
+	return fromValue(i.result)
+}
+
+// Global returns the value of a global variable of the script
+// and true if it is defined. Functions aren't values of the host
+// so it returns false for them. They are called with Call
+func (i *Instance) Global(name string) (interface{}, bool) {
+	v, ok := i.evaluator.Global(name)
+	if !ok {
+		return nil, false
+	}
+	switch v.(type) {
+	case *eval.Closure, *eval.Builtin:
+		return nil, false
+	}
+	return fromValue(v), true
+}
+
+// Call calls the function [name] defined by the script with [args].
+// Arguments that don't have the types of the parameters of the
+// function are reported before it is called. The results of a function that returns several values are returned
+// in a []interface{}. It stops with the error of [ctx] if [ctx] is
+// canceled or with a *LimitError if the deadline of [ctx] passes
+// before the function returns
+func (i *Instance) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	values := make([]eval.Value, 0, len(args))
+	for n, arg := range args {
+		v, err := toValue(reflect.ValueOf(arg))
+		if err != nil {
+			return nil, fmt.Errorf(`hero: cannot pass argument %d to %s: %s`, n+1, name, err)
+		}
+		values = append(values, v)
+	}
+
+	i.evaluator.Context = ctx
+	result, err := i.evaluator.Call(name, values...)
+	i.evaluator.Context = nil
+	if err != nil {
+		return nil, err
+	}
+	return fromValue(result), nil
+}
@@ -0,0 +1,298 @@
+package hero
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngine(t *testing.T) {
+	engine := NewEngine()
+	out := &bytes.Buffer{}
+	engine.Stdout = out
+
+	if err := engine.Register(`double`, func(n int) int { return n * 2 }); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Register(`sum`, func(xs ...float64) float64 {
+		total := 0.0
+		for _, x := range xs {
+			total += x
+		}
+		return total
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Register(`lookup`, func(key string) (string, error) {
+		if key == `` {
+			return ``, errors.New(`empty key`)
+		}
+		return strings.ToUpper(key), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Set(`limit`, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Set(`names`, []string{`a`, `b`}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Set(`ports`, map[string]int{`http`: 80, `https`: 443}); err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := engine.Compile(`
+	count := 0
+	total := sum(1.5, 2.5)
+	println(join(names, ","))
+
+	func run(n int) int {
+		count = count + 1
+		return double(n) + limit
+	}
+
+	func port(name string) int {
+		return ports[name]
+	}
+
+	func safe(key string) (string, string) {
+		try {
+			return lookup(key), ""
+		} catch err {
+			return "", err.message
+		}
+	}
+
+	func pair() (int, string) {
+		return 1, "one"
+	}
+
+	func label(n int, name string) string {
+		return name
+	}
+
+	return names`)
+	if err != nil {
+		t.Fatalf("Engine.Compile() error = %v", err)
+	}
+
+	instance, err := script.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Script.Run() error = %v", err)
+	}
+	if want := []interface{}{`a`, `b`}; !reflect.DeepEqual(instance.Result(), want) {
+		t.Errorf("Instance.Result() = %#v, want %#v", instance.Result(), want)
+	}
+	if want := "a,b\n"; out.String() != want {
+		t.Errorf("script printed %q, want %q", out.String(), want)
+	}
+
+	calls := []struct {
+		name string
+		args []interface{}
+		want interface{}
+	}{
+		{name: `run`, args: []interface{}{5}, want: int64(20)},
+		{name: `port`, args: []interface{}{`https`}, want: int64(443)},
+		{name: `safe`, args: []interface{}{`k`}, want: []interface{}{`K`, ``}},
+		{name: `safe`, args: []interface{}{``}, want: []interface{}{``, `empty key`}},
+		{name: `pair`, want: []interface{}{int64(1), `one`}},
+		{name: `label`, args: []interface{}{1, `one`}, want: `one`},
+	}
+	for _, c := range calls {
+		got, err := instance.Call(context.Background(), c.name, c.args...)
+		if err != nil {
+			t.Errorf("Instance.Call(%s) error = %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Instance.Call(%s) = %#v, want %#v", c.name, got, c.want)
+		}
+	}
+
+	if got, ok := instance.Global(`count`); !ok || got != int64(1) {
+		t.Errorf("Instance.Global(count) = %v, %v, want 1, true", got, ok)
+	}
+	if got, ok := instance.Global(`total`); !ok || got != 4.0 {
+		t.Errorf("Instance.Global(total) = %v, %v, want 4, true", got, ok)
+	}
+	if _, ok := instance.Global(`missing`); ok {
+		t.Errorf("Instance.Global(missing) should not be defined")
+	}
+	if got, ok := instance.Global(`run`); ok {
+		t.Errorf("Instance.Global(run) = %v, true, want a function to not be a global", got)
+	}
+	if _, err := instance.Call(context.Background(), `missing`); err == nil {
+		t.Errorf("Instance.Call(missing) should fail")
+	}
+
+	// the arguments of the host are checked against the parameters
+	wrong := []struct {
+		args []interface{}
+		want string
+	}{
+		{args: []interface{}{`x`, 1}, want: `cannot use string as int in argument to label`},
+		{args: []interface{}{1}, want: `missing argument for parameter name in call to label`},
+	}
+	for _, w := range wrong {
+		_, err := instance.Call(context.Background(), `label`, w.args...)
+		if err == nil || err.Error() != w.want {
+			t.Errorf("Instance.Call(label, %v) error = %v, want %v", w.args, err, w.want)
+		}
+	}
+}
+
+func TestEngine_Compile_errors(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.Register(`double`, func(n int) int { return n * 2 }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: `syntax error`, source: `x := `},
+		{name: `unknown token`, source: `x := 1 @`},
+		{
+			name:   `host function checked`,
+			source: `x := double("a")`,
+			want:   `cannot use a (string) as int in argument to double`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Compile(tt.source)
+			if _, ok := err.(*CompileError); !ok {
+				t.Fatalf("Engine.Compile() error = %v, want a *CompileError", err)
+			}
+			if tt.want != `` && err.Error() != tt.want {
+				t.Errorf("Engine.Compile() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_Register_unsupported(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.Register(`f`, 1); err == nil {
+		t.Errorf("Engine.Register() should fail for a non-function")
+	}
+	if err := engine.Register(`f`, func(c chan int) {}); err == nil {
+		t.Errorf("Engine.Register() should fail for a channel parameter")
+	}
+	if err := engine.Set(`v`, struct{}{}); err == nil {
+		t.Errorf("Engine.Set() should fail for a struct")
+	}
+}
+
+func TestScript_Run_canceled(t *testing.T) {
+	script, err := NewEngine().Compile(`
+	func spin() {
+		for {}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := script.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := instance.Call(ctx, `spin`); !isLimit(err, `time`) {
+		t.Errorf("Instance.Call() error = %v, want a time limit error", err)
+	}
+
+	// cancellation can't be caught by the script
+	script, err = NewEngine().Compile(`
+	try {
+		for {}
+	} catch {}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := script.Run(canceled); err != context.Canceled {
+		t.Errorf("Script.Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestScript_Run_twice(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.Set(`step`, 2); err != nil {
+		t.Fatal(err)
+	}
+	script, err := engine.Compile(`
+	count := 0
+	count = count + step
+	return count`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every run starts with new globals
+	for i := 0; i < 2; i++ {
+		instance, err := script.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := instance.Result(); got != int64(2) {
+			t.Errorf("Instance.Result() = %v, want 2", got)
+		}
+	}
+}
+
+func TestEngine_Limits(t *testing.T) {
+	engine := NewEngine()
+	engine.Limits = Limits{Steps: 1000}
+	script, err := engine.Compile(`
+	for {
+		try {} catch {}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = script.Run(context.Background())
+	if !isLimit(err, `steps`) || err.(*LimitError).Line != 2 {
+		t.Errorf("Script.Run() error = %v, want a step limit error on line 2", err)
+	}
+}
+
+// isLimit returns true if [err] is a LimitError for [limit]
+func isLimit(err error, limit string) bool {
+	lerr, ok := err.(*LimitError)
+	return ok && lerr.Limit == limit
+}
+
+func TestEngine_Allow(t *testing.T) {
+	engine := NewEngine()
+	engine.Stdout = &bytes.Buffer{}
+	engine.Allow(Stdout)
+
+	_, err := engine.Compile(`println(getenv("HOME"))`)
+	if want := `call to getenv is not allowed: it needs the env capability`; err == nil || err.Error() != want {
+		t.Errorf("Engine.Compile() error = %v, want %v", err, want)
+	}
+
+	// dynamic calls are stopped when the script is run
+	script, err := engine.Compile(`
+	f := println
+	f("a")
+	f = now
+	f()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = script.Run(context.Background())
+	if want := `call to now is not allowed: it needs the time capability`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Script.Run() error = %v, want %v", err, want)
+	}
+}
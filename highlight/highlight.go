@@ -0,0 +1,206 @@
+// Package highlight classifies the tokens of hero source for syntax
+// highlighting e.g. semantic tokens in an editor or exporting code to
+// HTML. Tokens are classified from their types and, if the source
+// parses, from the names the program defines so invalid code being
+// edited is still highlighted
+package highlight
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/amupitan/hero/ast"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/resolver"
+	"github.com/amupitan/hero/types"
+)
+
+// Kind is the kind of a highlighted span
+type Kind int
+
+const (
+	Keyword Kind = iota
+	Type
+	Identifier
+	Function
+	Parameter
+	String
+	Number
+	Comment
+	Operator
+)
+
+var kinds = [...]string{
+	Keyword:    `keyword`,
+	Type:       `type`,
+	Identifier: `identifier`,
+	Function:   `function`,
+	Parameter:  `parameter`,
+	String:     `string`,
+	Number:     `number`,
+	Comment:    `comment`,
+	Operator:   `operator`,
+}
+
+func (k Kind) String() string {
+	return kinds[k]
+}
+
+// Span is a highlighted part of a line. Lines and columns
+// start at 1 and columns and lengths count runes
+type Span struct {
+	Line, Column, Length int
+	Kind                 Kind
+}
+
+type classifier struct {
+	tokens []lx.Token
+
+	// names holds the kinds of the names defined and used in
+	// the program by their positions. It is empty if the source
+	// doesn't parse
+	names map[ast.Pos]resolver.SymbolKind
+
+	// types holds the names of the interfaces and the type
+	// parameters defined in the program
+	types map[string]bool
+}
+
+// Classify returns the spans of the tokens of [source] in order.
+// Delimiters and unknown tokens aren't highlighted. Tokens that span
+// lines e.g. raw strings are split into a span for each line
+func Classify(source string) []Span {
+	c := &classifier{
+		tokens: lx.Scan(source),
+		names:  map[ast.Pos]resolver.SymbolKind{},
+		types:  map[string]bool{},
+	}
+	c.resolve(source)
+
+	spans := []Span{}
+	for i, t := range c.tokens {
+		if kind, ok := c.classify(i); ok {
+			spans = append(spans, split(t, kind)...)
+		}
+	}
+	return spans
+}
+
+// resolve records the names defined in [source] if it parses
+func (c *classifier) resolve(source string) {
+	program := parser.ParseFile(source).Program()
+	if program == nil {
+		return
+	}
+	for _, sym := range resolver.Resolve(program).Symbols {
+		c.names[sym.Pos] = sym.Kind
+		for _, ref := range sym.References {
+			c.names[ref] = sym.Kind
+		}
+
+		switch sym.Kind {
+		case resolver.Interface:
+			c.types[sym.Name] = true
+		case resolver.Function:
+			if f, ok := sym.Node.(*ast.Function); ok {
+				for _, param := range f.TypeParams {
+					c.types[param.Name] = true
+				}
+			}
+		}
+	}
+}
+
+// classify returns the kind of the token at [i] and
+// false if the token isn't highlighted
+func (c *classifier) classify(i int) (Kind, bool) {
+	switch t := c.tokens[i]; t.Type {
+	case lx.Comment:
+		return Comment, true
+	case lx.String, lx.RawString, lx.Rune:
+		return String, true
+	case lx.Int, lx.Float:
+		return Number, true
+	case lx.Bool:
+		return Keyword, true
+	case lx.Identifier, lx.LoopName, lx.Underscore:
+		return c.identifier(i), true
+	case lx.Unknown, lx.NewLine, lx.Dot, lx.Colon, lx.Comma, lx.SemiColon,
+		lx.LeftParenthesis, lx.RightParenthesis, lx.LeftBracket,
+		lx.RightBracket, lx.LeftBrace, lx.RightBrace:
+		return 0, false
+	default:
+		if lx.IsBuiltinType(t.Type) {
+			return Type, true
+		}
+		if lx.IsKeyword(t.Type) {
+			return Keyword, true
+		}
+		return Operator, true
+	}
+}
+
+// identifier returns the kind of the identifier at [i]. Names the
+// program defines are classified by what they name. Other names
+// are classified by the tokens around them
+func (c *classifier) identifier(i int) Kind {
+	t := c.tokens[i]
+	call := c.next(i) == lx.LeftParenthesis
+	if kind, ok := c.names[ast.PosOf(t)]; ok {
+		switch {
+		case kind == resolver.Function || call:
+			return Function
+		case kind == resolver.Parameter:
+			return Parameter
+		case kind == resolver.Interface:
+			return Type
+		}
+		return Identifier
+	}
+
+	_, builtin := types.Builtins[t.Value]
+	_, constraint := types.Constraints[t.Value]
+	switch {
+	case i > 0 && c.tokens[i-1].Type == lx.Func:
+		return Function
+	case builtin || constraint || c.types[t.Value]:
+		return Type
+	case (t.Value == `list` || t.Value == `map`) && c.next(i) == lx.LeftBracket:
+		return Type
+	case call:
+		return Function
+	}
+	return Identifier
+}
+
+// next returns the type of the token after the token at [i]
+func (c *classifier) next(i int) lx.TokenType {
+	if i+1 < len(c.tokens) {
+		return c.tokens[i+1].Type
+	}
+	return lx.EndOfInput
+}
+
+// split returns the spans of the lines [t] is on
+func split(t lx.Token, kind Kind) []Span {
+	text, column := t.Value, t.Column
+	switch t.Type {
+	case lx.String:
+		text = `"` + text + `"`
+	case lx.RawString:
+		text = "`" + text + "`"
+	case lx.Rune:
+		// the column of a rune is after its opening quote
+		text, column = `'`+text+`'`, column-1
+	}
+
+	var spans []Span
+	for i, line := range strings.Split(text, "\n") {
+		if n := utf8.RuneCountInString(line); n > 0 {
+			spans = append(spans, Span{Line: t.Line + i, Column: column, Length: n, Kind: kind})
+		}
+		column = 1
+	}
+	return spans
+}
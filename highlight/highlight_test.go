@@ -0,0 +1,65 @@
+package highlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describe returns the text and kind of each of [spans] in [source]
+func describe(source string, spans []Span) []string {
+	lines := strings.Split(source, "\n")
+	var got []string
+	for _, s := range spans {
+		line := []rune(lines[s.Line-1])
+		got = append(got, string(line[s.Column-1:s.Column-1+s.Length])+` `+s.Kind.String())
+	}
+	return got
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: `program`,
+			source: `// sums
+interface Number { int | float }
+func sum<T Number>(xs list[T], f generic) T {
+	total := 0
+	for _, x in xs { total += f(x) }
+	return total
+}
+println(sum([1, 2.5], len), 'é')`,
+			want: []string{
+				`// sums comment`,
+				`interface keyword`, `Number type`, `int type`, `| operator`, `float type`,
+				`func keyword`, `sum function`, `< operator`, `T type`, `Number type`, `> operator`,
+				`xs parameter`, `list type`, `T type`, `f parameter`, `generic type`, `T type`,
+				`total identifier`, `:= operator`, `0 number`,
+				`for keyword`, `_ identifier`, `x identifier`, `in keyword`, `xs parameter`,
+				`total identifier`, `+= operator`, `f function`, `x identifier`,
+				`return keyword`, `total identifier`,
+				`println function`, `sum function`, `1 number`, `2.5 number`, `len identifier`, `'é' string`,
+			},
+		},
+		{
+			name:   `invalid code`,
+			source: "func add(a, b int {\n\treturn a @ b\n}\ns := `one\ntwo` + \"three",
+			want: []string{
+				`func keyword`, `add function`, `a identifier`, `b identifier`, `int type`,
+				`return keyword`, `a identifier`, `b identifier`,
+				`s identifier`, `:= operator`, "`one string", "two` string", `+ operator`, `three identifier`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describe(tt.source, Classify(tt.source)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package lexer
 
 import (
 	"bytes"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/amupitan/hero/lexer/fsm"
 )
@@ -40,6 +42,22 @@ func (l *Lexer) consumeDelimeter() Token {
 	return t
 }
 
+// consumeComment consumes a comment and returns it as a
+// token and true if one starts at the cursor
+func (l *Lexer) consumeComment() (Token, bool) {
+	start, line, col := l.position, l.Line, l.Column
+	l.skipComments()
+	if l.position == start {
+		return Token{}, false
+	}
+	return Token{
+		Type:   Comment,
+		Value:  string(l.input[start:l.position]),
+		Line:   line,
+		Column: col,
+	}, true
+}
+
 // consumeNewline consumes a new line
 func (l *Lexer) consumeNewline() Token {
 	t := Token{
@@ -77,6 +95,28 @@ func (l *Lexer) consumeColonOrDeclare() Token {
 	return t
 }
 
+// consumeQuestionOrNullCoalesce consumes a question
+// mark or null-coalescing token
+func (l *Lexer) consumeQuestionOrNullCoalesce() Token {
+	t := Token{
+		Type:   Question,
+		Value:  string(Question),
+		Column: l.Column,
+		Line:   l.Line,
+	}
+
+	l.move()
+
+	// check if it is a `??`
+	if next, _ := l.peek(); next == '?' {
+		t.Type = NullCoalesce
+		t.Value = string(NullCoalesce)
+		l.move()
+	}
+
+	return t
+}
+
 // recognizeOperator consumes an operator token
 func (l *Lexer) recognizeOperator() Token {
 	c := l.getCurr()
@@ -499,6 +539,10 @@ func (l *Lexer) consumeString() Token {
 
 	length := buf.Len()
 
+	// the cursor moves by runes while the buffer's
+	// length is in bytes
+	runes := utf8.RuneCount(buf.Bytes())
+
 	// remove starting delimeter
 	buf.ReadByte()
 	// remove trailing delimeter
@@ -510,8 +554,17 @@ func (l *Lexer) consumeString() Token {
 		Line:   l.Line,
 		Value:  buf.String(),
 	}
-	l.position += length
-	l.Column += length
+
+	// strings can span lines so the cursor moves to the
+	// line of the closing delimeter
+	for _, c := range l.input[l.position : l.position+runes] {
+		l.Column++
+		if isNewLine(c) {
+			l.Line++
+			l.Column = 1
+		}
+	}
+	l.position += runes
 
 	return t
 }
@@ -544,6 +597,12 @@ func (l *Lexer) consumeNumber() Token {
 		return UnknownToken(string(l.getCurr()), l.Line, l.Column)
 	}
 
+	// a number followed by dots e.g. 1..5 or f(1...) ends
+	// before the dots rather than with a decimal point
+	if next := l.position + len(num); strings.HasSuffix(num, `.`) && next < len(l.input) && l.input[next] == '.' {
+		num = strings.TrimSuffix(num, `.`)
+	}
+
 	// check for a decimal/exponent to determine whether Int or Float
 	var Type TokenType = Int
 	for _, b := range num {
@@ -0,0 +1,143 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Edit replaces the text between two positions of an input with Text.
+// Lines and columns start at 1 and columns count runes. The end is
+// the position after the last replaced rune so an insertion has the
+// same start and end
+type Edit struct {
+	StartLine, StartColumn int
+	EndLine, EndColumn     int
+	Text                   string
+}
+
+// Apply returns [input] with the edit applied. An error is
+// returned if a position of the edit isn't in [input]
+func (e Edit) Apply(input string) (string, error) {
+	runes := []rune(input)
+	start, ok := offset(runes, e.StartLine, e.StartColumn)
+	if !ok {
+		return ``, fmt.Errorf(`edit starts at %d:%d which is outside the input`, e.StartLine, e.StartColumn)
+	}
+	end, ok := offset(runes, e.EndLine, e.EndColumn)
+	if !ok || end < start {
+		return ``, fmt.Errorf(`edit ends at %d:%d which is outside the input or before its start`, e.EndLine, e.EndColumn)
+	}
+	return string(runes[:start]) + e.Text + string(runes[end:]), nil
+}
+
+// offset returns the index in [runes] of the position at [line] and
+// [column]. The position after the last rune of a line is valid
+func offset(runes []rune, line, column int) (int, bool) {
+	if line < 1 || column < 1 {
+		return 0, false
+	}
+	i := 0
+	for l := 1; l < line; l++ {
+		for i < len(runes) && !isNewLine(runes[i]) {
+			i++
+		}
+		if i == len(runes) {
+			return 0, false
+		}
+		i++
+	}
+	for c := 1; c < column; c++ {
+		if i == len(runes) || isNewLine(runes[i]) {
+			return 0, false
+		}
+		i++
+	}
+	return i, true
+}
+
+// Change describes how Retokenize changed a list of tokens. The
+// tokens before Start are unchanged. The old tokens from End are
+// reused from NewEnd in the new tokens with their lines moved by Lines
+type Change struct {
+	Start, End, NewEnd int
+	Lines              int
+}
+
+// Retokenize returns the tokens of [input] given the [tokens] of
+// the input it was before [edit] was applied to it. The lexer is
+// restarted at the start of the first line the edit touches, or of
+// an earlier line if a string spanning lines reaches it, and stops
+// at the first new line after the edit where it is in step with the
+// old tokens. The old tokens after that are reused
+func Retokenize(input string, tokens []Token, edit Edit) ([]Token, Change, error) {
+	if len(tokens) == 0 || tokens[len(tokens)-1].Type != EndOfInput {
+		return nil, Change{}, errors.New(`tokens don't end with the end of input`)
+	}
+	old := tokens[:len(tokens)-1]
+
+	added := strings.Count(edit.Text, "\n")
+	lines := added - (edit.EndLine - edit.StartLine)
+
+	// lines starting inside strings aren't safe to restart at
+	line := edit.StartLine
+	start := 0
+	for {
+		start = sort.Search(len(old), func(i int) bool { return endLine(old[i]) >= line })
+		if start == len(old) || old[start].Line >= line {
+			break
+		}
+		line = old[start].Line
+	}
+
+	runes := []rune(input)
+	position, ok := offset(runes, line, 1)
+	if !ok {
+		return nil, Change{}, fmt.Errorf(`line %d is outside the input`, line)
+	}
+	l := &Lexer{input: runes, position: position, Line: line, Column: 1}
+
+	retokenized := make([]Token, start, len(tokens)+added)
+	copy(retokenized, old[:start])
+	next := start
+	for {
+		t := l.NextToken()
+		switch t.Type {
+		case Unknown:
+			return nil, Change{}, fmt.Errorf(UnknownTokenError, t.Value, t.Line, t.Column)
+		case EndOfInput:
+			retokenized = append(retokenized, t)
+			return retokenized, Change{Start: start, End: len(tokens), NewEnd: len(retokenized), Lines: lines}, nil
+		}
+		retokenized = append(retokenized, t)
+
+		// a new line after the edit is in step with the old
+		// tokens if there was a new line at the same place
+		if t.Type != NewLine || t.Line <= edit.StartLine+added {
+			continue
+		}
+		oldLine := t.Line - lines
+		for next < len(old) && (old[next].Line < oldLine || old[next].Line == oldLine && old[next].Column < t.Column) {
+			next++
+		}
+		if next < len(old) && old[next].Type == NewLine && old[next].Line == oldLine && old[next].Column == t.Column {
+			end := len(retokenized)
+			for _, t := range tokens[next+1:] {
+				if t.Type != EndOfInput {
+					t.Line += lines
+				}
+				retokenized = append(retokenized, t)
+			}
+			return retokenized, Change{Start: start, End: next + 1, NewEnd: end, Lines: lines}, nil
+		}
+	}
+}
+
+// endLine returns the line the token [t] ends on
+func endLine(t Token) int {
+	if t.Type == String || t.Type == RawString {
+		return t.Line + strings.Count(t.Value, "\n")
+	}
+	return t.Line
+}
@@ -0,0 +1,147 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEdit_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		edit    Edit
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  `insertion`,
+			input: "a := 1\nb := 2",
+			edit:  Edit{StartLine: 2, StartColumn: 6, EndLine: 2, EndColumn: 6, Text: `3`},
+			want:  "a := 1\nb := 32",
+		},
+		{
+			name:  `replacement across lines`,
+			input: "a := 1\nb := 2",
+			edit:  Edit{StartLine: 1, StartColumn: 3, EndLine: 2, EndColumn: 3, Text: "= 5\nc "},
+			want:  "a = 5\nc := 2",
+		},
+		{
+			name:  `end of a line`,
+			input: "a := é\nb",
+			edit:  Edit{StartLine: 1, StartColumn: 7, EndLine: 2, EndColumn: 1, Text: ``},
+			want:  "a := éb",
+		},
+		{
+			name:    `outside the input`,
+			input:   "a := 1",
+			edit:    Edit{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 1},
+			wantErr: true,
+		},
+		{
+			name:    `end before start`,
+			input:   "a := 1",
+			edit:    Edit{StartLine: 1, StartColumn: 3, EndLine: 1, EndColumn: 2},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.edit.Apply(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Edit.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Edit.Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetokenize(t *testing.T) {
+	input := "a := 1\nb := `x\ny`\n// comment\nc := a + b\n// `\nd := `s`\n// `\n"
+
+	// the edits are applied in order
+	tests := []struct {
+		name string
+		edit Edit
+		// start is the token the lexer is expected to restart at
+		start int
+	}{
+		{
+			name:  `edit a line`,
+			edit:  Edit{StartLine: 5, StartColumn: 6, EndLine: 5, EndColumn: 7, Text: `b`},
+			start: 9,
+		},
+		{
+			name:  `edit inside a string spanning lines`,
+			edit:  Edit{StartLine: 3, StartColumn: 1, EndLine: 3, EndColumn: 1, Text: `z`},
+			start: 4,
+		},
+		{
+			name:  `add lines`,
+			edit:  Edit{StartLine: 1, StartColumn: 7, EndLine: 1, EndColumn: 7, Text: "\ne := 3\n"},
+			start: 0,
+		},
+		{
+			name:  `open a string`,
+			edit:  Edit{StartLine: 8, StartColumn: 1, EndLine: 8, EndColumn: 4, Text: `e = `},
+			start: 20,
+		},
+		{
+			name:  `close the string`,
+			edit:  Edit{StartLine: 8, StartColumn: 1, EndLine: 8, EndColumn: 5, Text: `// `},
+			start: 20,
+		},
+		{
+			name:  `remove lines`,
+			edit:  Edit{StartLine: 2, StartColumn: 1, EndLine: 4, EndColumn: 1, Text: ``},
+			start: 4,
+		},
+		{
+			name:  `edit the last line`,
+			edit:  Edit{StartLine: 9, StartColumn: 1, EndLine: 9, EndColumn: 1, Text: `f`},
+			start: 21,
+		},
+	}
+
+	tokens, err := New(input).Tokenize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited, err := tt.edit.Apply(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, change, err := Retokenize(edited, tokens, tt.edit)
+			if err != nil {
+				t.Fatalf("Retokenize() error = %v", err)
+			}
+			want, err := New(edited).Tokenize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Retokenize() = %v, want %v", got, want)
+			}
+			if change.Start != tt.start {
+				t.Errorf("Retokenize() restarted at token %d, want %d", change.Start, tt.start)
+			}
+			input, tokens = edited, got
+		})
+	}
+}
+
+func TestRetokenize_error(t *testing.T) {
+	input := "a := 1\nb := 2"
+	tokens, _ := New(input).Tokenize()
+	edit := Edit{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 1, Text: `@`}
+	edited, _ := edit.Apply(input)
+
+	_, _, err := Retokenize(edited, tokens, edit)
+	_, want := New(edited).Tokenize()
+	if err == nil || err.Error() != want.Error() {
+		t.Errorf("Retokenize() error = %v, want %v", err, want)
+	}
+}
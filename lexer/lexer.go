@@ -47,6 +47,10 @@ func (l *Lexer) NextToken() Token {
 		return l.consumeColonOrDeclare()
 	}
 
+	if isQuestion(curr) {
+		return l.consumeQuestionOrNullCoalesce()
+	}
+
 	if isOperator(curr) {
 		return l.recognizeOperator()
 	}
@@ -72,6 +76,36 @@ func (l *Lexer) Tokenize() ([]Token, error) {
 	return tokens, nil
 }
 
+// Scan returns the tokens of [input] with its comments. Unlike
+// Tokenize it doesn't stop at an unknown token: the token is returned
+// and lexing goes on after it so invalid input e.g. input being edited
+// can be scanned. The end of input token isn't returned
+func Scan(input string) []Token {
+	l := New(input)
+	tokens := []Token{}
+	for {
+		l.skipWhiteSpace()
+		if t, ok := l.consumeComment(); ok {
+			tokens = append(tokens, t)
+			continue
+		}
+
+		start := l.position
+		t := l.NextToken()
+		if t.Type == EndOfInput {
+			return tokens
+		}
+		tokens = append(tokens, t)
+
+		// some unknown tokens are returned without
+		// moving past them
+		if t.Type == Unknown && l.position <= start {
+			l.position = start
+			l.move()
+		}
+	}
+}
+
 // getCurr returns the rune at the current position
 func (l *Lexer) getCurr() rune {
 	return l.input[l.position]
@@ -306,18 +306,29 @@ func TestLexer_Tokenize(t *testing.T) {
 			"int ending with two dots",
 			fields{"3.."},
 			[]Token{
-				Token{Column: 1, Type: Float, Line: 1, Value: "3."},
-				Token{Column: 3, Type: Dot, Line: 1, Value: "."},
+				Token{Column: 1, Type: Int, Line: 1, Value: "3"},
+				Token{Column: 2, Type: TwoDots, Line: 1, Value: ".."},
 				EndOfInputToken,
 			},
 			nil,
 		},
 		{
-			"float ending with two dots",
+			"int ending with three dots",
 			fields{"3..."},
 			[]Token{
-				Token{Column: 1, Type: Float, Line: 1, Value: "3."},
-				Token{Column: 3, Type: TwoDots, Line: 1, Value: ".."},
+				Token{Column: 1, Type: Int, Line: 1, Value: "3"},
+				Token{Column: 2, Type: Ellipsis, Line: 1, Value: "..."},
+				EndOfInputToken,
+			},
+			nil,
+		},
+		{
+			"int range",
+			fields{"1..10"},
+			[]Token{
+				Token{Column: 1, Type: Int, Line: 1, Value: "1"},
+				Token{Column: 2, Type: TwoDots, Line: 1, Value: ".."},
+				Token{Column: 4, Type: Int, Line: 1, Value: "10"},
 				EndOfInputToken,
 			},
 			nil,
@@ -338,6 +349,19 @@ func TestLexer_Tokenize(t *testing.T) {
 			nil,
 			errors.New(`Unexpected token '@' on line 1, column 1.`),
 		},
+		{
+			"raw string spanning lines",
+			fields{"a := `x\ny`\nb"},
+			[]Token{
+				Token{Column: 1, Type: Identifier, Line: 1, Value: "a"},
+				Token{Column: 3, Type: Declare, Line: 1, Value: ":="},
+				Token{Column: 6, Type: RawString, Line: 1, Value: "x\ny"},
+				Token{Column: 3, Type: NewLine, Line: 2, Value: `\n`},
+				Token{Column: 1, Type: Identifier, Line: 3, Value: "b"},
+				EndOfInputToken,
+			},
+			nil,
+		},
 		{
 			"identifier-raw_string addition",
 			fields{"a + `hello`"},
@@ -360,6 +384,35 @@ func TestLexer_Tokenize(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"identifier-unicode_string addition",
+			fields{`a + "爱心" + b`},
+			[]Token{
+				Token{Column: 1, Type: Identifier, Line: 1, Value: "a"},
+				Token{Column: 3, Type: Plus, Line: 1, Value: "+"},
+				Token{Column: 5, Type: String, Line: 1, Value: `爱心`},
+				Token{Column: 10, Type: Plus, Line: 1, Value: "+"},
+				Token{Column: 12, Type: Identifier, Line: 1, Value: "b"},
+				EndOfInputToken,
+			},
+			nil,
+		},
+		{
+			"nullable definition and null coalescing",
+			fields{`var s string? = t ?? null`},
+			[]Token{
+				Token{Column: 1, Type: Var, Line: 1, Value: "var"},
+				Token{Column: 5, Type: Identifier, Line: 1, Value: "s"},
+				Token{Column: 7, Type: Identifier, Line: 1, Value: "string"},
+				Token{Column: 13, Type: Question, Line: 1, Value: "?"},
+				Token{Column: 15, Type: Assign, Line: 1, Value: "="},
+				Token{Column: 17, Type: Identifier, Line: 1, Value: "t"},
+				Token{Column: 19, Type: NullCoalesce, Line: 1, Value: "??"},
+				Token{Column: 22, Type: Null, Line: 1, Value: "null"},
+				EndOfInputToken,
+			},
+			nil,
+		},
 		{
 			"identifier-bad_string addition",
 			fields{`a + "he"llo"`},
@@ -500,6 +553,44 @@ func TestLexer_Tokenize(t *testing.T) {
 	}
 }
 
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			"comments",
+			"// start\na := 1 // one",
+			[]Token{
+				{Column: 1, Type: Comment, Line: 1, Value: "// start"},
+				{Column: 9, Type: NewLine, Line: 1, Value: `\n`},
+				{Column: 1, Type: Identifier, Line: 2, Value: "a"},
+				{Column: 3, Type: Declare, Line: 2, Value: ":="},
+				{Column: 6, Type: Int, Line: 2, Value: "1"},
+				{Column: 8, Type: Comment, Line: 2, Value: "// one"},
+			},
+		},
+		{
+			"unknown tokens",
+			"a @ \"b",
+			[]Token{
+				{Column: 1, Type: Identifier, Line: 1, Value: "a"},
+				{Column: 3, Type: Unknown, Line: 1, Value: "@"},
+				{Column: 5, Type: Unknown, Line: 1, Value: `"`},
+				{Column: 6, Type: Identifier, Line: 1, Value: "b"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Scan(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLexer_skipComments(t *testing.T) {
 	type fields struct {
 		input    []rune
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"fmt"
+	"sort"
 )
 
 type TokenType string
@@ -34,9 +35,13 @@ const (
 
 	/// Keywords
 	Break     TokenType = "break"
+	Case      TokenType = "case"
+	Catch     TokenType = "catch"
 	Class     TokenType = "class"
 	Const     TokenType = "const"
 	Continue  TokenType = "continue"
+	Default   TokenType = "default"
+	Defer     TokenType = "defer"
 	Else      TokenType = "else"
 	For       TokenType = "for"
 	Func      TokenType = "func"
@@ -48,7 +53,10 @@ const (
 	Null      TokenType = "null"
 	Package   TokenType = "package"
 	Return    TokenType = "return"
+	Switch    TokenType = "switch"
 	This      TokenType = "this"
+	Throw     TokenType = "throw"
+	Try       TokenType = "try"
 	Var       TokenType = "var"
 
 	/// Arithmetic operators
@@ -93,6 +101,10 @@ const (
 	BitLeftShift  TokenType = "<<"
 	BitRightShift TokenType = ">>"
 
+	/// Null operators
+	Question     TokenType = "?"
+	NullCoalesce TokenType = "??"
+
 	/// Assignment operators
 	Assign  TokenType = "="
 	Declare TokenType = ":="
@@ -119,13 +131,20 @@ const (
 	/// Special TokenTypes
 	EndOfInput TokenType = "end of input"
 	Unknown    TokenType = "unknown"
+
+	// Comment is only returned by Scan
+	Comment TokenType = "comment"
 )
 
 var keywords = map[TokenType]struct{}{
 	Break:     struct{}{},
+	Case:      struct{}{},
+	Catch:     struct{}{},
 	Class:     struct{}{},
 	Const:     struct{}{},
 	Continue:  struct{}{},
+	Default:   struct{}{},
+	Defer:     struct{}{},
 	Else:      struct{}{},
 	For:       struct{}{},
 	Func:      struct{}{},
@@ -137,7 +156,10 @@ var keywords = map[TokenType]struct{}{
 	Null:      struct{}{},
 	Package:   struct{}{},
 	Return:    struct{}{},
+	Switch:    struct{}{},
 	This:      struct{}{},
+	Throw:     struct{}{},
+	Try:       struct{}{},
 	Var:       struct{}{},
 }
 
@@ -158,6 +180,16 @@ func IsKeyword(t TokenType) bool {
 	return false
 }
 
+// Keywords returns the keywords of the language in alphabetical order
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for k := range keywords {
+		names = append(names, string(k))
+	}
+	sort.Strings(names)
+	return names
+}
+
 // IsBuiltinType returns true of the token type represents
 // a builtin type
 func IsBuiltinType(t TokenType) bool {
@@ -67,3 +67,18 @@ func TestIsKeyword(t *testing.T) {
 		})
 	}
 }
+
+func TestKeywords(t *testing.T) {
+	got := Keywords()
+	if len(got) != len(keywords) {
+		t.Fatalf("Keywords() returned %d keywords, want %d", len(got), len(keywords))
+	}
+	for i, k := range got {
+		if !IsKeyword(TokenType(k)) {
+			t.Errorf("Keywords() returned %s which is not a keyword", k)
+		}
+		if i > 0 && got[i-1] >= k {
+			t.Errorf("Keywords() = %v, want them in alphabetical order", got)
+		}
+	}
+}
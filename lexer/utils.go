@@ -115,6 +115,7 @@ func isLetter(b rune) bool              { return unicode.IsLetter(rune(b)) }
 func isDigit(b rune) bool               { return unicode.IsDigit(rune(b)) }
 func isDot(b rune) bool                 { return b == '.' }
 func isColon(b rune) bool               { return b == ':' }
+func isQuestion(b rune) bool            { return b == '?' }
 func isValidIdentifierChar(b rune) bool { return b == '_' || isLetter(b) || isDigit(b) }
 func isBoolOperator(b rune) bool        { return b == '&' || b == '|' || b == '!' }
 func isComparisonOperator(b rune) bool  { return b == '>' || b == '<' || b == '=' }
@@ -0,0 +1,186 @@
+// Package lint reports code in hero programs that is valid but likely
+// a mistake e.g. unused variables or unreachable code. Each check is a
+// Rule that can be disabled in a Config or ignored on a line with a
+// comment e.g. // hero:ignore unused-variable
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/amupitan/hero/ast"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/resolver"
+)
+
+// Rule is a check of a program
+type Rule struct {
+	// Name is the name the rule is disabled and ignored by
+	Name string
+
+	// Doc describes what the rule reports
+	Doc string
+
+	// Check reports the problems found in the program of [pass]
+	Check func(pass *Pass)
+}
+
+// Pass is the run of a rule on a program
+type Pass struct {
+	Program *ast.Program
+
+	// Symbols holds the names defined in the program and their uses
+	Symbols *resolver.Table
+
+	rule     *Rule
+	problems []*Problem
+}
+
+// Report reports a problem at [pos] with a formatted message
+func (p *Pass) Report(pos ast.Pos, format string, args ...interface{}) {
+	p.problems = append(p.problems, &Problem{
+		Rule:    p.rule.Name,
+		Message: fmt.Sprintf(format, args...),
+		Line:    pos.Line,
+		Column:  pos.Column,
+	})
+}
+
+// Problem is a problem found by a rule
+type Problem struct {
+	Rule    string
+	Message string
+
+	// Line and Column locate the problem if its position is known
+	Line, Column int
+}
+
+func (p *Problem) Error() string {
+	return fmt.Sprintf(`%d:%d: %s (%s)`, p.Line, p.Column, p.Message, p.Rule)
+}
+
+// Config configures the rules run on a program
+type Config struct {
+	// Rules holds the rules to run. The rules in
+	// the package's Rules are run if it is nil
+	Rules []*Rule
+
+	// Disabled holds the names of the rules that aren't run
+	Disabled map[string]bool
+}
+
+// ParseConfig parses a config file. The file is a JSON object
+// enabling or disabling rules by name e.g.
+//
+//	{"rules": {"shadow": false, "empty-block": true}}
+//
+// Rules that aren't listed are enabled. An error is returned if
+// the file isn't valid or names a rule that doesn't exist
+func ParseConfig(data []byte) (*Config, error) {
+	var file struct {
+		Rules map[string]bool `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf(`invalid lint config: %s`, err)
+	}
+
+	config := &Config{Disabled: map[string]bool{}}
+	for name, enabled := range file.Rules {
+		if Lookup(name) == nil {
+			return nil, fmt.Errorf(`invalid lint config: unknown rule %s`, name)
+		}
+		if !enabled {
+			config.Disabled[name] = true
+		}
+	}
+	return config, nil
+}
+
+// Lookup returns the rule in Rules named [name] or nil
+func Lookup(name string) *Rule {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Lint runs the rules enabled in [config] on [source] and returns
+// the problems they find in the order they appear in the source.
+// Problems on lines with an ignore comment naming their rule aren't
+// returned. An error is returned if [source] doesn't parse
+func Lint(source string, config Config) ([]*Problem, error) {
+	f := parser.ParseFile(source)
+	if err := f.Err(); err != nil {
+		return nil, err
+	}
+	program := f.Program()
+	symbols := resolver.Resolve(program)
+
+	rules := config.Rules
+	if rules == nil {
+		rules = Rules
+	}
+	ignored := ignores(source)
+
+	problems := []*Problem{}
+	for _, rule := range rules {
+		if config.Disabled[rule.Name] {
+			continue
+		}
+		pass := &Pass{Program: program, Symbols: symbols, rule: rule}
+		rule.Check(pass)
+		for _, p := range pass.problems {
+			if names, ok := ignored[p.Line]; !ok || len(names) > 0 && !names[p.Rule] {
+				problems = append(problems, p)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return problems, nil
+}
+
+// ignoreComment matches an ignore comment e.g. // hero:ignore shadow
+// and captures the names of the rules it ignores
+var ignoreComment = regexp.MustCompile(`^//\s*hero:ignore\b(.*)$`)
+
+// ignores returns the names of the rules ignored on the lines of
+// [source]. A comment ignores rules on its line or on the next line
+// if it is on a line of its own. A comment naming no rules ignores
+// every rule so the names of its line are empty
+func ignores(source string) map[int]map[string]bool {
+	ignored := map[int]map[string]bool{}
+	tokens := lx.Scan(source)
+	for i, t := range tokens {
+		if t.Type != lx.Comment {
+			continue
+		}
+		match := ignoreComment.FindStringSubmatch(t.Value)
+		if match == nil {
+			continue
+		}
+
+		line := t.Line
+		if i == 0 || tokens[i-1].Type == lx.NewLine {
+			line++
+		}
+		names := ignored[line]
+		if names == nil {
+			names = map[string]bool{}
+			ignored[line] = names
+		}
+		for _, name := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			names[name] = true
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,209 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+// lint returns the problems found in [source] by the rule [name]
+func lint(t *testing.T, source string, name string) []string {
+	t.Helper()
+	problems, err := Lint(source, Config{Rules: []*Rule{Lookup(name)}})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	got := []string{}
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	return got
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule   string
+		source string
+		want   []string
+	}{
+		{
+			rule: `unused-variable`,
+			source: `global := 1
+func f() {
+	x := 1
+	y := 2
+	for i, v in [y] { println(v) }
+}`,
+			want: []string{
+				`3:2: x is defined but never used (unused-variable)`,
+				`5:6: i is defined but never used (unused-variable)`,
+			},
+		},
+		{
+			rule: `unused-parameter`,
+			source: `func f(a, b int) int {
+	return b
+}`,
+			want: []string{`1:8: parameter a is never used (unused-parameter)`},
+		},
+		{
+			rule: `unreachable-code`,
+			source: `func f(x int) int {
+	for {
+		break
+		x++
+	}
+	return x
+	println(x)
+	println(x)
+}`,
+			want: []string{
+				`4:3: unreachable code (unreachable-code)`,
+				`7:2: unreachable code (unreachable-code)`,
+			},
+		},
+		{
+			rule: `empty-block`,
+			source: `func stub() {}
+x := 1
+if x > 0 {
+} else {
+	x = 2
+}
+try { x = 3 } catch err {}
+{}
+switch x {
+case 1:
+case 2:
+	x = 4
+default:
+}
+f := func() {}
+f()`,
+			want: []string{
+				`1:13: empty block (empty-block)`,
+				`3:10: empty block (empty-block)`,
+				`7:25: empty block (empty-block)`,
+				`8:1: empty block (empty-block)`,
+				`10:1: empty block (empty-block)`,
+				`13:1: empty block (empty-block)`,
+				`15:13: empty block (empty-block)`,
+			},
+		},
+		{
+			rule: `constant-condition`,
+			source: `x := 1
+if true { x = 2 } else if 1 < 2 { x = 3 } else if x > 1 { x = 4 }
+y := "a" == "b" ? 1 : 2`,
+			want: []string{
+				`2:4: condition true is always the same (constant-condition)`,
+				`2:27: condition (1<2) is always the same (constant-condition)`,
+				`3:6: condition (a==b) is always the same (constant-condition)`,
+			},
+		},
+		{
+			rule: `self-assignment`,
+			source: `a, b := 1, 2
+a = a
+a = -a
+a, b = b, b`,
+			want: []string{
+				`2:1: a is assigned to itself (self-assignment)`,
+				`4:4: b is assigned to itself (self-assignment)`,
+			},
+		},
+		{
+			rule: `shadow`,
+			source: `x := 1
+func f(x int) int {
+	if x > 0 {
+		x := 2
+		return x
+	}
+	return x
+}
+if x > 0 {
+	y := 1
+	println(y)
+} else {
+	y := 2
+	println(y)
+}`,
+			want: []string{
+				`2:8: x shadows the variable defined on line 1 (shadow)`,
+				`4:3: x shadows the parameter defined on line 2 (shadow)`,
+			},
+		},
+		{
+			rule: `negated-comparison`,
+			source: `a, b := 1, 2
+println(!(a == b), !(a < b), a != b, !(a > 0 && b > 0))`,
+			want: []string{
+				`2:11: !(a==b) can be simplified to a != b (negated-comparison)`,
+				`2:22: !(a<b) can be simplified to a >= b (negated-comparison)`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			if got := lint(t, tt.source, tt.rule); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLint_ignore(t *testing.T) {
+	source := `func f(a int) {
+	x := 1 // hero:ignore unused-variable
+	// hero:ignore shadow, unused-variable
+	a := 2
+	y := 3 // hero:ignore shadow
+	// hero:ignore
+	if true {}
+}`
+	problems, err := Lint(source, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	want := []string{
+		`1:8: parameter a is never used (unused-parameter)`,
+		`5:2: y is defined but never used (unused-variable)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %q, want %q", got, want)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"rules": {"shadow": false, "empty-block": true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{`shadow`: true}; !reflect.DeepEqual(config.Disabled, want) {
+		t.Errorf("ParseConfig() disabled %v, want %v", config.Disabled, want)
+	}
+
+	problems, err := Lint("x := 1\nfunc f() {\n\tx := 2\n\tprintln(x)\n}", *config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Lint() = %v, want no problems with shadow disabled", problems)
+	}
+
+	for _, data := range []string{`{"rules": {"unknown": false}}`, `{"rules": []}`} {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("ParseConfig(%s) error = nil, want an error", data)
+		}
+	}
+}
+
+func TestLint_error(t *testing.T) {
+	if _, err := Lint(`func 5() {}`, Config{}); err == nil {
+		t.Errorf("Lint() error = nil, want the parse error")
+	}
+}
@@ -0,0 +1,227 @@
+package lint
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/resolver"
+)
+
+// Rules holds the rules run by default
+var Rules = []*Rule{
+	{
+		Name:  `unused-variable`,
+		Doc:   `variables defined in a block that are never used`,
+		Check: unusedVariables,
+	},
+	{
+		Name:  `unused-parameter`,
+		Doc:   `parameters that are never used in the body of their function`,
+		Check: unusedParameters,
+	},
+	{
+		Name:  `unreachable-code`,
+		Doc:   `statements after a return, throw, break or continue in the same block`,
+		Check: unreachableCode,
+	},
+	{
+		Name:  `empty-block`,
+		Doc:   `empty blocks of functions, if statements, switch cases, loops, try statements and bare blocks`,
+		Check: emptyBlocks,
+	},
+	{
+		Name:  `constant-condition`,
+		Doc:   `conditions of if statements and conditional expressions made of literals`,
+		Check: constantConditions,
+	},
+	{
+		Name:  `self-assignment`,
+		Doc:   `assignments of a variable to itself`,
+		Check: selfAssignments,
+	},
+	{
+		Name:  `shadow`,
+		Doc:   `definitions hiding a name defined in an enclosing block`,
+		Check: shadowedDefinitions,
+	},
+	{
+		Name:  `negated-comparison`,
+		Doc:   `negated comparisons that can be written with the opposite operator e.g. !(a == b)`,
+		Check: negatedComparisons,
+	},
+}
+
+// unusedVariables doesn't report globals since the
+// host of a program can use them
+func unusedVariables(pass *Pass) {
+	for _, sym := range pass.Symbols.Symbols {
+		if sym.Kind == resolver.Variable && sym.End.IsValid() && len(sym.References) == 0 {
+			pass.Report(sym.Pos, `%s is defined but never used`, sym.Name)
+		}
+	}
+}
+
+func unusedParameters(pass *Pass) {
+	for _, sym := range pass.Symbols.Symbols {
+		if sym.Kind == resolver.Parameter && len(sym.References) == 0 {
+			pass.Report(sym.Pos, `parameter %s is never used`, sym.Name)
+		}
+	}
+}
+
+func unreachableCode(pass *Pass) {
+	ast.Inspect(pass.Program, func(n core.Node) bool {
+		b, ok := n.(*ast.Block)
+		if !ok {
+			return true
+		}
+		for i := 0; i+1 < len(b.Statements); i++ {
+			switch b.Statements[i].(type) {
+			case *ast.Return, *ast.Throw, *ast.Break, *ast.Continue:
+				pass.Report(ast.Start(b.Statements[i+1]), `unreachable code`)
+				return true
+			}
+		}
+		return true
+	})
+}
+
+func emptyBlocks(pass *Pass) {
+	report := func(b *ast.Block) {
+		if b != nil && len(b.Statements) == 0 {
+			pass.Report(b.Pos, `empty block`)
+		}
+	}
+
+	ast.Inspect(pass.Program, func(n core.Node) bool {
+		switch n := n.(type) {
+		case *ast.Block:
+			// bare blocks are the blocks in blocks
+			for _, s := range n.Statements {
+				if b, ok := s.(*ast.Block); ok {
+					report(b)
+				}
+			}
+		case *ast.Function:
+			report(n.Body)
+		case *ast.If:
+			report(n.Body)
+		case *ast.Case:
+			report(n.Body)
+		case *ast.Switch:
+			report(n.Default)
+		case *ast.ForLoop:
+			report(n.Body)
+		case *ast.RangeLoop:
+			report(n.Body)
+		case *ast.Try:
+			report(n.Body)
+			report(n.Catch)
+		}
+		return true
+	})
+}
+
+func constantConditions(pass *Pass) {
+	ast.Inspect(pass.Program, func(n core.Node) bool {
+		var condition core.Expression
+		switch n := n.(type) {
+		case *ast.If:
+			condition = n.Condition
+		case *ast.Conditional:
+			condition = n.Condition
+		}
+		if condition != nil && constant(condition) {
+			pass.Report(ast.Start(condition), `condition %s is always the same`, condition)
+		}
+		return true
+	})
+}
+
+// constant returns true if [e] is made of literals
+func constant(e core.Expression) bool {
+	switch e := e.(type) {
+	case *ast.Atom:
+		switch e.Type {
+		case lx.Bool, lx.Int, lx.Float, lx.String, lx.RawString, lx.Rune, lx.Null:
+			return true
+		}
+	case *ast.Binary:
+		return constant(e.Left) && constant(e.Right)
+	}
+	return false
+}
+
+func selfAssignments(pass *Pass) {
+	ast.Inspect(pass.Program, func(n core.Node) bool {
+		switch n := n.(type) {
+		case *ast.Assignment:
+			if names(n.Value, n.Identifier) {
+				pass.Report(n.Pos, `%s is assigned to itself`, n.Identifier)
+			}
+		case *ast.MultiAssignment:
+			if n.Define || len(n.Values) != len(n.Identifiers) {
+				break
+			}
+			for i, name := range n.Identifiers {
+				if name != `_` && names(n.Values[i], name) && i < len(n.Positions) {
+					pass.Report(n.Positions[i], `%s is assigned to itself`, name)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// names returns true if [e] is the variable [name] as it is
+func names(e core.Expression, name string) bool {
+	a, ok := e.(*ast.Atom)
+	return ok && a.Type == lx.Identifier && a.Value == name && !a.Negated && !a.Signed && !a.Complemented
+}
+
+func shadowedDefinitions(pass *Pass) {
+	symbols := pass.Symbols.Symbols
+	for _, sym := range symbols {
+		if sym.Kind == resolver.Interface || !sym.End.IsValid() {
+			continue
+		}
+		// the shadowed name is the closest one defined before
+		// the name in a scope enclosing the name's scope
+		var shadowed *resolver.Symbol
+		for _, outer := range symbols {
+			if outer == sym || outer.Name != sym.Name || outer.Kind == resolver.Interface || !outer.Pos.Before(sym.Pos) {
+				continue
+			}
+			if !outer.End.IsValid() || sym.End.Before(outer.End) {
+				shadowed = outer
+			}
+		}
+		if shadowed != nil {
+			pass.Report(sym.Pos, `%s shadows the %s defined on line %d`, sym.Name, shadowed.Kind, shadowed.Pos.Line)
+		}
+	}
+}
+
+// inverses holds the comparison operators with the
+// opposite result of each comparison operator
+var inverses = map[lx.TokenType]lx.TokenType{
+	lx.Equal:              lx.NotEqual,
+	lx.NotEqual:           lx.Equal,
+	lx.LessThan:           lx.GreaterThanOrEqual,
+	lx.GreaterThanOrEqual: lx.LessThan,
+	lx.GreaterThan:        lx.LessThanOrEqual,
+	lx.LessThanOrEqual:    lx.GreaterThan,
+}
+
+func negatedComparisons(pass *Pass) {
+	ast.Inspect(pass.Program, func(n core.Node) bool {
+		b, ok := n.(*ast.Binary)
+		if !ok || !b.Negated {
+			return true
+		}
+		if inverse, ok := inverses[b.Operator.Type]; ok {
+			pass.Report(ast.Start(b), `!%s can be simplified to %s %s %s`, b, b.Left, inverse, b.Right)
+		}
+		return true
+	})
+}
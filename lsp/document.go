@@ -0,0 +1,209 @@
+package lsp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/checker"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/parser"
+	"github.com/amupitan/hero/resolver"
+	"github.com/amupitan/hero/types"
+)
+
+// document is an open hero source file and what is known about it
+type document struct {
+	uri   string
+	file  *parser.File
+	lines []string
+
+	// program, table and types are nil if the document doesn't parse
+	program *ast.Program
+	table   *resolver.Table
+	types   map[ast.Pos]types.Type
+
+	// names holds the symbols of the last version of the document
+	// that parsed. It keeps completion working while an edit is
+	// incomplete
+	names *resolver.Table
+
+	diagnostics []Diagnostic
+}
+
+// errorPos matches the positions in the errors of the parser e.g.
+// `2:5: Expected ...` and of the lexer e.g. `... on line 2, column 5.`
+var errorPos = regexp.MustCompile(`^(\d+):(\d+): |on line (\d+), column (\d+)`)
+
+// edit applies the change of the text in [r] to [text] to the
+// document. The whole text is replaced if [r] is nil
+func (d *document) edit(r *Range, text string) error {
+	if r == nil || d.file == nil {
+		d.file = parser.ParseFile(text)
+	} else {
+		start, end := d.pos(r.Start), d.pos(r.End)
+		err := d.file.Edit(lx.Edit{
+			StartLine: start.Line, StartColumn: start.Column,
+			EndLine: end.Line, EndColumn: end.Column,
+			Text: text,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	d.lines = strings.Split(d.file.Source(), "\n")
+	return nil
+}
+
+// analyze resolves and checks the program of the document
+// and records the errors found as diagnostics
+func (d *document) analyze() {
+	d.program, d.table, d.types = nil, nil, nil
+	d.diagnostics = []Diagnostic{}
+
+	if err := d.file.Err(); err != nil {
+		var pos ast.Pos
+		if e, ok := err.(*parser.Error); ok {
+			pos = ast.Pos{Line: e.Line, Column: e.Column}
+		}
+		d.report(pos, err.Error())
+		return
+	}
+	program := d.file.Program()
+	d.program = program
+	d.table = resolver.Resolve(program)
+	d.names = d.table
+	d.types = map[ast.Pos]types.Type{}
+
+	for _, err := range checker.CheckWith(program, checker.Config{Types: d.types}) {
+		var pos ast.Pos
+		if e, ok := err.(*checker.Error); ok {
+			pos = ast.Pos{Line: e.Line, Column: e.Column}
+		}
+		d.report(pos, err.Error())
+	}
+}
+
+// report records an error with [message] found at [pos]. The position
+// in the message is used instead if it has one. The diagnostic spans
+// the rest of the line from the position
+func (d *document) report(pos ast.Pos, message string) {
+	if m := errorPos.FindStringSubmatch(message); m != nil {
+		line, col := m[1], m[2]
+		if line == `` {
+			line, col = m[3], m[4]
+		}
+		pos.Line, _ = strconv.Atoi(line)
+		pos.Column, _ = strconv.Atoi(col)
+		message = strings.TrimPrefix(message, m[0])
+	}
+	if !pos.IsValid() {
+		pos = ast.Pos{Line: 1, Column: 1}
+	}
+
+	start := d.position(pos)
+	end := start
+	if line := pos.Line - 1; line < len(d.lines) {
+		end.Character = len(utf16.Encode([]rune(strings.TrimRight(d.lines[line], "\r \t"))))
+	}
+	if end.Character <= start.Character {
+		end.Character = start.Character + 1
+	}
+
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Range:    Range{Start: start, End: end},
+		Severity: severityError,
+		Source:   `hero`,
+		Message:  message,
+	})
+}
+
+// position converts [pos] to a position in the protocol. Columns
+// of the source count runes and characters count UTF-16 code units
+func (d *document) position(pos ast.Pos) Position {
+	line := pos.Line - 1
+	if line < 0 || line >= len(d.lines) {
+		return Position{Line: line}
+	}
+	runes := []rune(d.lines[line])
+	col := pos.Column - 1
+	if col > len(runes) {
+		col = len(runes)
+	}
+	return Position{Line: line, Character: len(utf16.Encode(runes[:col]))}
+}
+
+// pos converts a position in the protocol to a position in the source
+func (d *document) pos(p Position) ast.Pos {
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return ast.Pos{Line: p.Line + 1, Column: p.Character + 1}
+	}
+	col, units := 1, 0
+	for _, r := range d.lines[p.Line] {
+		if units >= p.Character {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		col++
+	}
+	return ast.Pos{Line: p.Line + 1, Column: col}
+}
+
+// nameRange returns the range of [name] starting at [pos]
+func (d *document) nameRange(pos ast.Pos, name string) Range {
+	end := pos
+	end.Column += utf8.RuneCountInString(name)
+	return Range{Start: d.position(pos), End: d.position(end)}
+}
+
+// word returns the identifier at [pos] or an empty string
+func (d *document) word(pos ast.Pos) string {
+	line := pos.Line - 1
+	if line < 0 || line >= len(d.lines) {
+		return ``
+	}
+	runes := []rune(d.lines[line])
+	start, end := pos.Column-1, pos.Column-1
+	for start > 0 && isIdentifier(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isIdentifier(runes[end]) {
+		end++
+	}
+	if start >= end {
+		return ``
+	}
+	return string(runes[start:end])
+}
+
+func isIdentifier(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// describe returns the declaration of [sym] with its type if it is known
+func (d *document) describe(sym *resolver.Symbol) string {
+	switch node := sym.Node.(type) {
+	case *ast.Function:
+		return signature(node)
+	case *ast.Interface:
+		return node.String()
+	case *ast.Definition:
+		if f, ok := node.Value.(*ast.Function); ok {
+			return sym.Name + ` := ` + signature(f)
+		}
+	}
+
+	s := sym.Kind.String() + ` ` + sym.Name
+	if t, ok := d.types[sym.Pos]; ok {
+		s += ` ` + t.String()
+	}
+	return s
+}
+
+// signature returns the declaration of [f] without its body
+func signature(f *ast.Function) string {
+	return strings.TrimSuffix(f.String(), ` {}`)
+}
@@ -0,0 +1,375 @@
+// Package lsp implements a language server for hero. It speaks the
+// Language Server Protocol over a stream e.g. stdio and provides
+// diagnostics from the parser and checker, hover, go to definition,
+// find references, document symbols, completion and semantic tokens
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/highlight"
+	lx "github.com/amupitan/hero/lexer"
+	"github.com/amupitan/hero/resolver"
+	"github.com/amupitan/hero/types"
+)
+
+type server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// docs holds the open documents by their URIs
+	docs map[string]*document
+
+	initialized bool
+	shutdown    bool
+}
+
+// handler handles a request and returns its result
+type handler func(s *server, params json.RawMessage) (interface{}, error)
+
+var requests = map[string]handler{
+	`initialize`:                  (*server).initialize,
+	`shutdown`:                    (*server).shutdownRequest,
+	`textDocument/hover`:          (*server).hover,
+	`textDocument/definition`:     (*server).definition,
+	`textDocument/references`:     (*server).references,
+	`textDocument/documentSymbol`: (*server).documentSymbol,
+	`textDocument/completion`:     (*server).completion,
+
+	`textDocument/semanticTokens/full`: (*server).semanticTokens,
+}
+
+// notifications holds the handlers of notifications.
+// Notifications that aren't handled are ignored
+var notifications = map[string]func(s *server, params json.RawMessage) error{
+	`textDocument/didOpen`:   (*server).didOpen,
+	`textDocument/didChange`: (*server).didChange,
+	`textDocument/didClose`:  (*server).didClose,
+}
+
+// Serve runs a language server that reads messages from [in] and
+// writes to [out] until it is told to exit or [in] ends. An error
+// is returned if reading or writing fails or the server exits
+// without being shut down
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{in: bufio.NewReader(in), out: out, docs: map[string]*document{}}
+	for {
+		m, err := read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m.Method == `exit` {
+			if !s.shutdown {
+				return errors.New(`exit before shutdown`)
+			}
+			return nil
+		}
+		if err := s.handle(m); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles the request or notification [m]
+func (s *server) handle(m *message) error {
+	if m.ID == nil {
+		if f, ok := notifications[m.Method]; ok && s.initialized && !s.shutdown {
+			return f(s, m.Params)
+		}
+		return nil
+	}
+
+	result, err := s.call(m.Method, m.Params)
+	response := &message{ID: m.ID}
+	if err != nil {
+		response.Error, _ = err.(*responseError)
+		if response.Error == nil {
+			response.Error = &responseError{Code: invalidParams, Message: err.Error()}
+		}
+	} else if response.Result, err = json.Marshal(result); err != nil {
+		return err
+	}
+	return write(s.out, response)
+}
+
+// call runs the handler of the request [method]
+func (s *server) call(method string, params json.RawMessage) (interface{}, error) {
+	f, ok := requests[method]
+	switch {
+	case !ok:
+		return nil, &responseError{Code: methodNotFound, Message: `unknown method ` + method}
+	case !s.initialized && method != `initialize`:
+		return nil, &responseError{Code: serverNotInitialized, Message: `server is not initialized`}
+	case s.shutdown:
+		return nil, &responseError{Code: invalidRequest, Message: `server is shut down`}
+	}
+	return f(s, params)
+}
+
+// notify sends the notification [method] to the client
+func (s *server) notify(method string, params interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return write(s.out, &message{Method: method, Params: body})
+}
+
+func (s *server) initialize(params json.RawMessage) (interface{}, error) {
+	s.initialized = true
+	return map[string]interface{}{
+		`capabilities`: map[string]interface{}{
+			// documents are synced by the changes made to them
+			`textDocumentSync`:       2,
+			`hoverProvider`:          true,
+			`definitionProvider`:     true,
+			`referencesProvider`:     true,
+			`documentSymbolProvider`: true,
+			`completionProvider`:     map[string]interface{}{},
+			`semanticTokensProvider`: map[string]interface{}{
+				`legend`: map[string]interface{}{
+					`tokenTypes`:     tokenTypes,
+					`tokenModifiers`: []string{},
+				},
+				`full`: true,
+			},
+		},
+		`serverInfo`: map[string]string{`name`: `hero`},
+	}, nil
+}
+
+func (s *server) shutdownRequest(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *server) didOpen(params json.RawMessage) error {
+	var p didOpenParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	d := &document{uri: p.TextDocument.URI}
+	s.docs[d.uri] = d
+	d.edit(nil, p.TextDocument.Text)
+	return s.update(d)
+}
+
+func (s *server) didChange(params json.RawMessage) error {
+	var p didChangeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	for _, change := range p.ContentChanges {
+		// a change that doesn't fit the document can't be
+		// applied so the document is left as it was
+		if err := d.edit(change.Range, change.Text); err != nil {
+			return nil
+		}
+	}
+	return s.update(d)
+}
+
+func (s *server) didClose(params json.RawMessage) error {
+	var p didCloseParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	delete(s.docs, p.TextDocument.URI)
+
+	// the diagnostics of a closed document are cleared
+	return s.notify(`textDocument/publishDiagnostics`, publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+// update analyzes [d] and publishes the errors found in it
+func (s *server) update(d *document) error {
+	d.analyze()
+	return s.notify(`textDocument/publishDiagnostics`, publishDiagnosticsParams{URI: d.uri, Diagnostics: d.diagnostics})
+}
+
+// locate returns the open document and the position in it of [p]
+func (s *server) locate(p textDocumentPositionParams) (*document, ast.Pos, error) {
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, ast.Pos{}, errors.New(`document is not open: ` + p.TextDocument.URI)
+	}
+	return d, d.pos(p.Position), nil
+}
+
+// symbol returns the open document of [p] and the symbol
+// at the position of [p] or nil if there is none
+func (s *server) symbol(p textDocumentPositionParams) (*document, *resolver.Symbol, error) {
+	d, pos, err := s.locate(p)
+	if err != nil || d.table == nil {
+		return d, nil, err
+	}
+	return d, d.table.At(pos), nil
+}
+
+func (s *server) hover(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, sym, err := s.symbol(p)
+	if err != nil || d.table == nil {
+		return nil, err
+	}
+	if sym != nil {
+		return &Hover{Contents: codeBlock(d.describe(sym))}, nil
+	}
+
+	// names that aren't defined in the document may be builtin functions
+	name := d.word(d.pos(p.Position))
+	if sig, ok := types.Functions[name]; ok {
+		return &Hover{Contents: codeBlock(signature(ast.FunctionOf(name, sig)))}, nil
+	}
+	return nil, nil
+}
+
+func (s *server) definition(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, sym, err := s.symbol(p)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	return &Location{URI: d.uri, Range: d.nameRange(sym.Pos, sym.Name)}, nil
+}
+
+func (s *server) references(params json.RawMessage) (interface{}, error) {
+	var p referenceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, sym, err := s.symbol(p.textDocumentPositionParams)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+
+	locations := make([]Location, 0, len(sym.References)+1)
+	if p.Context.IncludeDeclaration {
+		locations = append(locations, Location{URI: d.uri, Range: d.nameRange(sym.Pos, sym.Name)})
+	}
+	for _, ref := range sym.References {
+		locations = append(locations, Location{URI: d.uri, Range: d.nameRange(ref, sym.Name)})
+	}
+	return locations, nil
+}
+
+func (s *server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p documentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok || d.table == nil {
+		return nil, nil
+	}
+
+	symbols := []DocumentSymbol{}
+	for _, sym := range d.table.Symbols {
+		name := d.nameRange(sym.Pos, sym.Name)
+		switch node := sym.Node.(type) {
+		case *ast.Function:
+			// a function spans from its name to the end of its body
+			body := name
+			if node.Body != nil && node.Body.End.IsValid() {
+				body.End = d.position(ast.Pos{Line: node.Body.End.Line, Column: node.Body.End.Column + 1})
+			}
+			symbols = append(symbols, DocumentSymbol{
+				Name:           sym.Name,
+				Detail:         signature(node),
+				Kind:           symbolFunction,
+				Range:          body,
+				SelectionRange: name,
+			})
+		case *ast.Interface:
+			symbols = append(symbols, DocumentSymbol{
+				Name:           sym.Name,
+				Detail:         node.String(),
+				Kind:           symbolInterface,
+				Range:          name,
+				SelectionRange: name,
+			})
+		}
+	}
+	return symbols, nil
+}
+
+func (s *server) completion(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, pos, err := s.locate(p)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	if d.names != nil {
+		for _, sym := range d.names.Visible(pos) {
+			kind := completionVariable
+			if sym.Kind == resolver.Function {
+				kind = completionFunction
+			}
+			items = append(items, CompletionItem{Label: sym.Name, Kind: kind, Detail: d.describe(sym)})
+		}
+	}
+
+	builtins := make([]string, 0, len(types.Functions))
+	for name := range types.Functions {
+		builtins = append(builtins, name)
+	}
+	sort.Strings(builtins)
+	for _, name := range builtins {
+		items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: signature(ast.FunctionOf(name, types.Functions[name]))})
+	}
+
+	for _, keyword := range lx.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+	return items, nil
+}
+
+func (s *server) semanticTokens(params json.RawMessage) (interface{}, error) {
+	var p documentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+
+	tokens := &SemanticTokens{Data: []int{}}
+	var prev Position
+	for _, span := range highlight.Classify(d.file.Source()) {
+		start := d.position(ast.Pos{Line: span.Line, Column: span.Column})
+		end := d.position(ast.Pos{Line: span.Line, Column: span.Column + span.Length})
+
+		// the start is relative to the token before
+		// it if they are on the same line
+		char := start.Character
+		if start.Line == prev.Line {
+			char -= prev.Character
+		}
+		tokens.Data = append(tokens.Data, start.Line-prev.Line, char, end.Character-start.Character, int(span.Kind), 0)
+		prev = start
+	}
+	return tokens, nil
+}
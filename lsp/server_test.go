@@ -0,0 +1,228 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const uri = `file:///main.hr`
+
+// session sends the requests and notifications [messages] to a server
+// in order, followed by shutdown and exit. Requests are the messages
+// with IDs. The responses to the requests are returned by their IDs
+// with the diagnostics published for the document [uri] last
+func session(t *testing.T, messages ...message) (map[string]json.RawMessage, []Diagnostic) {
+	t.Helper()
+	messages = append([]message{{ID: json.RawMessage(`0`), Method: `initialize`, Params: json.RawMessage(`{}`)}}, messages...)
+	messages = append(messages, message{ID: json.RawMessage(`999`), Method: `shutdown`}, message{Method: `exit`})
+
+	in := &bytes.Buffer{}
+	for i := range messages {
+		if err := write(in, &messages[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &bytes.Buffer{}
+	if err := Serve(in, out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	results := map[string]json.RawMessage{}
+	var diagnostics []Diagnostic
+	r := bufio.NewReader(out)
+	for {
+		m, err := read(r)
+		if err != nil {
+			break
+		}
+		switch {
+		case m.Error != nil:
+			t.Errorf("request %s failed: %s", m.ID, m.Error.Message)
+		case m.ID != nil:
+			results[string(m.ID)] = m.Result
+		case m.Method == `textDocument/publishDiagnostics`:
+			var p publishDiagnosticsParams
+			json.Unmarshal(m.Params, &p)
+			if p.URI == uri {
+				diagnostics = p.Diagnostics
+			}
+		}
+	}
+	return results, diagnostics
+}
+
+func open(text string) message {
+	params, _ := json.Marshal(didOpenParams{TextDocument: textDocumentItem{URI: uri, Text: text, Version: 1}})
+	return message{Method: `textDocument/didOpen`, Params: params}
+}
+
+func request(id, method string, params string) message {
+	return message{ID: json.RawMessage(id), Method: method, Params: json.RawMessage(params)}
+}
+
+func at(line, character int) string {
+	p, _ := json.Marshal(textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: uri}, Position: Position{Line: line, Character: character}})
+	return string(p)
+}
+
+func TestServe_diagnostics(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Diagnostic
+	}{
+		{
+			name:  `no errors`,
+			input: "x := 1\nx = 2",
+			want:  []Diagnostic{},
+		},
+		{
+			name:  `parse error`,
+			input: "x := 1\nfunc 5() {}",
+			want: []Diagnostic{{
+				Range:    Range{Start: Position{Line: 1, Character: 5}, End: Position{Line: 1, Character: 11}},
+				Severity: severityError,
+				Source:   `hero`,
+				Message:  "Expected `identifier` but found `5`.",
+			}},
+		},
+		{
+			name:  `type error`,
+			input: "x := 1\n  var y string = null",
+			want: []Diagnostic{{
+				Range:    Range{Start: Position{Line: 1, Character: 6}, End: Position{Line: 1, Character: 21}},
+				Severity: severityError,
+				Source:   `hero`,
+				Message:  `cannot use null as string value`,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := session(t, open(tt.input))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnostics = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServe_navigation(t *testing.T) {
+	input := `total := 0
+func add(a, b int) int {
+	return a + b
+}
+total = add(total, 2)`
+
+	results, _ := session(t,
+		open(input),
+		request(`1`, `textDocument/hover`, at(4, 9)),
+		request(`2`, `textDocument/hover`, at(2, 12)),
+		request(`3`, `textDocument/definition`, at(4, 14)),
+		request(`4`, `textDocument/references`, strings.TrimSuffix(at(0, 0), `}`)+`,"context":{"includeDeclaration":true}}`),
+		request(`5`, `textDocument/documentSymbol`, `{"textDocument":{"uri":"`+uri+`"}}`),
+		request(`6`, `textDocument/hover`, at(3, 0)),
+	)
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{`1`, `{"contents":{"kind":"markdown","value":"` + "```hero\\nfunc add(a int, b int) (int)\\n```" + `"}}`},
+		{`2`, `{"contents":{"kind":"markdown","value":"` + "```hero\\nparameter b int\\n```" + `"}}`},
+		{`3`, `{"uri":"` + uri + `","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":5}}}`},
+		{`4`, `[{"uri":"` + uri + `","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":5}}},` +
+			`{"uri":"` + uri + `","range":{"start":{"line":4,"character":12},"end":{"line":4,"character":17}}},` +
+			`{"uri":"` + uri + `","range":{"start":{"line":4,"character":0},"end":{"line":4,"character":5}}}]`},
+		{`5`, `[{"name":"add","detail":"func add(a int, b int) (int)","kind":12,` +
+			`"range":{"start":{"line":1,"character":5},"end":{"line":3,"character":1}},` +
+			`"selectionRange":{"start":{"line":1,"character":5},"end":{"line":1,"character":8}}}]`},
+		{`6`, `null`},
+	}
+	for _, tt := range tests {
+		if got := string(results[tt.id]); got != tt.want {
+			t.Errorf("result %s = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestServe_completion(t *testing.T) {
+	input := `x := 1
+func f(y int) {
+
+}`
+	results, _ := session(t, open(input), request(`1`, `textDocument/completion`, at(2, 1)))
+
+	var items []CompletionItem
+	if err := json.Unmarshal(results[`1`], &items); err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]int{}
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+	want := map[string]int{
+		`x`:      completionVariable,
+		`f`:      completionFunction,
+		`y`:      completionVariable,
+		`len`:    completionFunction,
+		`return`: completionKeyword,
+		`for`:    completionKeyword,
+	}
+	for label, kind := range want {
+		if got, ok := labels[label]; !ok || got != kind {
+			t.Errorf("completion %s has kind %d, want %d", label, got, kind)
+		}
+	}
+}
+
+func TestServe_didChange(t *testing.T) {
+	change := func(changes string) message {
+		return message{Method: `textDocument/didChange`, Params: json.RawMessage(`{"textDocument":{"uri":"` + uri + `"},"contentChanges":` + changes + `}`)}
+	}
+
+	results, diagnostics := session(t,
+		open("x := 1\ny := x"),
+		// x := 1 becomes x := "é" and y := x becomes var z int = null
+		change(`[{"range":{"start":{"line":0,"character":5},"end":{"line":0,"character":6}},"text":"\"é\""},`+
+			`{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":6}},"text":"var z int = null"}]`),
+		request(`1`, `textDocument/hover`, at(1, 4)),
+	)
+
+	want := []Diagnostic{{
+		Range:    Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 16}},
+		Severity: severityError,
+		Source:   `hero`,
+		Message:  `cannot use null as int value`,
+	}}
+	if !reflect.DeepEqual(diagnostics, want) {
+		t.Errorf("diagnostics = %+v, want %+v", diagnostics, want)
+	}
+	if got, want := string(results[`1`]), `{"contents":{"kind":"markdown","value":"`+"```hero\\nvariable z int\\n```"+`"}}`; got != want {
+		t.Errorf("hover = %s, want %s", got, want)
+	}
+
+	// a change without a range replaces the document
+	_, diagnostics = session(t, open("x := 1\ny := x"), change(`[{"text":"x := "}]`))
+	if len(diagnostics) != 1 {
+		t.Errorf("diagnostics = %+v, want the error of the replaced document", diagnostics)
+	}
+}
+
+func TestServe_semanticTokens(t *testing.T) {
+	input := "// é\nfunc add(a int) {\n\treturn a + 1\n}"
+	results, _ := session(t, open(input), request(`1`, `textDocument/semanticTokens/full`, `{"textDocument":{"uri":"`+uri+`"}}`))
+
+	want := `{"data":[` +
+		`0,0,4,7,0,` + // comment
+		`1,0,4,0,0,0,5,3,3,0,0,4,1,4,0,0,2,3,1,0,` + // func add(a int)
+		`1,1,6,0,0,0,7,1,4,0,0,2,1,8,0,0,2,1,6,0` + // return a + 1
+		`]}`
+	if got := string(results[`1`]); got != want {
+		t.Errorf("semantic tokens = %s, want %s", got, want)
+	}
+}
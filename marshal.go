@@ -0,0 +1,293 @@
+package hero
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/amupitan/hero/eval"
+	"github.com/amupitan/hero/types"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// typeOf returns the hero type of the values of the Go type [t].
+// interface{} is the generic type
+func typeOf(t reflect.Type) (types.Type, error) {
+	if t == nil {
+		return nil, fmt.Errorf(`nil has no hero type`)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return types.Int, nil
+	case reflect.Float32, reflect.Float64:
+		return types.Float, nil
+	case reflect.Bool:
+		return types.Bool, nil
+	case reflect.String:
+		return types.String, nil
+	case reflect.Slice, reflect.Array:
+		elem, err := typeOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &types.List{Elem: elem}, nil
+	case reflect.Map:
+		key, err := typeOf(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := typeOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &types.Map{Key: key, Value: value}, nil
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return types.Generic, nil
+		}
+	}
+	return nil, fmt.Errorf(`%s has no hero type`, t)
+}
+
+// toValue converts the Go value [v] to a hero value. The
+// entries of a map are inserted in the order of their keys
+func toValue(v reflect.Value) (eval.Value, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		l := &eval.List{Elements: make([]eval.Value, 0, v.Len())}
+		for i := 0; i < v.Len(); i++ {
+			el, err := toValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			l.Elements = append(l.Elements, el)
+		}
+		return l, nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		m := eval.NewMap()
+		for _, k := range keys {
+			key, err := toValue(k)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, float64, bool, string:
+			default:
+				return nil, fmt.Errorf(`%s cannot be used as a map key`, k.Type())
+			}
+			value, err := toValue(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, value)
+		}
+		return m, nil
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toValue(v.Elem())
+	}
+	return nil, fmt.Errorf(`cannot convert %s to a hero value`, v.Type())
+}
+
+// fromValue converts the hero value [v] to a Go value. Lists and
+// tuples become []interface{} and maps map[interface{}]interface{}
+func fromValue(v eval.Value) interface{} {
+	switch val := v.(type) {
+	case *eval.List:
+		return fromValues(val.Elements)
+	case eval.Tuple:
+		return fromValues(val)
+	case *eval.Map:
+		m := make(map[interface{}]interface{}, val.Len())
+		for _, k := range val.Keys() {
+			value, _ := val.Get(k)
+			m[k] = fromValue(value)
+		}
+		return m
+	}
+	return v
+}
+
+// fromValues converts hero values to a slice of Go values
+func fromValues(values []eval.Value) []interface{} {
+	s := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		s = append(s, fromValue(v))
+	}
+	return s
+}
+
+// fromValueTo converts the hero value [v] to a Go value of type [t]
+func fromValueTo(v eval.Value, t reflect.Type) (reflect.Value, error) {
+	fail := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf(`cannot use %s as %s`, eval.Stringify(v), t)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := v.(int64); ok {
+			return reflect.ValueOf(n).Convert(t), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := v.(int64); ok && n >= 0 {
+			return reflect.ValueOf(n).Convert(t), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := v.(float64); ok {
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	case reflect.Bool:
+		if b, ok := v.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := v.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	case reflect.Slice, reflect.Array:
+		l, ok := v.(*eval.List)
+		if !ok || (t.Kind() == reflect.Array && len(l.Elements) != t.Len()) {
+			return fail()
+		}
+		var s reflect.Value
+		if t.Kind() == reflect.Array {
+			s = reflect.New(t).Elem()
+		} else {
+			s = reflect.MakeSlice(t, len(l.Elements), len(l.Elements))
+		}
+		for i, el := range l.Elements {
+			converted, err := fromValueTo(el, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			s.Index(i).Set(converted)
+		}
+		return s, nil
+	case reflect.Map:
+		m, ok := v.(*eval.Map)
+		if !ok {
+			return fail()
+		}
+		result := reflect.MakeMapWithSize(t, m.Len())
+		for _, k := range m.Keys() {
+			key, err := fromValueTo(k, t.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			value, _ := m.Get(k)
+			converted, err := fromValueTo(value, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetMapIndex(key, converted)
+		}
+		return result, nil
+	case reflect.Interface:
+		if v == nil {
+			return reflect.Zero(t), nil
+		}
+		if converted := reflect.ValueOf(fromValue(v)); converted.Type().AssignableTo(t) {
+			return converted, nil
+		}
+	}
+	return fail()
+}
+
+// builtin returns the builtin function named [name] that calls
+// the Go function [fn] and the signature of the builtin
+func builtin(name string, fn reflect.Value) (*eval.Builtin, *types.Signature, error) {
+	ft := fn.Type()
+	sig := &types.Signature{Variadic: ft.IsVariadic()}
+	for i := 0; i < ft.NumIn(); i++ {
+		in := ft.In(i)
+		if sig.Variadic && i == ft.NumIn()-1 {
+			in = in.Elem()
+		}
+		t, err := typeOf(in)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`hero: cannot register %s: parameter %d: %s`, name, i+1, err)
+		}
+		sig.Params = append(sig.Params, types.Param{Name: fmt.Sprintf(`arg%d`, i+1), Type: t})
+	}
+
+	// a final error result is thrown instead of returned
+	results := ft.NumOut()
+	fails := results > 0 && ft.Out(results-1) == errorType
+	if fails {
+		results--
+	}
+	for i := 0; i < results; i++ {
+		t, err := typeOf(ft.Out(i))
+		if err != nil {
+			return nil, nil, fmt.Errorf(`hero: cannot register %s: result %d: %s`, name, i+1, err)
+		}
+		sig.Returns = append(sig.Returns, t)
+	}
+
+	call := func(args []eval.Value) (eval.Value, error) {
+		// the arguments of a variadic parameter are in a list
+		// which is converted to the slice of the parameter
+		in := make([]reflect.Value, 0, len(args))
+		for i, arg := range args {
+			v, err := fromValueTo(arg, ft.In(i))
+			if err != nil {
+				return nil, err
+			}
+			in = append(in, v)
+		}
+
+		var out []reflect.Value
+		if sig.Variadic {
+			out = fn.CallSlice(in)
+		} else {
+			out = fn.Call(in)
+		}
+		if fails {
+			if err := out[len(out)-1]; !err.IsNil() {
+				return nil, err.Interface().(error)
+			}
+			out = out[:len(out)-1]
+		}
+
+		values := make(eval.Tuple, 0, len(out))
+		for _, o := range out {
+			v, err := toValue(o)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		switch len(values) {
+		case 0:
+			return nil, nil
+		case 1:
+			return values[0], nil
+		}
+		return values, nil
+	}
+	return eval.NewBuiltin(name, sig, call), sig, nil
+}
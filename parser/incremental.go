@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// File is the source of a program that is parsed again as it is
+// edited e.g. in an editor. Only the lines around an edit are lexed
+// again and the top-level statements whose tokens the edit didn't
+// reach are reused instead of being parsed again. Statements after
+// an edit that adds or removes lines are parsed again since their
+// positions change
+type File struct {
+	source string
+
+	// tokens is nil if the source doesn't lex
+	tokens []lx.Token
+
+	// statements holds the top-level statements parsed
+	// before the first error in the source if there is one
+	statements []statement
+
+	program *ast.Program
+	err     *Error
+}
+
+// statement is a top-level statement and the tokens it was parsed from
+type statement struct {
+	node core.Statement
+
+	// start and end are the indexes of the first token of the
+	// statement and of the token after it. seen is the index of
+	// the furthest token looked at while parsing it
+	start, end, seen int
+}
+
+// ParseFile parses [source] as a file that can be edited
+func ParseFile(source string) *File {
+	f := &File{source: source}
+	f.tokenize()
+	return f
+}
+
+// Source returns the current source of the file
+func (f *File) Source() string {
+	return f.source
+}
+
+// Program returns the program of the file or nil if it doesn't parse
+func (f *File) Program() *ast.Program {
+	return f.program
+}
+
+// Err returns the error found while lexing or parsing the file, if any.
+// It is the same error that parsing the whole source would find
+func (f *File) Err() error {
+	if f.err == nil {
+		return nil
+	}
+	return f.err
+}
+
+// Edit applies [e] to the source of the file and parses it again.
+// An error is returned if the edit isn't in the source. Errors in
+// the new source are returned by Err
+func (f *File) Edit(e lx.Edit) error {
+	source, err := e.Apply(f.source)
+	if err != nil {
+		return err
+	}
+	f.source = source
+	if f.tokens == nil {
+		f.tokenize()
+		return nil
+	}
+
+	tokens, change, err := lx.Retokenize(source, f.tokens, e)
+	if err != nil {
+		f.tokens, f.statements = nil, nil
+		f.fail(err.Error(), ast.Pos{})
+		return nil
+	}
+	f.tokens = tokens
+
+	// the statements before the edit are kept
+	old := f.statements
+	n := 0
+	for n < len(old) && old[n].seen < change.Start {
+		n++
+	}
+	f.statements = old[:n:n]
+	start := 0
+	if n > 0 {
+		start = old[n-1].end
+	}
+	f.parse(start, old[n:], change)
+	return nil
+}
+
+// tokenize lexes and parses the whole source
+func (f *File) tokenize() {
+	tokens, err := lx.New(f.source).Tokenize()
+	if err != nil {
+		f.tokens, f.statements = nil, nil
+		f.fail(err.Error(), ast.Pos{})
+		return
+	}
+	f.tokens = tokens
+	f.statements = nil
+	f.parse(0, nil, lx.Change{})
+}
+
+// fail records an error found at [pos]
+func (f *File) fail(message string, pos ast.Pos) {
+	f.program = nil
+	f.err = &Error{Message: message, Line: pos.Line, Column: pos.Column}
+}
+
+// parse parses the top-level statements from the token at [start].
+// The statements in [old] starting after the tokens [change] changed
+// are reused once parsing reaches them if their lines didn't move
+func (f *File) parse(start int, old []statement, change lx.Change) {
+	p := &Parser{Lexer: f.end(), tokens: f.tokens, curr: start}
+	defer func() {
+		if rec := recover(); rec != nil {
+			perr, ok := rec.(error)
+			if !ok {
+				panic(rec)
+			}
+			f.fail(perr.Error(), p.Pos())
+		}
+	}()
+	f.err = nil
+
+	p.skipNewLines()
+	for t := p.peek(); t != nil && t.Type != lx.Unknown && t.Type != lx.EndOfInput; t = p.peek() {
+		// parsing a statement can split tokens so the indexes
+		// of the statements are kept in terms of the tokens
+		inserted := len(p.tokens) - len(f.tokens)
+		start := p.curr - inserted
+
+		// the old statements are reused from the first one parsing
+		// reaches. Parsing goes on after them in case the old
+		// statements stopped at an error
+		if change.Lines == 0 && start >= change.NewEnd {
+			if i := startingAt(old, start-change.NewEnd+change.End); i >= 0 {
+				moved := change.NewEnd - change.End
+				for _, s := range old[i:] {
+					s.start, s.end, s.seen = s.start+moved, s.end+moved, s.seen+moved
+					f.statements = append(f.statements, s)
+				}
+				p.curr = f.statements[len(f.statements)-1].end + inserted
+				old = nil
+				p.skipNewLines()
+				continue
+			}
+		}
+
+		p.seen = p.curr
+		node := p.parse_statement()
+		f.statements = append(f.statements, statement{
+			node:  node,
+			start: start,
+			end:   p.curr - (len(p.tokens) - len(f.tokens)),
+			seen:  p.seen - inserted,
+		})
+		p.skipNewLines()
+	}
+
+	var statements []core.Statement
+	for _, s := range f.statements {
+		statements = append(statements, s.node)
+	}
+	f.program = &ast.Program{Body: &ast.Block{
+		Statements: statements,
+	}}
+}
+
+// end returns a lexer at the end of the source. Errors
+// found at the end of the input are reported at its position
+func (f *File) end() *lx.Lexer {
+	l := &lx.Lexer{Line: 1, Column: 1}
+	for _, c := range f.source {
+		l.Column++
+		if c == '\n' {
+			l.Line++
+			l.Column = 1
+		}
+	}
+	return l
+}
+
+// startingAt returns the index of the statement in
+// [statements] starting at the token [start] or -1
+func startingAt(statements []statement, start int) int {
+	for i, s := range statements {
+		if s.start == start {
+			return i
+		}
+		if s.start > start {
+			break
+		}
+	}
+	return -1
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amupitan/hero/ast"
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// parseAll parses [source] from scratch and returns its program
+// or the error that is found
+func parseAll(source string) (program *ast.Program, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = rec.(error)
+		}
+	}()
+	p := New(source)
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	return p.Parse().Body.(*ast.Program), nil
+}
+
+func TestFile_Edit(t *testing.T) {
+	source := `x := 1
+func add(a, b int) int {
+	return a + b
+}
+s := ` + "`multi\nline`" + `
+switch x {
+case y: x++
+}
+total := add(x, 2)
+`
+
+	// the edits are applied in order and each result is
+	// compared with parsing the edited source from scratch
+	edits := []struct {
+		name string
+		edit lx.Edit
+	}{
+		{`edit a function body`, lx.Edit{StartLine: 3, StartColumn: 13, EndLine: 3, EndColumn: 14, Text: `a`}},
+		{`type a character`, lx.Edit{StartLine: 10, StartColumn: 17, EndLine: 10, EndColumn: 18, Text: `3`}},
+		{`break the syntax`, lx.Edit{StartLine: 2, StartColumn: 18, EndLine: 2, EndColumn: 19, Text: `(`}},
+		{`fix the syntax`, lx.Edit{StartLine: 2, StartColumn: 18, EndLine: 2, EndColumn: 19, Text: `)`}},
+		{`add lines`, lx.Edit{StartLine: 1, StartColumn: 7, EndLine: 1, EndColumn: 7, Text: "\ny := 2\n"}},
+		{`edit inside a string`, lx.Edit{StartLine: 8, StartColumn: 1, EndLine: 8, EndColumn: 5, Text: `LINE`}},
+		{`open a string`, lx.Edit{StartLine: 8, StartColumn: 1, EndLine: 8, EndColumn: 1, Text: "`"}},
+		{`close the string`, lx.Edit{StartLine: 8, StartColumn: 1, EndLine: 8, EndColumn: 2, Text: ``}},
+		{`edit a case`, lx.Edit{StartLine: 10, StartColumn: 6, EndLine: 10, EndColumn: 7, Text: `x`}},
+		{`unknown token`, lx.Edit{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 1, Text: `@`}},
+		{`remove the unknown token`, lx.Edit{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 2, Text: ``}},
+		{`remove lines`, lx.Edit{StartLine: 2, StartColumn: 1, EndLine: 4, EndColumn: 1, Text: ``}},
+		{`add a statement at the end`, lx.Edit{StartLine: 11, StartColumn: 1, EndLine: 11, EndColumn: 1, Text: `total++`}},
+		{`break the end`, lx.Edit{StartLine: 11, StartColumn: 8, EndLine: 11, EndColumn: 8, Text: `(`}},
+		{`edit before the error`, lx.Edit{StartLine: 1, StartColumn: 6, EndLine: 1, EndColumn: 7, Text: `5`}},
+	}
+
+	f := ParseFile(source)
+	for _, tt := range edits {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := f.Edit(tt.edit); err != nil {
+				t.Fatalf("File.Edit() error = %v", err)
+			}
+			want, wantErr := parseAll(f.Source())
+			if !reflect.DeepEqual(f.Program(), want) {
+				t.Errorf("File.Program() = %v, want %v", f.Program(), want)
+			}
+			if err := f.Err(); (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("File.Err() = %v, want %v", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestFile_Edit_reuse(t *testing.T) {
+	f := ParseFile(`x := 1
+func add(a, b int) int {
+	return a + b
+}
+total := add(x, 2)`)
+	before := f.Program().Body.Statements
+
+	if err := f.Edit(lx.Edit{StartLine: 3, StartColumn: 13, EndLine: 3, EndColumn: 14, Text: `a`}); err != nil {
+		t.Fatal(err)
+	}
+	after := f.Program().Body.Statements
+	if after[0] != before[0] || after[2] != before[2] {
+		t.Errorf("File.Edit() parsed the statements before and after the edit again")
+	}
+	if after[1] == before[1] {
+		t.Errorf("File.Edit() reused the edited statement")
+	}
+
+	// statements after added lines move so they are parsed again
+	if err := f.Edit(lx.Edit{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 1, Text: "\n"}); err != nil {
+		t.Fatal(err)
+	}
+	moved := f.Program().Body.Statements
+	if moved[0] != before[0] || moved[2] == before[2] {
+		t.Errorf("File.Edit() reused a statement whose lines moved or parsed one that didn't move again")
+	}
+}
+
+func TestFile_Edit_outside(t *testing.T) {
+	f := ParseFile(`x := 1`)
+	if err := f.Edit(lx.Edit{StartLine: 3, StartColumn: 1, EndLine: 3, EndColumn: 1, Text: `y`}); err == nil {
+		t.Errorf("File.Edit() error = nil, want an error for an edit outside the source")
+	}
+	if f.Source() != `x := 1` {
+		t.Errorf("File.Edit() changed the source to %q", f.Source())
+	}
+}
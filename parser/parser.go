@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"github.com/amupitan/hero/ast"
 	"github.com/amupitan/hero/ast/core"
 	lx "github.com/amupitan/hero/lexer"
 	"github.com/amupitan/hero/types"
@@ -15,15 +16,41 @@ type Parser struct {
 	curr    int
 	tokens  []lx.Token
 	err     error
+
+	// loops holds the names of the loops enclosing the
+	// statement being parsed. Unnamed loops have empty names
+	loops []string
+
+	// switches counts the switches enclosing the statement
+	// being parsed in the function being parsed
+	switches int
+
+	// functions counts the functions enclosing
+	// the statement being parsed
+	functions int
+
+	// typeParams holds the type parameters of the generic
+	// functions enclosing the statement being parsed
+	typeParams map[string]*types.TypeParam
+
+	// seen is the index of the furthest token looked at
+	seen int
 }
 
 type CustomType string
 
 var precedence = map[lx.TokenType]int{
 	lx.Assign: 1, lx.Increment: 1, lx.Decrement: 1, lx.PlusEq: 1, lx.MinusEq: 1, lx.DivEq: 1, lx.ModEq: 1, lx.TimesEq: 1,
-	lx.Or:       4,
-	lx.And:      5,
-	lx.LessThan: 9, lx.GreaterThan: 9, lx.LessThanOrEqual: 9, lx.GreaterThanOrEqual: 9, lx.Equal: 9, lx.NotEqual: 9,
+	lx.BitAndEq: 1, lx.BitOrEq: 1, lx.BitXorEq: 1,
+	lx.Question:     2,
+	lx.NullCoalesce: 3,
+	lx.Or:           4,
+	lx.And:          5,
+	lx.BitOr:        6,
+	lx.BitXor:       7,
+	lx.BitAnd:       8,
+	lx.LessThan:     9, lx.GreaterThan: 9, lx.LessThanOrEqual: 9, lx.GreaterThanOrEqual: 9, lx.Equal: 9, lx.NotEqual: 9,
+	lx.BitLeftShift: 11, lx.BitRightShift: 11,
 	lx.Plus: 12, lx.Minus: 12,
 	lx.Times: 15, lx.Div: 15, lx.Mod: 15,
 }
@@ -38,19 +65,10 @@ var VALUES = []lx.TokenType{
 	lx.RawString,
 	lx.Rune,
 	lx.Underscore,
+	lx.Null,
 }
 
-var literals = VALUES[1:]
-
-var builtins = map[string]types.Type{
-	`bool`:    types.Bool,
-	`float`:   types.Float,
-	`func`:    types.Func,
-	`generic`: types.Generic,
-	`int`:     types.Int,
-	`rune`:    types.Rune,
-	`string`:  types.String,
-}
+var builtins = types.Builtins
 
 // New returns a new parser
 func New(input string) *Parser {
@@ -62,10 +80,26 @@ func New(input string) *Parser {
 	return p
 }
 
+// Err returns the error found while tokenizing
+// the input of the parser, if any
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// Pos returns the position of the next token to be parsed.
+// It is the end of the input if there are no more tokens
+func (p *Parser) Pos() ast.Pos {
+	if t := p.peek(); t != nil && t.Type != lx.EndOfInput {
+		return ast.PosOf(*t)
+	}
+	return ast.Pos{Line: p.Lexer.Line, Column: p.Lexer.Column}
+}
+
 func (p *Parser) peek() *lx.Token {
 	if p.curr >= len(p.tokens) {
 		return nil
 	}
+	p.see(p.curr)
 	return &p.tokens[p.curr]
 }
 
@@ -73,9 +107,17 @@ func (p *Parser) lookahead() *lx.Token {
 	if p.curr+1 >= len(p.tokens) {
 		return nil
 	}
+	p.see(p.curr + 1)
 	return &p.tokens[p.curr+1]
 }
 
+// see records that the token at [i] was looked at
+func (p *Parser) see(i int) {
+	if i > p.seen {
+		p.seen = i
+	}
+}
+
 func (p *Parser) next() *lx.Token {
 	t := p.peek()
 	if t != nil {
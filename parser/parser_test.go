@@ -20,6 +20,42 @@ func expectPanic(t *testing.T, want interface{}) {
 	}
 }
 
+// sameTree returns true if the parsed tree [got] is deeply equal
+// to [want] regardless of the positions of their nodes. Positions
+// are tested by TestParser_positions
+func sameTree(got, want interface{}) bool {
+	clearPositions(reflect.ValueOf(got))
+	return reflect.DeepEqual(got, want)
+}
+
+// clearPositions zeroes the positions in the nodes reachable from [v]
+func clearPositions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			clearPositions(v.Elem())
+		}
+	case reflect.Slice:
+		if v.Type() == reflect.TypeOf([]ast.Pos{}) {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			clearPositions(v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(ast.Pos{}) {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				clearPositions(v.Field(i))
+			}
+		}
+	}
+}
+
 func TestParser_parse_expression(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -52,13 +88,125 @@ func TestParser_parse_expression(t *testing.T) {
 				Right:    &ast.Atom{Value: `3`, Type: lx.Int},
 			},
 		},
+		{
+			name:  "conditional",
+			input: "y = a > b ? a: b ?? 0",
+			want: &ast.Assignment{
+				Identifier: `y`,
+				Value: &ast.Conditional{
+					Condition: &ast.Binary{
+						Left:     &ast.Atom{Value: `a`, Type: lx.Identifier},
+						Operator: lx.Token{Value: `>`, Type: lx.GreaterThan, Line: 1, Column: 7},
+						Right:    &ast.Atom{Value: `b`, Type: lx.Identifier},
+					},
+					Then: &ast.Atom{Value: `a`, Type: lx.Identifier},
+					Else: &ast.Binary{
+						Left:     &ast.Atom{Value: `b`, Type: lx.Identifier},
+						Operator: lx.Token{Value: `??`, Type: lx.NullCoalesce, Line: 1, Column: 18},
+						Right:    &ast.Atom{Value: `0`, Type: lx.Int},
+					},
+				},
+			},
+		},
+		{
+			name:  "nested conditional",
+			input: "a ? b ? 1 : 2 : 3",
+			want: &ast.Conditional{
+				Condition: &ast.Atom{Value: `a`, Type: lx.Identifier},
+				Then: &ast.Conditional{
+					Condition: &ast.Atom{Value: `b`, Type: lx.Identifier},
+					Then:      &ast.Atom{Value: `1`, Type: lx.Int},
+					Else:      &ast.Atom{Value: `2`, Type: lx.Int},
+				},
+				Else: &ast.Atom{Value: `3`, Type: lx.Int},
+			},
+		},
+		{
+			name:  "bitwise precedence",
+			input: "a|b^c&d",
+			want: &ast.Binary{
+				Left:     &ast.Atom{Value: `a`, Type: lx.Identifier},
+				Operator: lx.Token{Value: `|`, Type: lx.BitOr, Line: 1, Column: 2},
+				Right: &ast.Binary{
+					Left:     &ast.Atom{Value: `b`, Type: lx.Identifier},
+					Operator: lx.Token{Value: `^`, Type: lx.BitXor, Line: 1, Column: 4},
+					Right: &ast.Binary{
+						Left:     &ast.Atom{Value: `c`, Type: lx.Identifier},
+						Operator: lx.Token{Value: `&`, Type: lx.BitAnd, Line: 1, Column: 6},
+						Right:    &ast.Atom{Value: `d`, Type: lx.Identifier},
+					},
+				},
+			},
+		},
+		{
+			name:  "shift binds tighter than comparison and looser than addition",
+			input: "1<<n+1<x",
+			want: &ast.Binary{
+				Left: &ast.Binary{
+					Left:     &ast.Atom{Value: `1`, Type: lx.Int},
+					Operator: lx.Token{Value: `<<`, Type: lx.BitLeftShift, Line: 1, Column: 2},
+					Right: &ast.Binary{
+						Left:     &ast.Atom{Value: `n`, Type: lx.Identifier},
+						Operator: lx.Token{Value: `+`, Type: lx.Plus, Line: 1, Column: 5},
+						Right:    &ast.Atom{Value: `1`, Type: lx.Int},
+					},
+				},
+				Operator: lx.Token{Value: `<`, Type: lx.LessThan, Line: 1, Column: 7},
+				Right:    &ast.Atom{Value: `x`, Type: lx.Identifier},
+			},
+		},
+		{
+			name:  "complement",
+			input: "~x&255",
+			want: &ast.Binary{
+				Left:     &ast.Atom{Value: `x`, Type: lx.Identifier, Complemented: true},
+				Operator: lx.Token{Value: `&`, Type: lx.BitAnd, Line: 1, Column: 3},
+				Right:    &ast.Atom{Value: `255`, Type: lx.Int},
+			},
+		},
+		{
+			name:  "null coalescing binds looser than ||",
+			input: "a ?? b || c",
+			want: &ast.Binary{
+				Left:     &ast.Atom{Value: `a`, Type: lx.Identifier},
+				Operator: lx.Token{Value: `??`, Type: lx.NullCoalesce, Line: 1, Column: 3},
+				Right: &ast.Binary{
+					Left:     &ast.Atom{Value: `b`, Type: lx.Identifier},
+					Operator: lx.Token{Value: `||`, Type: lx.Or, Line: 1, Column: 8},
+					Right:    &ast.Atom{Value: `c`, Type: lx.Identifier},
+				},
+			},
+		},
+		{
+			name:  "null check",
+			input: "x != null",
+			want: &ast.Binary{
+				Left:     &ast.Atom{Value: `x`, Type: lx.Identifier},
+				Operator: lx.Token{Value: `!=`, Type: lx.NotEqual, Line: 1, Column: 3},
+				Right:    &ast.Atom{Value: `null`, Type: lx.Null},
+			},
+		},
+		{
+			name:  "compound bitwise assignment",
+			input: "flags |= 1 << 2",
+			want: &ast.Assignment{
+				Identifier: `flags`,
+				Value: &ast.Operation{Type: lx.BitOrEq, Value: &ast.Binary{
+					Left:     &ast.Atom{Value: `1`, Type: lx.Int},
+					Operator: lx.Token{Value: `<<`, Type: lx.BitLeftShift, Line: 1, Column: 12},
+					Right:    &ast.Atom{Value: `2`, Type: lx.Int},
+				}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := New(tt.input)
-			if got := p.parse_expression(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_expression()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_expression() = %s,\n want %s", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -169,7 +317,8 @@ func TestParser_parse_statement(t *testing.T) {
 					Operator: lx.Token{Type: lx.Equal, Value: `==`, Line: 1, Column: 7},
 					Right:    &ast.Atom{Type: lx.Identifier, Value: `j`},
 				},
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
 			},
 		},
 		{
@@ -183,9 +332,76 @@ func TestParser_parse_statement(t *testing.T) {
 					Operator: lx.Token{Type: lx.Equal, Value: `==`, Line: 2, Column: 10},
 					Right:    &ast.Atom{Type: lx.Identifier, Value: `j`},
 				},
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 2, Column: 4},
+			},
+		},
+		{
+			name:  `parse lambda call`,
+			input: `func() {}()`,
+			want: &ast.Call{
+				Func: &ast.Function{
+					Definition:  ast.Definition{Type: string(lx.Func)},
+					Parameters:  []*ast.Param{},
+					ReturnTypes: []types.Type{},
+					Body:        &ast.Block{},
+					Lambda:      true,
+				},
+				Args:  []core.Expression{},
+				Token: lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 10},
+			},
+		},
+		{
+			name:  `parse interface`,
+			input: "interface Number {\n\tint | float\n}",
+			want:  &ast.Interface{Name: `Number`, Types: []types.Type{types.Int, types.Float}},
+		},
+		{
+			name:        `interface missing separator`,
+			input:       `interface Number { int float }`,
+			shouldPanic: true,
+		},
+		{
+			name:        `break outside loop`,
+			input:       `break`,
+			shouldPanic: true,
+		},
+		{
+			name:        `continue outside loop`,
+			input:       `continue`,
+			shouldPanic: true,
+		},
+		{
+			name:  `break in switch outside loop`,
+			input: "switch x {\ncase 1:\n\tbreak\n}",
+			want: &ast.Switch{
+				Value: &ast.Atom{Type: lx.Identifier, Value: `x`},
+				Cases: []*ast.Case{{
+					Values: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}},
+					Body:   &ast.Block{Statements: []core.Statement{&ast.Break{}}},
+				}},
 			},
 		},
+		{
+			name:        `continue in switch outside loop`,
+			input:       "switch x {\ncase 1:\n\tcontinue\n}",
+			shouldPanic: true,
+		},
+		{
+			name:        `break in function in switch`,
+			input:       "switch x {\ncase 1:\n\tfunc() { break }()\n}",
+			shouldPanic: true,
+		},
+		{
+			name:        `defer outside function`,
+			input:       `defer f()`,
+			shouldPanic: true,
+		},
+		{
+			name:        `undefined loop label`,
+			input:       `for { break outer }`,
+			shouldPanic: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -193,9 +409,11 @@ func TestParser_parse_statement(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_statement(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_statement()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_statement() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -234,6 +452,11 @@ func TestParser_attempt_parse_definition(t *testing.T) {
 			want:        nil,
 			shouldPanic: true,
 		},
+		{
+			name:  `nullable variable declaration`,
+			input: `var name string? = null`,
+			want:  &ast.Definition{Name: `name`, Type: `string?`, Value: &ast.Atom{Value: `null`, Type: lx.Null}},
+		},
 		{
 			name:  `short variable declaration with type and value`,
 			input: `foo := 0`,
@@ -260,9 +483,89 @@ func TestParser_attempt_parse_definition(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.attempt_parse_definition(); !reflect.DeepEqual(got, tt.want) {
+			got := p.attempt_parse_definition()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.attempt_parse_definition() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
+		})
+	}
+}
+
+func TestParser_attempt_parse_multi_assignment(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        *ast.MultiAssignment
+		shouldPanic bool
+	}{
+		{
+			name:  `definition from call`,
+			input: `q, r := divmod(7, 2)`,
+			want: &ast.MultiAssignment{
+				Identifiers: []string{`q`, `r`},
+				Values: []core.Expression{&ast.Call{Name: `divmod`, Args: []core.Expression{
+					&ast.Atom{Type: lx.Int, Value: `7`},
+					&ast.Atom{Type: lx.Int, Value: `2`},
+				}, Token: lx.Token{Type: lx.Identifier, Value: `divmod`, Line: 1, Column: 9}}},
+				Define: true,
+			},
+		},
+		{
+			name:  `swap`,
+			input: `a, b = b, a`,
+			want: &ast.MultiAssignment{
+				Identifiers: []string{`a`, `b`},
+				Values: []core.Expression{
+					&ast.Atom{Type: lx.Identifier, Value: `b`},
+					&ast.Atom{Type: lx.Identifier, Value: `a`},
+				},
+			},
+		},
+		{
+			name:  `discarded value`,
+			input: `_, r := divmod(7, 2)`,
+			want: &ast.MultiAssignment{
+				Identifiers: []string{`_`, `r`},
+				Values: []core.Expression{&ast.Call{Name: `divmod`, Args: []core.Expression{
+					&ast.Atom{Type: lx.Int, Value: `7`},
+					&ast.Atom{Type: lx.Int, Value: `2`},
+				}, Token: lx.Token{Type: lx.Identifier, Value: `divmod`, Line: 1, Column: 9}}},
+				Define: true,
+			},
+		},
+		{
+			name:  `single identifier`,
+			input: `a := 1`,
+			want:  nil,
+		},
+		{
+			name:  `not an assignment`,
+			input: `a, b`,
+			want:  nil,
+		},
+		{
+			name:        `too many values`,
+			input:       `a, b := 1, 2, 3`,
+			shouldPanic: true,
+		},
+		{
+			name:        `single value that isn't a call`,
+			input:       `a, b := 1`,
+			shouldPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.input)
+			if tt.shouldPanic {
+				defer expectPanic(t, nil)
+			}
+			got := p.attempt_parse_multi_assignment()
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.attempt_parse_multi_assignment() = %v, want %v", got, tt.want)
+			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -428,14 +731,16 @@ func TestParser_parse_binary(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_binary(tt.args.left, tt.args.my_op); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_binary(tt.args.left, tt.args.my_op)
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_binary() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
 
-func TestParser_attempt_parse_call(t *testing.T) {
+func TestParser_parse_operand(t *testing.T) {
 	tests := []struct {
 		name        string
 		input       string
@@ -443,12 +748,9 @@ func TestParser_attempt_parse_call(t *testing.T) {
 		shouldPanic bool
 	}{
 		{
-			name:  `call with two args`,
+			name:  `identifier`,
 			input: `print(1, "hello")`,
-			want: &ast.Call{
-				Name: `print`,
-				Args: []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: `string`, Value: `hello`}},
-			},
+			want:  &ast.Atom{Type: lx.Identifier, Value: `print`},
 		},
 		{
 			name:  `lambda declaration call with 2 args`,
@@ -461,9 +763,20 @@ func TestParser_attempt_parse_call(t *testing.T) {
 					Body:        &ast.Block{},
 					Lambda:      true,
 				},
-				Args: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}, &ast.Atom{Type: lx.Identifier, Value: `z`}},
+				Args:  []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}, &ast.Atom{Type: lx.Identifier, Value: `z`}},
+				Token: lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 18},
 			},
 		},
+		{
+			name:  `expression in parenthesis`,
+			input: `(x)`,
+			want:  &ast.Atom{Type: lx.Identifier, Value: `x`},
+		},
+		{
+			name:        `operator`,
+			input:       `*`,
+			shouldPanic: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -471,9 +784,11 @@ func TestParser_attempt_parse_call(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.attempt_parse_call(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Parser.attempt_parse_call() = %v, want %v", got, tt.want)
+			got := p.parse_operand()
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.parse_operand() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -544,9 +859,11 @@ func TestParser_delimited(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.delimited(tt.args.start, tt.args.stop, tt.args.separator, tt.args.end_sep, tt.args.expr_parser); !reflect.DeepEqual(got, tt.want) {
+			got := p.delimited(tt.args.start, tt.args.stop, tt.args.separator, tt.args.end_sep, tt.args.expr_parser)
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.delimited() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -602,9 +919,74 @@ func TestParser_parse_atom(t *testing.T) {
 			name:  `call object method with two args`,
 			input: `foo.print(1, "hello")`,
 			want: &ast.Call{
-				Name:   `print`,
+				Callee: &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `foo`}, Name: `print`},
 				Args:   []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: `string`, Value: `hello`}},
-				Object: `foo`,
+				Token:  lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 10},
+			},
+		},
+		{
+			name:  `field access`,
+			input: `cfg.entries`,
+			want:  &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `cfg`}, Name: `entries`},
+		},
+		{
+			name:  `negated field access`,
+			input: `!cfg.enabled`,
+			want:  &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `cfg`}, Name: `enabled`, Negated: true},
+		},
+		{
+			name:  `empty list`,
+			input: `[]`,
+			want:  &ast.List{Elements: []core.Expression{}},
+		},
+		{
+			name:  `list with separator at the end`,
+			input: `[1, x,]`,
+			want: &ast.List{Elements: []core.Expression{
+				&ast.Atom{Type: lx.Int, Value: `1`},
+				&ast.Atom{Type: lx.Identifier, Value: `x`},
+			}},
+		},
+		{
+			name:  `empty map`,
+			input: `[:]`,
+			want:  &ast.Map{Keys: []core.Expression{}, Values: []core.Expression{}},
+		},
+		{
+			name: `map with identifier keys`,
+			input: `[one: 1,
+				two: 2]`,
+			want: &ast.Map{
+				Keys:   []core.Expression{&ast.Atom{Type: lx.Identifier, Value: `one`}, &ast.Atom{Type: lx.Identifier, Value: `two`}},
+				Values: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}, &ast.Atom{Type: lx.Int, Value: `2`}},
+			},
+		},
+		{
+			name:        `map with missing key`,
+			input:       `["one": 1, 2]`,
+			shouldPanic: true,
+		},
+		{
+			name:        `negated list`,
+			input:       `![true]`,
+			shouldPanic: true,
+		},
+		{
+			name:  `negated field access chain`,
+			input: `!a.b.c`,
+			want: &ast.Selector{
+				Object:  &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `a`}, Name: `b`},
+				Name:    `c`,
+				Negated: true,
+			},
+		},
+		{
+			name:  `signed index`,
+			input: `-xs[0]`,
+			want: &ast.Index{
+				Object: &ast.Atom{Type: lx.Identifier, Value: `xs`},
+				Index:  &ast.Atom{Type: lx.Int, Value: `0`},
+				Signed: true,
 			},
 		},
 		{
@@ -635,16 +1017,16 @@ func TestParser_parse_atom(t *testing.T) {
 		{
 			name:  `negated call`,
 			input: `!isWild()`,
-			want:  &ast.Call{Name: `isWild`, Negated: true, Args: []core.Expression{}},
+			want:  &ast.Call{Name: `isWild`, Negated: true, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `isWild`, Line: 1, Column: 2}},
 		},
 		{
 			name:  `negated object call`,
 			input: `!foo.print(1, "hello")`,
 			want: &ast.Call{
-				Name:    `print`,
+				Callee:  &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `foo`}, Name: `print`},
 				Args:    []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: `string`, Value: `hello`}},
-				Object:  `foo`,
 				Negated: true,
+				Token:   lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 11},
 			},
 		},
 		{
@@ -700,21 +1082,21 @@ func TestParser_parse_atom(t *testing.T) {
 		{
 			name:  `sign specified  call`,
 			input: `+isWild()`,
-			want:  &ast.Call{Name: `isWild`, Args: []core.Expression{}},
+			want:  &ast.Call{Name: `isWild`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `isWild`, Line: 1, Column: 2}},
 		},
 		{
 			name:  `signed call`,
 			input: `-isWild()`,
-			want:  &ast.Call{Name: `isWild`, Signed: true, Args: []core.Expression{}},
+			want:  &ast.Call{Name: `isWild`, Signed: true, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `isWild`, Line: 1, Column: 2}},
 		},
 		{
 			name:  `signed object call`,
 			input: `-foo.print(1, "hello")`,
 			want: &ast.Call{
-				Name:   `print`,
+				Callee: &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `foo`}, Name: `print`},
 				Args:   []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: `string`, Value: `hello`}},
-				Object: `foo`,
 				Signed: true,
+				Token:  lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 11},
 			},
 		},
 		{
@@ -744,9 +1126,59 @@ func TestParser_parse_atom(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_atom(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_atom()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_atom() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
+
+func TestParser_positions(t *testing.T) {
+	input := `x := 1
+func add(a, b int) int {
+	return a + b
+}
+for i, v in [x] {}
+try {} catch err {}
+x, y = 2, 3
+x++
+interface Number { int }`
+	statements := New(input).Parse().Body.(*ast.Program).Body.Statements
+
+	def := statements[0].(*ast.Definition)
+	add := statements[1].(*ast.Function)
+	sum := add.Body.Statements[0].(*ast.Return).Values[0].(*ast.Binary)
+	loop := statements[2].(*ast.RangeLoop)
+	try := statements[3].(*ast.Try)
+	multi := statements[4].(*ast.MultiAssignment)
+	increment := statements[5].(*ast.Assignment)
+	iface := statements[6].(*ast.Interface)
+
+	tests := []struct {
+		name string
+		got  ast.Pos
+		want ast.Pos
+	}{
+		{name: `definition`, got: def.Pos, want: ast.Pos{Line: 1, Column: 1}},
+		{name: `literal`, got: def.Value.(*ast.Atom).Pos, want: ast.Pos{Line: 1, Column: 6}},
+		{name: `function`, got: add.Pos, want: ast.Pos{Line: 2, Column: 6}},
+		{name: `first parameter`, got: add.Parameters[0].Pos, want: ast.Pos{Line: 2, Column: 10}},
+		{name: `second parameter`, got: add.Parameters[1].Pos, want: ast.Pos{Line: 2, Column: 13}},
+		{name: `identifier`, got: sum.Right.(*ast.Atom).Pos, want: ast.Pos{Line: 3, Column: 13}},
+		{name: `range first`, got: loop.FirstPos, want: ast.Pos{Line: 5, Column: 5}},
+		{name: `range second`, got: loop.SecondPos, want: ast.Pos{Line: 5, Column: 8}},
+		{name: `iterable`, got: loop.Iterable.(*ast.List).Elements[0].(*ast.Atom).Pos, want: ast.Pos{Line: 5, Column: 14}},
+		{name: `caught error`, got: try.NamePos, want: ast.Pos{Line: 6, Column: 14}},
+		{name: `first of multiple`, got: multi.Positions[0], want: ast.Pos{Line: 7, Column: 1}},
+		{name: `second of multiple`, got: multi.Positions[1], want: ast.Pos{Line: 7, Column: 4}},
+		{name: `assignment`, got: increment.Pos, want: ast.Pos{Line: 8, Column: 1}},
+		{name: `interface`, got: iface.Pos, want: ast.Pos{Line: 9, Column: 11}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s is at %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
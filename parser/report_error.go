@@ -9,6 +9,19 @@ import (
 	lx "github.com/amupitan/hero/lexer"
 )
 
+// Error is an error found while lexing or parsing a file
+type Error struct {
+	Message string
+
+	// Line and Column locate the token the error was found
+	// at. They are 0 for errors found while lexing
+	Line, Column int
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
 // report creates an error with message and panics
 func report(message string) {
 	panic(errors.New(message))
@@ -24,7 +37,8 @@ func (p *Parser) reportEndOfInput(expected *lx.TokenType) error {
 // reportUnexpected returns an error of receiving a wrong token type
 func (p *Parser) reportUnexpected(expected *lx.TokenType) error {
 	// TODO(DEV) add file name
-	return fmt.Errorf("%d:%d: Expected `%v` but found `%s`.", p.Lexer.Line, p.Lexer.Column, *expected, p.peek().Value)
+	pos := p.Pos()
+	return fmt.Errorf("%d:%d: Expected `%v` but found `%s`.", pos.Line, pos.Column, *expected, p.peek().Value)
 }
 
 // reportUnexpectedMultiple returns an error for expecting one of a set of
@@ -34,9 +48,10 @@ func (p *Parser) reportUnexpectedMultiple(expected ...lx.TokenType) error {
 	sb := bytes.Buffer{}
 
 	// Add line and column info
-	sb.WriteString(strconv.Itoa(p.Lexer.Line)) // TODO(DEV) this or rune-int calculation
+	pos := p.Pos()
+	sb.WriteString(strconv.Itoa(pos.Line))
 	sb.WriteRune(':')
-	sb.WriteString(strconv.Itoa(p.Lexer.Column)) // TODO(DEV) this or rune-int calculation
+	sb.WriteString(strconv.Itoa(pos.Column))
 	sb.WriteString(": Expected either ")
 	for _, ex := range expected {
 		sb.WriteString(string(ex))
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strconv"
+
 	"github.com/amupitan/hero/ast"
 	"github.com/amupitan/hero/ast/core"
 	lx "github.com/amupitan/hero/lexer"
@@ -31,17 +33,37 @@ func (p *Parser) parse_statement() core.Statement {
 	case lx.For:
 		return p.parse_loop()
 	case lx.Func:
-		return p.parse_func(false)
+		// a lambda has no name
+		if lookahead := p.lookahead(); lookahead == nil || lookahead.Type != lx.LeftParenthesis {
+			return p.parse_func(false)
+		}
 	case lx.If:
 		return p.parse_if()
+	case lx.Interface:
+		return p.parse_interface()
+	case lx.Switch:
+		return p.parse_switch()
+	case lx.Try:
+		return p.parse_try()
+	case lx.Throw:
+		return p.parse_throw()
+	case lx.Defer:
+		return p.parse_defer()
 	case lx.LeftBrace:
 		return p.parse_block()
 	case lx.Return:
 		return p.parse_return()
+	case lx.Break, lx.Continue:
+		return p.parse_break_or_continue()
 		//TODO
 
 	}
 
+	// attempt to parse assignment to multiple identifiers
+	if m := p.attempt_parse_multi_assignment(); m != nil {
+		return m
+	}
+
 	// attempt to parse definition
 	if d := p.attempt_parse_definition(); d != nil {
 		return d
@@ -54,78 +76,269 @@ func (p *Parser) parse_expression() core.Expression {
 	return p.parse_binary(p.parse_atom(), nil)
 }
 
-// attempt_parse_call attempts to parse a call or returns nil if a call can't be parsed
-// it parses a lambda function if possible
-func (p *Parser) attempt_parse_call() core.Expression {
-
-	if p.accept(lx.Identifier) {
-		// This can't simply be changed to return p.attempt_parse_named_call()
-		// because p.attempt_parse_named_call() can never return nil since
-		// it has a type. See: https://golang.org/doc/faq#nil_error
-		if c := p.attempt_parse_named_call(); c != nil {
-			return c
-		}
-		return nil
-	}
-	return p.attempt_parse_lambda_call()
-}
-
 // attempt_parse_lambda_call attempts to parse a call
 // from a lambda expression, returns the lambda expression
 // if it is not call or panics if neither is possible
 func (p *Parser) attempt_parse_lambda_call() core.Expression {
 	f := p.parse_func(true)
 	if t := p.peek(); t.Type == lx.LeftParenthesis {
+		args, named, spread := p.parse_call_args()
 		return &ast.Call{
-			Args: p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, nil),
-			Func: f,
+			Args:   args,
+			Named:  named,
+			Func:   f,
+			Spread: spread,
+			Token:  *t,
 		}
 	}
 
 	return f
 }
 
-// attempt_parse_named_call attempts to parse a call
-// from an identifier
-func (p *Parser) attempt_parse_named_call() *ast.Call {
-	object := ``
-	identifier := p.expect(lx.Identifier)
-	if p.nextIs(lx.Dot) {
-		// consume dot
+// parse_postfix parses the field accesses, indexes and calls
+// that follow an expression e.g. a.b[0](c). They must start
+// on the same line as the expression
+func (p *Parser) parse_postfix(e core.Expression) core.Expression {
+	for {
+		switch {
+		case p.nextIs(lx.Dot):
+			// consume dot
+			p.next()
+
+			e = &ast.Selector{Object: e, Name: p.expect(lx.Identifier).Value}
+		case p.nextIs(lx.LeftBracket):
+			// consume left bracket
+			p.next()
+
+			index := p.parse_expression()
+			p.expect(lx.RightBracket)
+			e = &ast.Index{Object: e, Index: index}
+		case p.nextIs(lx.LeftParenthesis):
+			// a call to a builtin type name converts its argument
+			if a, ok := e.(*ast.Atom); ok && a.Type == lx.Identifier {
+				if to, ok := builtins[a.Value]; ok {
+					e = p.parse_conversion(to, p.tokens[p.curr-1])
+					continue
+				}
+			}
+
+			// a named call is located at its name
+			at := *p.peek()
+			if a, ok := e.(*ast.Atom); ok && a.Type == lx.Identifier {
+				at = p.tokens[p.curr-1]
+			}
+
+			args, named, spread := p.parse_call_args()
+			call := newCall(e, args)
+			call.Named, call.Spread, call.Token = named, spread, at
+			e = call
+		default:
+			return e
+		}
+	}
+}
+
+// parse_conditional parses the branches of a conditional expression
+// whose condition is [cond] e.g. a : b in c ? a : b. The question
+// mark is already consumed
+func (p *Parser) parse_conditional(cond core.Expression) *ast.Conditional {
+	ensureBoolean(cond)
+	p.split_label()
+	c := &ast.Conditional{Condition: cond, Then: p.parse_expression()}
+	p.expect(lx.Colon)
+	c.Else = p.parse_expression()
+	return c
+}
+
+// parse_conversion parses the value converted to the builtin type
+// [to] e.g. ("42") in int("42"). [at] is the type name
+func (p *Parser) parse_conversion(to types.Type, at lx.Token) *ast.Conversion {
+	args, named, spread := p.parse_call_args()
+	if len(args) != 1 || len(named) > 0 || spread {
+		report(`conversion to ` + to.String() + ` expects 1 argument`)
+	}
+	return &ast.Conversion{Type: to, Value: args[0], Token: at}
+}
+
+// parse_call_args parses the arguments of a call in parenthesis.
+// Positional arguments are returned before named arguments
+// e.g. connect("x", port: 9000). It returns true if the final
+// positional argument is spread e.g. sum(xs...)
+func (p *Parser) parse_call_args() ([]core.Expression, []*ast.NamedArg, bool) {
+	spread := false
+	var named []*ast.NamedArg
+	args := p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, func(p *Parser) core.Expression {
+		if spread {
+			report(`only the final argument can be spread with ...`)
+		}
+
+		if arg := p.attempt_parse_named_arg(); arg != nil {
+			named = append(named, arg)
+			return arg
+		}
+		if named != nil {
+			report(`positional arguments must come before named arguments`)
+		}
+
+		arg := p.parse_expression()
+		if p.accept(lx.Ellipsis) {
+			// consume ellipsis
+			p.next()
+			spread = true
+		}
+		return arg
+	})
+
+	// named arguments are at the end of the arguments
+	return args[:len(args)-len(named)], named, spread
+}
+
+// attempt_parse_named_arg attempts to parse a named argument
+// e.g. port: 9000 or returns nil if it can't be parsed. The lexer
+// folds an identifier that is directly followed by a colon into a
+// loop name, so `port: 9000` starts with a loop name
+func (p *Parser) attempt_parse_named_arg() *ast.NamedArg {
+	var name string
+	if p.accept(lx.LoopName) {
+		name = p.next().Value
+	} else if lookahead := p.lookahead(); p.accept(lx.Identifier) && lookahead != nil && lookahead.Type == lx.Colon {
+		name = p.next().Value
+
+		// consume colon
 		p.next()
+	} else {
+		return nil
+	}
+	return &ast.NamedArg{Name: name, Value: p.parse_expression()}
+}
 
-		object = identifier.Value
-		identifier = p.expect(lx.Identifier)
+// newCall creates a call of [callee] with [args]. Identifiers
+// create named calls and lambdas create lambda calls
+func newCall(callee core.Expression, args []core.Expression) *ast.Call {
+	switch c := callee.(type) {
+	case *ast.Atom:
+		if c.Type == lx.Identifier {
+			return &ast.Call{Name: c.Value, Args: args}
+		}
+	case *ast.Function:
+		return &ast.Call{Func: c, Args: args}
 	}
-	params := p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, nil)
-	if params == nil {
-		// if parse was unsuccessful, retract and return
-		p.unstep()
+	return &ast.Call{Callee: callee, Args: args}
+}
+
+// parse_list_or_map parses a list literal e.g. [1, 2] or a
+// map literal e.g. ["one": 1, "two": 2]. An empty map is
+// written as [:]
+func (p *Parser) parse_list_or_map() core.Expression {
+	// consume left bracket
+	pos := ast.PosOf(*p.expect(lx.LeftBracket))
+
+	// empty list
+	if p.accept(lx.RightBracket) {
+		p.next()
+		return &ast.List{Elements: []core.Expression{}, Pos: pos}
+	}
+
+	// empty map
+	if p.accept(lx.Colon) {
+		p.next()
+		p.expect(lx.RightBracket)
+		return &ast.Map{Keys: []core.Expression{}, Values: []core.Expression{}, Pos: pos}
+	}
+
+	// parse_key parses a map key and its colon. It returns nil if
+	// there is no key, which makes the literal a list. The lexer
+	// folds an identifier that is directly followed by a colon
+	// into a loop name, so `[key: value]` starts with a loop name
+	parse_key := func(first core.Expression) core.Expression {
+		if first == nil && p.accept(lx.LoopName) {
+			t := p.next()
+			return &ast.Atom{Type: lx.Identifier, Value: t.Value, Pos: ast.PosOf(*t)}
+		}
+		if first == nil {
+			first = p.parse_expression()
+		}
+		if p.accept(lx.Colon) {
+			// consume colon
+			p.next()
+			return first
+		}
 		return nil
 	}
 
-	// TODO: convert expression to call.params?
-	return &ast.Call{
-		Name:   identifier.Value,
-		Args:   params,
-		Object: object,
+	var first core.Expression
+	if !p.accept(lx.LoopName) {
+		first = p.parse_expression()
+	}
+
+	key := parse_key(first)
+	if key == nil {
+		elements := []core.Expression{first}
+		for {
+			if p.accept(lx.RightBracket) {
+				break
+			}
+			p.expect(lx.Comma)
+
+			// a separator is allowed at the end
+			if p.accept(lx.RightBracket) {
+				break
+			}
+			elements = append(elements, p.parse_expression())
+		}
+
+		// consume right bracket
+		p.next()
+
+		return &ast.List{Elements: elements, Pos: pos}
+	}
+
+	m := &ast.Map{
+		Keys:   []core.Expression{key},
+		Values: []core.Expression{p.parse_expression()},
+		Pos:    pos,
 	}
+	for {
+		if p.accept(lx.RightBracket) {
+			break
+		}
+		p.expect(lx.Comma)
+
+		// a separator is allowed at the end
+		if p.accept(lx.RightBracket) {
+			break
+		}
+
+		if key = parse_key(nil); key == nil {
+			// TODO(REPORT) better message
+			report(`Expected a key in map literal`)
+		}
+		m.Keys = append(m.Keys, key)
+		m.Values = append(m.Values, p.parse_expression())
+	}
+
+	// consume right bracket
+	p.next()
+
+	return m
 }
 
 // attempt_parse_definition attempts to parse a definition or returns nil if it can't be parsed
 func (p *Parser) attempt_parse_definition() *ast.Definition {
 	var name, Type string
 	var value core.Expression
+	var pos ast.Pos
 	if p.accept(lx.Var) {
 		// consume var keyword
 		p.next()
 
 		// consume identifier name
-		name = p.expect(lx.Identifier).Value
+		t := p.expect(lx.Identifier)
+		name, pos = t.Value, ast.PosOf(*t)
 
 		// check if type is present
-		if p.accept(lx.Identifier) {
-			Type = p.next().Value
+		if p.acceptsOneOf(lx.Identifier, lx.Func) {
+			Type = p.parse_type().String()
 
 			// consume value if assign token is present
 			if p.accept(lx.Assign) {
@@ -144,7 +357,8 @@ func (p *Parser) attempt_parse_definition() *ast.Definition {
 	} else if p.accept(lx.Identifier) {
 		if lookahead := p.lookahead(); lookahead != nil && lookahead.Type == lx.Declare {
 			// consume identifier as name
-			name = p.next().Value
+			t := p.next()
+			name, pos = t.Value, ast.PosOf(*t)
 
 			// consume operator
 			p.next()
@@ -164,12 +378,68 @@ func (p *Parser) attempt_parse_definition() *ast.Definition {
 		Name:  name,
 		Value: value,
 		Type:  Type,
+		Pos:   pos,
+	}
+}
+
+// attempt_parse_multi_assignment attempts to parse an assignment or
+// definition of multiple identifiers e.g. q, r := divmod(7, 2) or
+// returns nil if it can't be parsed
+func (p *Parser) attempt_parse_multi_assignment() *ast.MultiAssignment {
+	// get parser cursor before parse attempt
+	initial := p.curr
+
+	// consume identifiers separated by commas
+	identifiers := make([]string, 0, 5) // we assume most multi-assignments have ≤ 5 identifiers
+	positions := make([]ast.Pos, 0, 5)
+	for p.acceptsOneOf(lx.Identifier, lx.Underscore) {
+		t := p.next()
+		identifiers = append(identifiers, t.Value)
+		positions = append(positions, ast.PosOf(*t))
+		if !p.nextIs(lx.Comma) {
+			break
+		}
+
+		// consume comma
+		p.next()
+	}
+
+	if len(identifiers) < 2 || !(p.nextIs(lx.Declare) || p.nextIs(lx.Assign)) {
+		// restore parser cursor if it isn't a multi-assignment
+		p.curr = initial
+		return nil
+	}
+
+	// consume := or =
+	define := p.next().Type == lx.Declare
+
+	values := []core.Expression{p.parse_expression()}
+	for p.nextIs(lx.Comma) {
+		// consume comma
+		p.next()
+		values = append(values, p.parse_expression())
+	}
+
+	// a single value must be a call that returns multiple values
+	if len(values) > 1 && len(values) != len(identifiers) {
+		report(`assignment mismatch: ` + strconv.Itoa(len(identifiers)) + ` variables but ` +
+			strconv.Itoa(len(values)) + ` values`)
+	}
+	if _, isCall := values[0].(*ast.Call); len(values) == 1 && !isCall {
+		report(`assignment mismatch: ` + strconv.Itoa(len(identifiers)) + ` variables but 1 value`)
+	}
+
+	return &ast.MultiAssignment{
+		Identifiers: identifiers,
+		Values:      values,
+		Define:      define,
+		Positions:   positions,
 	}
 }
 
 // parse_atom parses out an atom - which is a literal value or identifier
 func (p *Parser) parse_atom() core.Expression {
-	isSigned := false
+	isSigned, isComplemented := false, false
 	// check for negation
 	isNegated := p.accept(lx.Not)
 	if isNegated {
@@ -180,6 +450,9 @@ func (p *Parser) parse_atom() core.Expression {
 		isSigned = p.nextIs(lx.Minus)
 		// consume + or - token
 		p.next()
+	} else if isComplemented = p.accept(lx.BitNot); isComplemented {
+		// consume bitwise complement token
+		p.next()
 	}
 
 	signAndOrNegate := func(exp core.Expression) {
@@ -193,8 +466,44 @@ func (p *Parser) parse_atom() core.Expression {
 		if isSigned {
 			signExpr(exp)
 		}
+
+		// attempt to complement if there was a ~
+		if isComplemented {
+			complementExpr(exp)
+		}
+	}
+
+	exp := p.parse_postfix(p.parse_operand())
+
+	// only identifiers and booleans can be negated and only
+	// identifiers and numbers can be signed
+	if a, ok := exp.(*ast.Atom); ok {
+		if isNegated && !isBooleanAble(a.Type) {
+			// TODO(REPORT) better message
+			report(`cannot negate non-boolean type`)
+			return nil
+		}
+
+		if isSigned && !isSignSpecifiable(a.Type) {
+			// TODO(REPORT) better message
+			report(`cannot specify sign of non-number type`)
+			return nil
+		}
+
+		if isComplemented && !isComplementable(a.Type) {
+			report(`cannot complement non-integer type`)
+			return nil
+		}
 	}
 
+	signAndOrNegate(exp)
+	return exp
+}
+
+// parse_operand parses an operand without its sign, negation or
+// postfix. It is a literal, identifier, lambda, list, map or an
+// expression in parenthesis
+func (p *Parser) parse_operand() core.Expression {
 	// attempt to consume expression in a parenthesis
 	if p.accept(lx.LeftParenthesis) {
 		// skip left paren
@@ -204,38 +513,24 @@ func (p *Parser) parse_atom() core.Expression {
 		// consume right paren
 		p.expect(lx.RightParenthesis)
 
-		signAndOrNegate(exp)
 		return exp
 	}
 
-	// parse call if it is a named or lambda call
-	if p.nextIs(lx.Identifier) || p.nextIs(lx.Func) {
-		if e := p.attempt_parse_call(); e != nil {
-			signAndOrNegate(e)
-			return e
-		}
+	// parse lambda or lambda call
+	if p.nextIs(lx.Func) {
+		return p.attempt_parse_lambda_call()
 	}
 
-	t := p.expectsOneOf(VALUES...)
-
-	if isNegated && !isBooleanAble(t.Type) {
-		// TODO(REPORT) better message
-		report(`cannot negate non-boolean type`)
-		return nil
-	}
-
-	if isSigned && !isSignSpecifiable(t.Type) {
-		// TODO(REPORT) better message
-		report(`cannot specify sign of non-number type`)
-		return nil
+	// parse list or map literal
+	if p.accept(lx.LeftBracket) {
+		return p.parse_list_or_map()
 	}
 
-	// TODO: allow functions
+	t := p.expectsOneOf(VALUES...)
 	return &ast.Atom{
-		Type:    t.Type,
-		Value:   t.Value,
-		Negated: isNegated,
-		Signed:  isSigned,
+		Type:  t.Type,
+		Value: t.Value,
+		Pos:   ast.PosOf(*t),
 	}
 }
 
@@ -267,6 +562,10 @@ func (p *Parser) parse_binary(left core.Expression, my_op *lx.TokenType) core.Ex
 	// consume operator
 	op := p.next()
 
+	if op.Type == lx.Question {
+		return p.parse_binary(p.parse_conditional(left), my_op)
+	}
+
 	right := p.parse_binary(p.parse_atom(), &(op.Type))
 	b := &ast.Binary{
 		Left:     left,
@@ -308,6 +607,7 @@ func (p *Parser) parse_assignment(e core.Expression) core.Expression {
 		return &ast.Assignment{
 			Identifier: a.Left.String(),
 			Value:      value,
+			Pos:        a.Left.(*ast.Atom).Pos,
 		}
 	case *ast.Atom:
 		if a.Type == lx.Identifier {
@@ -315,6 +615,7 @@ func (p *Parser) parse_assignment(e core.Expression) core.Expression {
 			return &ast.Assignment{
 				Identifier: a.Value,
 				Value:      &ast.Operation{Type: t.Type},
+				Pos:        a.Pos,
 			}
 		}
 	}
@@ -338,18 +639,18 @@ func isIdentifier(e core.Expression) bool {
 // is an assignment that can be used in a binary expression
 func isBinaryAssgnmentToken(t lx.TokenType) bool {
 	return t == lx.Assign || t == lx.PlusEq || t == lx.MinusEq ||
-		t == lx.TimesEq || t == lx.DivEq || t == lx.ModEq
+		t == lx.TimesEq || t == lx.DivEq || t == lx.ModEq ||
+		t == lx.BitAndEq || t == lx.BitOrEq || t == lx.BitXorEq
 }
 
 // parse_block parses a block surrounded by braces
 func (p *Parser) parse_block() *ast.Block {
 	// consume left brace
-	p.expect(lx.LeftBrace)
+	pos := ast.PosOf(*p.expect(lx.LeftBrace))
 
 	// return an empty slice if there are no statements
 	if p.accept(lx.RightBrace) {
-		p.next()
-		return &ast.Block{}
+		return &ast.Block{Pos: pos, End: ast.PosOf(*p.next())}
 	}
 
 	// we assume blocks are usually <= 20 statements
@@ -360,10 +661,148 @@ func (p *Parser) parse_block() *ast.Block {
 	}
 
 	// consume right brace
-	p.next()
+	end := p.next()
 
 	return &ast.Block{
 		Statements: statements,
+		Pos:        pos,
+		End:        ast.PosOf(*end),
+	}
+}
+
+// parse_try parses a try statement and its catch clause. The
+// name of the caught error is optional e.g. try {} catch err {}
+func (p *Parser) parse_try() *ast.Try {
+	p.expect(lx.Try)
+	t := &ast.Try{Body: p.parse_block()}
+
+	p.expect(lx.Catch)
+	if p.accept(lx.Identifier) {
+		name := p.next()
+		t.Name, t.NamePos = name.Value, ast.PosOf(*name)
+	}
+	t.Catch = p.parse_block()
+	return t
+}
+
+// parse_throw parses a throw statement e.g. throw "not found"
+func (p *Parser) parse_throw() *ast.Throw {
+	t := p.expect(lx.Throw)
+	return &ast.Throw{Value: p.parse_expression(), Token: *t}
+}
+
+// parse_defer parses a defer statement e.g. defer close(f).
+// Only calls in function bodies can be deferred
+func (p *Parser) parse_defer() *ast.Defer {
+	p.expect(lx.Defer)
+	if p.functions == 0 {
+		report(`defer is not in a function`)
+	}
+
+	call, ok := p.parse_expression().(*ast.Call)
+	if !ok || call.Negated || call.Signed || call.Complemented {
+		report(`expression in defer must be a function call`)
+	}
+	return &ast.Defer{Call: call}
+}
+
+// parse_switch parses a switch statement e.g.
+//
+//	switch x {
+//	case 1, 2:
+//		...
+//	case 3..9:
+//		...
+//	default:
+//		...
+//	}
+func (p *Parser) parse_switch() *ast.Switch {
+	p.expect(lx.Switch)
+	p.switches++
+	defer func() { p.switches-- }()
+	s := &ast.Switch{Value: p.parse_expression(), Cases: []*ast.Case{}}
+	p.expect(lx.LeftBrace)
+
+	for !p.accept(lx.RightBrace) {
+		keyword := *p.expectsOneOf(lx.Case, lx.Default)
+		if keyword.Type == lx.Default {
+			if s.Default != nil {
+				report(`multiple defaults in switch`)
+			}
+			p.expect(lx.Colon)
+			s.Default = p.parse_case_body(ast.PosOf(keyword))
+			continue
+		}
+
+		p.split_label()
+		values := make([]core.Expression, 0, 2) // we assume most cases have ≤ 2 values
+		for {
+			values = append(values, p.parse_case_value())
+			if p.expectsOneOf(lx.Comma, lx.Colon).Type == lx.Colon {
+				break
+			}
+		}
+		s.Cases = append(s.Cases, &ast.Case{Values: values, Body: p.parse_case_body(ast.PosOf(keyword))})
+	}
+
+	// consume right brace
+	p.next()
+	return s
+}
+
+// parse_case_value parses a value or a range of values e.g. 'a'..'z'
+func (p *Parser) parse_case_value() core.Expression {
+	v := p.parse_expression()
+	if p.accept(lx.TwoDots) {
+		// consume two dots
+		p.next()
+		return &ast.Range{Start: v, End: p.parse_expression()}
+	}
+	return v
+}
+
+// parse_case_body parses the statements of a case whose keyword
+// is at [pos] up to the next case, the default case or the end
+// of the switch
+func (p *Parser) parse_case_body(pos ast.Pos) *ast.Block {
+	var statements []core.Statement
+	for !p.acceptsOneOf(lx.Case, lx.Default, lx.RightBrace) {
+		statements = append(statements, p.parse_statement())
+	}
+	return &ast.Block{Statements: statements, Pos: pos}
+}
+
+// split_label splits an identifier that ends the values of a case
+// or the first branch of a conditional and was lexed as a loop name
+// e.g. x in `case x:` or `c ? x: y` into the identifier and the colon
+func (p *Parser) split_label() {
+	depth := 0
+	for i := p.curr; i < len(p.tokens); i++ {
+		p.see(i)
+		switch t := p.tokens[i]; t.Type {
+		case lx.LeftParenthesis, lx.LeftBracket:
+			depth++
+		case lx.RightParenthesis, lx.RightBracket:
+			depth--
+		case lx.Colon:
+			if depth == 0 {
+				return
+			}
+		case lx.LoopName:
+			if depth > 0 {
+				continue
+			}
+			t.Type = lx.Identifier
+			colon := lx.Token{Type: lx.Colon, Value: string(lx.Colon), Line: t.Line, Column: t.Column + len(t.Value)}
+
+			tokens := make([]lx.Token, 0, len(p.tokens)+1)
+			tokens = append(tokens, p.tokens[:i]...)
+			tokens = append(tokens, t, colon)
+			p.tokens = append(tokens, p.tokens[i+1:]...)
+			return
+		case lx.NewLine, lx.LeftBrace, lx.EndOfInput:
+			return
+		}
 	}
 }
 
@@ -372,11 +811,39 @@ func (p *Parser) parse_func(lamdba bool) *ast.Function {
 
 	var name string
 	// consume func
-	p.expect(lx.Func)
+	pos := ast.PosOf(*p.expect(lx.Func))
+
+	// loops and switches outside a function can't be broken
+	// out of or continued from inside it
+	loops, switches := p.loops, p.switches
+	p.loops, p.switches = nil, 0
+	p.functions++
+	defer func() {
+		p.loops, p.switches = loops, switches
+		p.functions--
+	}()
 
 	// consume function name if not lambda
 	if !lamdba {
-		name = p.expect(lx.Identifier).Value
+		t := p.expect(lx.Identifier)
+		name, pos = t.Value, ast.PosOf(*t)
+	}
+
+	// get type parameters e.g. <T, U ordered>. They are
+	// visible in the function's signature and body
+	var typeParams []*types.TypeParam
+	if p.accept(lx.LessThan) {
+		typeParams = p.parse_type_params()
+
+		enclosing := p.typeParams
+		p.typeParams = make(map[string]*types.TypeParam, len(enclosing)+len(typeParams))
+		for n, t := range enclosing {
+			p.typeParams[n] = t
+		}
+		for _, t := range typeParams {
+			p.typeParams[t.Name] = t
+		}
+		defer func() { p.typeParams = enclosing }()
 	}
 
 	// get function parameters
@@ -385,32 +852,17 @@ func (p *Parser) parse_func(lamdba bool) *ast.Function {
 	// we assume most functions have returns ≤ 5
 	returns := make([]types.Type, 0, 5)
 
-	getType := func(identifier string) types.Type {
-		// check if type is a builtin else
-		// create custom type
-		if _type, ok := builtins[identifier]; ok {
-			return _type
-		}
-		return CustomType(identifier)
-	}
-
 	// get return types
 	//
 	// has one return type
-	if p.accept(lx.Identifier) {
-		_type := getType(p.next().Value)
-		returns = append(returns, _type)
+	if p.acceptsOneOf(lx.Identifier, lx.Func) {
+		returns = append(returns, p.parse_type())
 	} else if p.accept(lx.LeftParenthesis) {
-		rets := p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, func(p *Parser) core.Expression {
-			t := p.expect(lx.Identifier)
-			return &ast.Value{Value: t.Value}
+		p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, func(p *Parser) core.Expression {
+			// add parsed return type
+			returns = append(returns, p.parse_type())
+			return nil
 		})
-
-		// add parsed return types
-		for i := range rets {
-			name := rets[i].(*ast.Value).Value
-			returns = append(returns, getType(name))
-		}
 	}
 
 	// parse function body
@@ -420,7 +872,9 @@ func (p *Parser) parse_func(lamdba bool) *ast.Function {
 		Definition: ast.Definition{
 			Name: name,
 			Type: types.Func.String(), // TODO(DEV) remove String() caller
+			Pos:  pos,
 		},
+		TypeParams:  typeParams,
 		Parameters:  params,
 		Body:        body,
 		ReturnTypes: returns,
@@ -428,6 +882,128 @@ func (p *Parser) parse_func(lamdba bool) *ast.Function {
 	}
 }
 
+// parse_type_params parses the type parameters of a generic function
+// e.g. <T, U ordered>. A type parameter without a constraint can be
+// any type. A constraint is a builtin constraint or an interface
+func (p *Parser) parse_type_params() []*types.TypeParam {
+	// consume less than
+	p.expect(lx.LessThan)
+
+	params := make([]*types.TypeParam, 0, 2) // we assume most functions have ≤ 2 type parameters
+	for {
+		param := &types.TypeParam{Name: p.expect(lx.Identifier).Value, Constraint: types.Generic}
+		if p.accept(lx.Identifier) {
+			name := p.next().Value
+			if c, ok := types.Constraints[name]; ok {
+				param.Constraint = c
+			} else {
+				param.Constraint = CustomType(name)
+			}
+		}
+		params = append(params, param)
+
+		if p.expectsOneOf(lx.Comma, lx.GreaterThan).Type == lx.GreaterThan {
+			return params
+		}
+	}
+}
+
+// parse_interface parses an interface declaration which lists the
+// types that satisfy it e.g. interface Number { int | float }
+func (p *Parser) parse_interface() *ast.Interface {
+	p.expect(lx.Interface)
+	name := p.expect(lx.Identifier)
+	i := &ast.Interface{Name: name.Value, Types: []types.Type{}, Pos: ast.PosOf(*name)}
+
+	p.expect(lx.LeftBrace)
+	p.skipNewLines()
+	for !p.accept(lx.RightBrace) {
+		if len(i.Types) > 0 {
+			p.expect(lx.BitOr)
+			p.skipNewLines()
+		}
+		i.Types = append(i.Types, p.parse_type())
+		p.skipNewLines()
+	}
+
+	// consume right brace
+	p.next()
+	return i
+}
+
+// parse_type parses a type name. A type followed by
+// a question mark is nullable e.g. string?. Lists and
+// maps are written as list[T] and map[K]V and functions
+// as func or by their signature e.g. func(int) bool
+func (p *Parser) parse_type() types.Type {
+	token := p.expectsOneOf(lx.Identifier, lx.Func)
+	name := token.Value
+
+	var _type types.Type
+	if token.Type == lx.Func {
+		_type = p.parse_func_type()
+	} else if t, ok := p.typeParams[name]; ok {
+		_type = t
+	} else if name == `list` && p.accept(lx.LeftBracket) {
+		// consume left bracket
+		p.next()
+		_type = &types.List{Elem: p.parse_type()}
+		p.expect(lx.RightBracket)
+	} else if name == `map` && p.accept(lx.LeftBracket) {
+		// consume left bracket
+		p.next()
+		key := p.parse_type()
+		p.expect(lx.RightBracket)
+		_type = &types.Map{Key: key, Value: p.parse_type()}
+	} else if t, ok := builtins[name]; ok {
+		_type = t
+	} else {
+		// create custom type
+		_type = CustomType(name)
+	}
+
+	if p.accept(lx.Question) {
+		// consume question mark
+		p.next()
+		_type = &types.Nullable{Type: _type}
+	}
+	return _type
+}
+
+// parse_func_type parses the signature following func in a type.
+// The parameters are only listed by their types e.g. func(int, ...string)
+// and the return types follow them like they do in functions. It
+// returns the func type if there is no signature
+func (p *Parser) parse_func_type() types.Type {
+	if !p.nextIs(lx.LeftParenthesis) {
+		return types.Func
+	}
+
+	s := &types.Signature{Params: []types.Param{}, Returns: []types.Type{}}
+	p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, func(p *Parser) core.Expression {
+		if s.Variadic {
+			report(`only the final parameter of a function type can be variadic`)
+		}
+		if p.accept(lx.Ellipsis) {
+			// consume ellipsis
+			p.next()
+			s.Variadic = true
+		}
+		s.Params = append(s.Params, types.Param{Type: p.parse_type()})
+		return nil
+	})
+
+	if p.nextIs(lx.Identifier) || p.nextIs(lx.Func) {
+		s.Returns = append(s.Returns, p.parse_type())
+	} else if p.nextIs(lx.LeftParenthesis) {
+		p.delimited(lx.LeftParenthesis, lx.RightParenthesis, lx.Comma, false, func(p *Parser) core.Expression {
+			s.Returns = append(s.Returns, p.parse_type())
+			return nil
+		})
+	}
+	return s
+}
+
 // parse_func_params parses the parameters from a function
 func (p *Parser) parse_func_params() []*ast.Param {
 
@@ -447,39 +1023,66 @@ func (p *Parser) parse_func_params() []*ast.Param {
 	// buffer to store identifier names till their
 	// type has been identified
 	buff := make([]string, 0, 5)
+	positions := make([]ast.Pos, 0, 5)
 
 	for {
 		// get next parameter name
-		identifier := p.expect(lx.Identifier).Value
+		t := p.expect(lx.Identifier)
+		identifier := t.Value
 
 		// add parameter name to buffer
 		buff = append(buff, identifier)
+		positions = append(positions, ast.PosOf(*t))
+
+		// check for variadic parameter e.g. nums ...int
+		variadic := p.accept(lx.Ellipsis)
+		if variadic {
+			if len(buff) > 1 {
+				report(`cannot use ... with more than one parameter name`)
+			}
+
+			// consume ellipsis
+			p.next()
+		}
 
 		// if type is founf
-		if p.accept(lx.Identifier) {
-			var (
-				_type types.Type
-				ok    bool
-			)
-			// get type name
-			typeName := p.next().Value
-
-			// check if type is a builtin else
-			// create custom type
-			if _type, ok = builtins[typeName]; !ok {
-				_type = CustomType(typeName)
+		if p.acceptsOneOf(lx.Identifier, lx.Func) {
+			_type := p.parse_type()
+
+			// check for default value e.g. port int = 8080
+			var value core.Expression
+			if p.accept(lx.Assign) {
+				if len(buff) > 1 {
+					report(`cannot use a default value with more than one parameter name`)
+				}
+				if variadic {
+					report(`variadic parameter ` + identifier + ` cannot have a default value`)
+				}
+
+				// consume assign token
+				p.next()
+				value = p.parse_expression()
+			} else if !variadic && len(params) > 0 && params[len(params)-1].Default != nil {
+				// required parameters can't follow optional ones
+				report(`parameter ` + buff[0] + ` without a default value follows a parameter with one`)
 			}
 
 			// create a param from everything in the buffer
 			// and assign the type that was found to each of those
 			// params created
 			for i := range buff {
-				param := &ast.Param{Name: buff[i], Type: _type}
+				param := &ast.Param{Name: buff[i], Type: _type, Variadic: variadic, Default: value, Pos: positions[i]}
 				params = append(params, param)
 			}
 
 			// empty the buffer
 			buff = buff[:0]
+			positions = positions[:0]
+
+			// a variadic parameter must be the final parameter
+			if variadic && !p.accept(lx.RightParenthesis) {
+				report(`only the final parameter can be variadic`)
+			}
 
 			// check for comma separator or end parenthesis
 			next := p.expectsOneOf(lx.Comma, lx.RightParenthesis)
@@ -513,6 +1116,19 @@ func (p *Parser) parse_func_params() []*ast.Param {
 func (p *Parser) parse_if() *ast.If {
 	p.expect(lx.If)
 
+	// a statement can run before the condition e.g. if x := f(); x > 0 {}
+	var init core.Statement
+	if m := p.attempt_parse_multi_assignment(); m != nil {
+		init = m
+	} else if d := p.attempt_parse_definition(); d != nil {
+		init = d
+	} else if p.semicolon_before_block() {
+		init = p.parse_expression()
+	}
+	if init != nil {
+		p.expect(lx.SemiColon)
+	}
+
 	hasLeftParen := false
 	// attempt to consume expression in a parenthesis
 	if p.accept(lx.LeftParenthesis) {
@@ -553,10 +1169,35 @@ func (p *Parser) parse_if() *ast.If {
 	}
 
 	return &ast.If{
-		Condition: cond,
-		Body:      body,
-		Else:      else_,
+		Condition:  cond,
+		Body:       body,
+		Else:       else_,
+		Definition: init,
+	}
+}
+
+// semicolon_before_block returns true if a semicolon comes before
+// the next block on the current line e.g. if x++; x > 0 {}
+func (p *Parser) semicolon_before_block() bool {
+	depth := 0
+	for i := p.curr; i < len(p.tokens); i++ {
+		p.see(i)
+		switch p.tokens[i].Type {
+		case lx.LeftParenthesis, lx.LeftBracket:
+			depth++
+		case lx.RightParenthesis, lx.RightBracket:
+			depth--
+		case lx.SemiColon:
+			return depth == 0
+		case lx.LeftBrace:
+			if depth == 0 {
+				return false
+			}
+		case lx.NewLine, lx.EndOfInput:
+			return false
+		}
 	}
+	return false
 }
 
 // parse_loop parses a for statement
@@ -570,8 +1211,16 @@ func (p *Parser) parse_loop() ast.Loop {
 		p.expect(lx.NewLine)
 	}
 
+	// track the loop so break and continue statements
+	// in its body can be validated
+	p.loops = append(p.loops, name)
+	defer func() { p.loops = p.loops[:len(p.loops)-1] }()
+
+	// the for token locates the loop
+	t := *p.peek()
 	if rl := p.attempt_parse_range_loop(); rl != nil {
 		rl.Name = name
+		rl.Token = t
 		return rl
 	}
 	// 'for' token is already consumed
@@ -580,13 +1229,15 @@ func (p *Parser) parse_loop() ast.Loop {
 
 	// if loop has no statements then parse the body
 	if p.nextIs(lx.LeftBrace) {
-		return &ast.ForLoop{Name: name, Body: p.parse_block()}
+		return &ast.ForLoop{Name: name, Body: p.parse_block(), Token: t}
 	}
 
 	// if a stement exists before the first semicolon
 	// then it is the preLoop statement
 	if !p.nextIs(lx.SemiColon) {
-		if d := p.attempt_parse_definition(); d != nil {
+		if m := p.attempt_parse_multi_assignment(); m != nil {
+			preLoop = m
+		} else if d := p.attempt_parse_definition(); d != nil {
 			preLoop = d
 		} else {
 			preLoop = p.parse_expression()
@@ -600,6 +1251,7 @@ func (p *Parser) parse_loop() ast.Loop {
 			Name:      name,
 			Condition: preLoop,
 			Body:      p.parse_block(),
+			Token:     t,
 		}
 	}
 
@@ -628,6 +1280,7 @@ func (p *Parser) parse_loop() ast.Loop {
 		Condition:     condition,
 		PostIteration: postIter,
 		Body:          p.parse_block(),
+		Token:         t,
 	}
 }
 
@@ -641,6 +1294,7 @@ func (p *Parser) attempt_parse_range_loop() *ast.RangeLoop {
 
 	// the value of the second identifier for the range loop
 	second := ``
+	var secondToken *lx.Token
 
 	// restore parser cursor if range loop
 	// was not successfully parsed
@@ -651,10 +1305,11 @@ func (p *Parser) attempt_parse_range_loop() *ast.RangeLoop {
 	}()
 
 	// consume first identifier
-	if !p.accept(lx.Identifier) { // TODO(DEV) or underscore
+	if !p.acceptsOneOf(lx.Identifier, lx.Underscore) {
 		return nil
 	}
-	first := p.next().Value
+	firstToken := p.next()
+	first := firstToken.Value
 
 	// potentially consume in
 	if p.accept(lx.In) {
@@ -668,10 +1323,11 @@ func (p *Parser) attempt_parse_range_loop() *ast.RangeLoop {
 	p.next()
 
 	// consume second identifier
-	if !p.nextIs(lx.Identifier) { // TODO(DEV) or underscore
+	if !p.nextIs(lx.Identifier) && !p.nextIs(lx.Underscore) {
 		return nil
 	}
-	second = p.next().Value
+	secondToken = p.next()
+	second = secondToken.Value
 
 	if !p.nextIs(lx.In) {
 		return nil
@@ -681,14 +1337,19 @@ parse_iterable:
 	// consume in token
 	p.next()
 
-	iterable := p.expect(lx.Identifier).Value
+	iterable := p.parse_expression()
 	success = true
-	return &ast.RangeLoop{
+	loop := &ast.RangeLoop{
 		First:    first,
 		Second:   second,
+		FirstPos: ast.PosOf(*firstToken),
 		Iterable: iterable,
 		Body:     p.parse_block(),
 	}
+	if secondToken != nil {
+		loop.SecondPos = ast.PosOf(*secondToken)
+	}
+	return loop
 }
 
 // parse_return parses a return statement
@@ -702,26 +1363,15 @@ func (p *Parser) parse_return() *ast.Return {
 	}
 
 	values = make([]core.Expression, 0, 5) // we assume most return statements will have ≤ 5 values
+
+	// a return without values ends its line or block
+	if p.nextIs(lx.NewLine) || p.nextIs(lx.RightBrace) || p.nextIs(lx.EndOfInput) {
+		return &ast.Return{Values: values}
+	}
+
 	for {
 		// get next return value
-		isLiteral := func() bool {
-			t := p.peek().Type
-			for _, literal := range literals {
-				if t == literal {
-					return true
-				}
-			}
-			return false
-		}
-		// TODO(DEV) use nextIs(...)
-		if p.nextIs(lx.Identifier) || p.nextIs(lx.Func) || isLiteral() {
-			values = append(values, p.parse_expression())
-			// TODO(DEV) use a universal check for end of input
-		} else if !p.nextIs(lx.EndOfInput) {
-			// TODO(REPORT) use expectsOneOf or something better
-			report(`Expected an expression`)
-			return nil
-		}
+		values = append(values, p.parse_expression())
 
 		// break if no comma is found
 		if !p.nextIs(lx.Comma) {
@@ -736,6 +1386,44 @@ func (p *Parser) parse_return() *ast.Return {
 	}
 }
 
+// parse_break_or_continue parses a break or continue statement
+// with an optional loop label e.g. break outer. An unlabeled
+// break ends the innermost loop or switch enclosing it
+func (p *Parser) parse_break_or_continue() core.Statement {
+	t := p.expectsOneOf(lx.Break, lx.Continue)
+
+	// the label must be on the same line
+	var label string
+	if p.nextIs(lx.Identifier) {
+		label = p.next().Value
+	}
+
+	// an unlabeled break can also end a switch
+	if len(p.loops) == 0 && (t.Type == lx.Continue || label != `` || p.switches == 0) {
+		report(t.Value + ` is not in a loop`)
+	}
+
+	if label != `` && !p.inLoop(label) {
+		report(t.Value + ` label not defined: ` + label)
+	}
+
+	if t.Type == lx.Break {
+		return &ast.Break{Label: label}
+	}
+	return &ast.Continue{Label: label}
+}
+
+// inLoop returns true if a loop named [name]
+// encloses the statement being parsed
+func (p *Parser) inLoop(name string) bool {
+	for _, loop := range p.loops {
+		if loop == name {
+			return true
+		}
+	}
+	return false
+}
+
 // isBooleanAble returns true if the token could
 // possibly be a boolean value
 func isBooleanAble(t lx.TokenType) bool {
@@ -748,6 +1436,12 @@ func isSignSpecifiable(t lx.TokenType) bool {
 	return t == lx.Int || t == lx.Float || t == lx.Identifier
 }
 
+// isComplementable returns true if the token could
+// be complemented with ~
+func isComplementable(t lx.TokenType) bool {
+	return t == lx.Int || t == lx.Identifier
+}
+
 // isBooleanBinaryExpr returns true if the binary
 // expression's operator is a comparator
 // i.e. ==, <, >, <=, !=
@@ -763,6 +1457,13 @@ func isArithmeticBinaryExpr(op lx.TokenType) bool {
 	return op == lx.Plus || op == lx.Minus || op == lx.Times || op == lx.Div || op == lx.Mod
 }
 
+// isBitwiseBinaryExpr returns true if the binary
+// expression's operator is bitwise
+// i.e. &, |, ^, <<, >>
+func isBitwiseBinaryExpr(op lx.TokenType) bool {
+	return op == lx.BitAnd || op == lx.BitOr || op == lx.BitXor || op == lx.BitLeftShift || op == lx.BitRightShift
+}
+
 // isBooleanExpr returns true if the expression is a
 // valid boolean expression
 // e.g. identifier, boolean binary expression & calls
@@ -773,7 +1474,7 @@ func isBooleanExpr(e core.Expression) bool {
 		return exp.Type == lx.Bool || exp.Type == lx.Identifier
 	case *ast.Binary:
 		return isBooleanBinaryExpr(exp.Operator.Type)
-	case *ast.Call:
+	case *ast.Call, *ast.Conversion, *ast.Selector, *ast.Index, *ast.Conditional:
 		return true
 	}
 
@@ -805,6 +1506,12 @@ func negateExpr(e core.Expression) {
 		report(`cannot negate non-boolean expression`)
 	case *ast.Call:
 		exp.Negated = true
+	case *ast.Conversion:
+		exp.Negated = true
+	case *ast.Selector:
+		exp.Negated = true
+	case *ast.Index:
+		exp.Negated = true
 	default:
 		// TODO(REPORT) better message
 		report(`cannot negate non-boolean expression`)
@@ -819,8 +1526,14 @@ func signExpr(e core.Expression) {
 		exp.Signed = true
 	case *ast.Call:
 		exp.Signed = true
+	case *ast.Conversion:
+		exp.Signed = true
+	case *ast.Selector:
+		exp.Signed = true
+	case *ast.Index:
+		exp.Signed = true
 	case *ast.Binary:
-		if isArithmeticBinaryExpr(exp.Operator.Type) {
+		if isArithmeticBinaryExpr(exp.Operator.Type) || isBitwiseBinaryExpr(exp.Operator.Type) {
 			exp.Signed = true
 			return
 		}
@@ -829,3 +1542,27 @@ func signExpr(e core.Expression) {
 		report(`cannot specify sign of non-number type`)
 	}
 }
+
+// complementExpr marks an integer expression to be complemented with ~
+func complementExpr(e core.Expression) {
+	switch exp := e.(type) {
+	case *ast.Atom:
+		exp.Complemented = true
+	case *ast.Call:
+		exp.Complemented = true
+	case *ast.Conversion:
+		exp.Complemented = true
+	case *ast.Selector:
+		exp.Complemented = true
+	case *ast.Index:
+		exp.Complemented = true
+	case *ast.Binary:
+		if isArithmeticBinaryExpr(exp.Operator.Type) || isBitwiseBinaryExpr(exp.Operator.Type) {
+			exp.Complemented = true
+			return
+		}
+		report(`cannot complement non-integer expression`)
+	default:
+		report(`cannot complement non-integer type`)
+	}
+}
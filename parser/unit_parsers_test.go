@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"reflect"
 	"testing"
 
 	"github.com/amupitan/hero/ast"
@@ -48,6 +47,100 @@ func TestParser_parse_func(t *testing.T) {
 				Body:        &ast.Block{},
 			},
 		},
+		{
+			name:  `variadic final parameter`,
+			input: `func sum(base int, nums ...int) {}`,
+			want: &ast.Function{
+				Definition:  ast.Definition{Name: `sum`, Type: string(lx.Func)},
+				Parameters:  []*ast.Param{&ast.Param{Name: `base`, Type: types.Int}, &ast.Param{Name: `nums`, Type: types.Int, Variadic: true}},
+				ReturnTypes: []types.Type{},
+				Body:        &ast.Block{},
+			},
+		},
+		{
+			name:  `nullable parameter and return type`,
+			input: `func find(key string?) (int?, bool) {}`,
+			want: &ast.Function{
+				Definition:  ast.Definition{Name: `find`, Type: string(lx.Func)},
+				Parameters:  []*ast.Param{&ast.Param{Name: `key`, Type: &types.Nullable{Type: types.String}}},
+				ReturnTypes: []types.Type{&types.Nullable{Type: types.Int}, types.Bool},
+				Body:        &ast.Block{},
+			},
+		},
+		{
+			name:  `default parameter value`,
+			input: `func connect(host string, port int = 8080) {}`,
+			want: &ast.Function{
+				Definition: ast.Definition{Name: `connect`, Type: string(lx.Func)},
+				Parameters: []*ast.Param{
+					&ast.Param{Name: `host`, Type: types.String},
+					&ast.Param{Name: `port`, Type: types.Int, Default: &ast.Atom{Type: lx.Int, Value: `8080`}},
+				},
+				ReturnTypes: []types.Type{},
+				Body:        &ast.Block{},
+			},
+		},
+		{
+			name:  `generic function`,
+			input: `func first<T>(xs list[T]) T {}`,
+			want: &ast.Function{
+				Definition:  ast.Definition{Name: `first`, Type: string(lx.Func)},
+				TypeParams:  []*types.TypeParam{&types.TypeParam{Name: `T`, Constraint: types.Generic}},
+				Parameters:  []*ast.Param{&ast.Param{Name: `xs`, Type: &types.List{Elem: &types.TypeParam{Name: `T`, Constraint: types.Generic}}}},
+				ReturnTypes: []types.Type{&types.TypeParam{Name: `T`, Constraint: types.Generic}},
+				Body:        &ast.Block{},
+			},
+		},
+		{
+			name:  `constrained type parameters`,
+			input: `func lookup<K ordered, V Number>(m map[K]V, k K) V? {}`,
+			want: &ast.Function{
+				Definition: ast.Definition{Name: `lookup`, Type: string(lx.Func)},
+				TypeParams: []*types.TypeParam{
+					&types.TypeParam{Name: `K`, Constraint: types.Ordered},
+					&types.TypeParam{Name: `V`, Constraint: CustomType(`Number`)},
+				},
+				Parameters: []*ast.Param{
+					&ast.Param{Name: `m`, Type: &types.Map{
+						Key:   &types.TypeParam{Name: `K`, Constraint: types.Ordered},
+						Value: &types.TypeParam{Name: `V`, Constraint: CustomType(`Number`)},
+					}},
+					&ast.Param{Name: `k`, Type: &types.TypeParam{Name: `K`, Constraint: types.Ordered}},
+				},
+				ReturnTypes: []types.Type{&types.Nullable{Type: &types.TypeParam{Name: `V`, Constraint: CustomType(`Number`)}}},
+				Body:        &ast.Block{},
+			},
+		},
+		{
+			name:        `unclosed type parameters`,
+			input:       `func first<T(xs list[T]) T {}`,
+			shouldPanic: true,
+		},
+		{
+			name:        `required parameter after default`,
+			input:       `func connect(port int = 8080, host string) {}`,
+			shouldPanic: true,
+		},
+		{
+			name:        `default value for grouped parameters`,
+			input:       `func f(x, y int = 1) {}`,
+			shouldPanic: true,
+		},
+		{
+			name:        `default value for variadic parameter`,
+			input:       `func f(xs ...int = 1) {}`,
+			shouldPanic: true,
+		},
+		{
+			name:        `variadic parameter that isn't final`,
+			input:       `func sum(nums ...int, base int) {}`,
+			shouldPanic: true,
+		},
+		{
+			name:        `variadic parameter with multiple names`,
+			input:       `func sum(a, b ...int) {}`,
+			shouldPanic: true,
+		},
 		{
 			name:  `lambda - 2 args, joined type, no return`,
 			input: `func(x, y int) {}`,
@@ -153,6 +246,44 @@ func TestParser_parse_func(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  `function types`,
+			input: `func apply(f func(int, ...string) bool, g func) func {}`,
+			want: &ast.Function{
+				Definition: ast.Definition{Name: `apply`, Type: string(lx.Func)},
+				Parameters: []*ast.Param{
+					&ast.Param{Name: `f`, Type: &types.Signature{
+						Params:   []types.Param{{Type: types.Int}, {Type: types.String}},
+						Variadic: true,
+						Returns:  []types.Type{types.Bool},
+					}},
+					&ast.Param{Name: `g`, Type: types.Func},
+				},
+				ReturnTypes: []types.Type{types.Func},
+				Body:        &ast.Block{},
+			},
+		},
+		{
+			name:  `function type returning functions`,
+			input: `func mk() func() (func(int) int, error?) {}`,
+			want: &ast.Function{
+				Definition: ast.Definition{Name: `mk`, Type: string(lx.Func)},
+				Parameters: []*ast.Param{},
+				ReturnTypes: []types.Type{&types.Signature{
+					Params: []types.Param{},
+					Returns: []types.Type{
+						&types.Signature{Params: []types.Param{{Type: types.Int}}, Returns: []types.Type{types.Int}},
+						&types.Nullable{Type: types.Error},
+					},
+				}},
+				Body: &ast.Block{},
+			},
+		},
+		{
+			name:        `variadic parameter before the last in a function type`,
+			input:       `func bad(f func(...int, string)) {}`,
+			shouldPanic: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -160,9 +291,11 @@ func TestParser_parse_func(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_func(tt.lambda); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_func(tt.lambda)
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_func() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -206,7 +339,8 @@ func TestParser_attempt_parse_lambda_call(t *testing.T) {
 					Body:        &ast.Block{},
 					Lambda:      true,
 				},
-				Args: []core.Expression{},
+				Args:  []core.Expression{},
+				Token: lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 10},
 			},
 		},
 		{
@@ -220,49 +354,55 @@ func TestParser_attempt_parse_lambda_call(t *testing.T) {
 					Body:        &ast.Block{},
 					Lambda:      true,
 				},
-				Args: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}, &ast.Atom{Type: lx.Identifier, Value: `z`}},
+				Args:  []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}, &ast.Atom{Type: lx.Identifier, Value: `z`}},
+				Token: lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 18},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := New(tt.input)
-			if got := p.attempt_parse_lambda_call(); !reflect.DeepEqual(got, tt.want) {
+			got := p.attempt_parse_lambda_call()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.attempt_parse_lambda_call() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
 
-func TestParser_attempt_parse_named_call(t *testing.T) {
+func TestParser_parse_postfix(t *testing.T) {
 	tests := []struct {
 		name        string
 		input       string
-		want        *ast.Call
+		want        core.Expression
 		shouldPanic bool
 	}{
 		{
 			name:  `call with no args`,
 			input: `print()`,
 			want: &ast.Call{
-				Name: `print`,
-				Args: []core.Expression{},
+				Name:  `print`,
+				Args:  []core.Expression{},
+				Token: lx.Token{Type: lx.Identifier, Value: `print`, Line: 1, Column: 1},
 			},
 		},
 		{
 			name:  `call with one arg`,
 			input: `print(1)`,
 			want: &ast.Call{
-				Name: `print`,
-				Args: []core.Expression{&ast.Atom{Type: `int`, Value: `1`}},
+				Name:  `print`,
+				Args:  []core.Expression{&ast.Atom{Type: `int`, Value: `1`}},
+				Token: lx.Token{Type: lx.Identifier, Value: `print`, Line: 1, Column: 1},
 			},
 		},
 		{
 			name:  `call with two args`,
 			input: `print(1, "hello")`,
 			want: &ast.Call{
-				Name: `print`,
-				Args: []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: `string`, Value: `hello`}},
+				Name:  `print`,
+				Args:  []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: `string`, Value: `hello`}},
+				Token: lx.Token{Type: lx.Identifier, Value: `print`, Line: 1, Column: 1},
 			},
 		},
 		{
@@ -272,15 +412,155 @@ func TestParser_attempt_parse_named_call(t *testing.T) {
 			shouldPanic: true,
 		},
 		{
-			name:        `call with extra separator at the end`,
-			input:       `print(1, 2,)`,
-			want:        nil,
+			name:  `call with spread argument`,
+			input: `sum(1, xs...)`,
+			want: &ast.Call{
+				Name:   `sum`,
+				Args:   []core.Expression{&ast.Atom{Type: `int`, Value: `1`}, &ast.Atom{Type: lx.Identifier, Value: `xs`}},
+				Spread: true,
+				Token:  lx.Token{Type: lx.Identifier, Value: `sum`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name:  `call with named arguments`,
+			input: `connect("x", port: 9000, retries : 2)`,
+			want: &ast.Call{
+				Name: `connect`,
+				Args: []core.Expression{&ast.Atom{Type: lx.String, Value: `x`}},
+				Named: []*ast.NamedArg{
+					&ast.NamedArg{Name: `port`, Value: &ast.Atom{Type: lx.Int, Value: `9000`}},
+					&ast.NamedArg{Name: `retries`, Value: &ast.Atom{Type: lx.Int, Value: `2`}},
+				},
+				Token: lx.Token{Type: lx.Identifier, Value: `connect`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name:        `positional argument after named argument`,
+			input:       `connect(port: 9000, "x")`,
+			shouldPanic: true,
+		},
+		{
+			name:        `call with spread argument that isn't final`,
+			input:       `sum(xs..., 1)`,
 			shouldPanic: true,
 		},
 		{
 			name:  `invalid call - no parenthesis`,
 			input: `print{1, "hello"}`,
-			want:  nil,
+			want:  &ast.Atom{Type: lx.Identifier, Value: `print`},
+		},
+		{
+			name:  `field access chain`,
+			input: `a.b.c`,
+			want: &ast.Selector{
+				Object: &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `a`}, Name: `b`},
+				Name:   `c`,
+			},
+		},
+		{
+			name:  `method call on call result`,
+			input: `f(x).g(y)`,
+			want: &ast.Call{
+				Callee: &ast.Selector{
+					Object: &ast.Call{Name: `f`, Args: []core.Expression{&ast.Atom{Type: lx.Identifier, Value: `x`}}, Token: lx.Token{Type: lx.Identifier, Value: `f`, Line: 1, Column: 1}},
+					Name:   `g`,
+				},
+				Args:  []core.Expression{&ast.Atom{Type: lx.Identifier, Value: `y`}},
+				Token: lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 7},
+			},
+		},
+		{
+			name:  `field access on index`,
+			input: `list[0].name`,
+			want: &ast.Selector{
+				Object: &ast.Index{
+					Object: &ast.Atom{Type: lx.Identifier, Value: `list`},
+					Index:  &ast.Atom{Type: lx.Int, Value: `0`},
+				},
+				Name: `name`,
+			},
+		},
+		{
+			name:  `call on call result`,
+			input: `makeAdder(1)(2)`,
+			want: &ast.Call{
+				Callee: &ast.Call{Name: `makeAdder`, Args: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}}, Token: lx.Token{Type: lx.Identifier, Value: `makeAdder`, Line: 1, Column: 1}},
+				Args:   []core.Expression{&ast.Atom{Type: lx.Int, Value: `2`}},
+				Token:  lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 13},
+			},
+		},
+		{
+			name:  `index with expression`,
+			input: `m["a" + k][i]`,
+			want: &ast.Index{
+				Object: &ast.Index{
+					Object: &ast.Atom{Type: lx.Identifier, Value: `m`},
+					Index: &ast.Binary{
+						Left:     &ast.Atom{Type: lx.String, Value: `a`},
+						Operator: lx.Token{Type: lx.Plus, Value: `+`, Line: 1, Column: 7},
+						Right:    &ast.Atom{Type: lx.Identifier, Value: `k`},
+					},
+				},
+				Index: &ast.Atom{Type: lx.Identifier, Value: `i`},
+			},
+		},
+		{
+			name:  `call on list literal element`,
+			input: `[f][0]()`,
+			want: &ast.Call{
+				Callee: &ast.Index{
+					Object: &ast.List{Elements: []core.Expression{&ast.Atom{Type: lx.Identifier, Value: `f`}}},
+					Index:  &ast.Atom{Type: lx.Int, Value: `0`},
+				},
+				Args:  []core.Expression{},
+				Token: lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 7},
+			},
+		},
+		{
+			name:  `postfix must be on the same line`,
+			input: "a\n.b",
+			want:  &ast.Atom{Type: lx.Identifier, Value: `a`},
+		},
+		{
+			name:  `conversion to builtin type`,
+			input: `int("42")`,
+			want: &ast.Conversion{
+				Type:  types.Int,
+				Value: &ast.Atom{Type: lx.String, Value: `42`},
+				Token: lx.Token{Type: lx.Identifier, Value: `int`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name:  `conversion of call result`,
+			input: `string(f(x)).len`,
+			want: &ast.Selector{
+				Object: &ast.Conversion{
+					Type:  types.String,
+					Value: &ast.Call{Name: `f`, Args: []core.Expression{&ast.Atom{Type: lx.Identifier, Value: `x`}}, Token: lx.Token{Type: lx.Identifier, Value: `f`, Line: 1, Column: 8}},
+					Token: lx.Token{Type: lx.Identifier, Value: `string`, Line: 1, Column: 1},
+				},
+				Name: `len`,
+			},
+		},
+		{
+			name:        `conversion with two arguments`,
+			input:       `float(1, 2)`,
+			shouldPanic: true,
+		},
+		{
+			name:        `conversion with named argument`,
+			input:       `float(x: 1)`,
+			shouldPanic: true,
+		},
+		{
+			name:        `selector without name`,
+			input:       `a.(b)`,
+			shouldPanic: true,
+		},
+		{
+			name:        `unclosed index`,
+			input:       `a[1`,
+			shouldPanic: true,
 		},
 	}
 	for _, tt := range tests {
@@ -289,9 +569,11 @@ func TestParser_attempt_parse_named_call(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.attempt_parse_named_call(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Parser.attempt_parse_named_call() = %v, want %v", got, tt.want)
+			got := p.parse_postfix(p.parse_operand())
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.parse_postfix() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -352,9 +634,11 @@ func TestParser_parse_block(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := New(tt.input)
-			if got := p.parse_block(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_block()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_block() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -374,11 +658,34 @@ func TestParser_parse_if(t *testing.T) {
 				Body:      &ast.Block{},
 			},
 		},
+		{
+			name:  `definition before condition`,
+			input: `if x := compute(); x > 0 {} else if y = x; y {}`,
+			want: &ast.If{
+				Definition: &ast.Definition{Name: `x`, Value: &ast.Call{Name: `compute`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `compute`, Line: 1, Column: 9}}},
+				Condition: &ast.Binary{
+					Left:     &ast.Atom{Type: lx.Identifier, Value: `x`},
+					Operator: lx.Token{Type: lx.GreaterThan, Value: `>`, Line: 1, Column: 22},
+					Right:    &ast.Atom{Type: lx.Int, Value: `0`},
+				},
+				Body: &ast.Block{},
+				Else: &ast.If{
+					Definition: &ast.Assignment{Identifier: `y`, Value: &ast.Atom{Type: lx.Identifier, Value: `x`}},
+					Condition:  &ast.Atom{Type: lx.Identifier, Value: `y`},
+					Body:       &ast.Block{},
+				},
+			},
+		},
+		{
+			name:        `definition without condition`,
+			input:       `if x := compute() {}`,
+			shouldPanic: true,
+		},
 		{
 			name:  `call evaluation, no parenthesis`,
 			input: `if !isFree() {}`,
 			want: &ast.If{
-				Condition: &ast.Call{Name: `isFree`, Args: []core.Expression{}, Negated: true},
+				Condition: &ast.Call{Name: `isFree`, Args: []core.Expression{}, Negated: true, Token: lx.Token{Type: lx.Identifier, Value: `isFree`, Line: 1, Column: 5}},
 				Body:      &ast.Block{},
 			},
 		},
@@ -436,7 +743,7 @@ func TestParser_parse_if(t *testing.T) {
 				Condition: &ast.Binary{
 					Left:     &ast.Atom{Type: lx.Identifier, Value: `x`},
 					Operator: lx.Token{Value: `!=`, Type: lx.NotEqual, Line: 1, Column: 7},
-					Right:    &ast.Call{Name: `getValue`, Args: []core.Expression{}},
+					Right:    &ast.Call{Name: `getValue`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `getValue`, Line: 1, Column: 10}},
 				},
 				Body: &ast.Block{},
 			},
@@ -448,7 +755,7 @@ func TestParser_parse_if(t *testing.T) {
 				Condition: &ast.Binary{
 					Left:     &ast.Atom{Type: lx.Identifier, Value: `x`},
 					Operator: lx.Token{Value: `!=`, Type: lx.NotEqual, Line: 1, Column: 6},
-					Right:    &ast.Call{Name: `getValue`, Args: []core.Expression{}},
+					Right:    &ast.Call{Name: `getValue`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `getValue`, Line: 1, Column: 9}},
 				},
 				Body: &ast.Block{},
 			},
@@ -539,7 +846,11 @@ func TestParser_parse_if(t *testing.T) {
 					},
 					Else: &ast.If{
 						Body: &ast.Block{
-							Statements: []core.Statement{&ast.Call{Name: `start`, Object: `runner`, Args: []core.Expression{}}},
+							Statements: []core.Statement{&ast.Call{
+								Callee: &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `runner`}, Name: `start`},
+								Args:   []core.Expression{},
+								Token:  lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 50},
+							}},
 						},
 					},
 				},
@@ -552,9 +863,220 @@ func TestParser_parse_if(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_if(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_if()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_if() = %s, want %s", got, tt.want)
 			}
+			checkWalks(t, got)
+		})
+	}
+}
+
+func TestParser_parse_switch(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        *ast.Switch
+		shouldPanic bool
+	}{
+		{
+			name: `values, ranges and default`,
+			input: `switch x {
+			case 1, 2:
+				y = 1
+			case 3..9, z:
+			case lo..hi:
+				y = 2
+			default:
+				y = 3
+			}`,
+			want: &ast.Switch{
+				Value: &ast.Atom{Type: lx.Identifier, Value: `x`},
+				Cases: []*ast.Case{
+					&ast.Case{
+						Values: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}, &ast.Atom{Type: lx.Int, Value: `2`}},
+						Body:   &ast.Block{Statements: []core.Statement{&ast.Assignment{Identifier: `y`, Value: &ast.Atom{Type: lx.Int, Value: `1`}}}},
+					},
+					&ast.Case{
+						Values: []core.Expression{
+							&ast.Range{Start: &ast.Atom{Type: lx.Int, Value: `3`}, End: &ast.Atom{Type: lx.Int, Value: `9`}},
+							&ast.Atom{Type: lx.Identifier, Value: `z`},
+						},
+						Body: &ast.Block{},
+					},
+					&ast.Case{
+						Values: []core.Expression{&ast.Range{Start: &ast.Atom{Type: lx.Identifier, Value: `lo`}, End: &ast.Atom{Type: lx.Identifier, Value: `hi`}}},
+						Body:   &ast.Block{Statements: []core.Statement{&ast.Assignment{Identifier: `y`, Value: &ast.Atom{Type: lx.Int, Value: `2`}}}},
+					},
+				},
+				Default: &ast.Block{Statements: []core.Statement{&ast.Assignment{Identifier: `y`, Value: &ast.Atom{Type: lx.Int, Value: `3`}}}},
+			},
+		},
+		{
+			name:  `empty switch`,
+			input: `switch f() {}`,
+			want: &ast.Switch{
+				Value: &ast.Call{Name: `f`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `f`, Line: 1, Column: 8}},
+				Cases: []*ast.Case{},
+			},
+		},
+		{
+			name:        `multiple defaults`,
+			input:       `switch x { default: default: }`,
+			shouldPanic: true,
+		},
+		{
+			name:        `case without colon`,
+			input:       `switch x { case 1 }`,
+			shouldPanic: true,
+		},
+		{
+			name:        `statement before first case`,
+			input:       `switch x { y = 1 }`,
+			shouldPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.input)
+			if tt.shouldPanic {
+				defer expectPanic(t, nil)
+			}
+			got := p.parse_switch()
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.parse_switch() = %v, want %v", got, tt.want)
+			}
+			checkWalks(t, got)
+		})
+	}
+}
+
+func TestParser_parse_try(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        *ast.Try
+		shouldPanic bool
+	}{
+		{
+			name:  `named error`,
+			input: `try { x = 1 } catch err { y = 2 }`,
+			want: &ast.Try{
+				Body:  &ast.Block{Statements: []core.Statement{&ast.Assignment{Identifier: `x`, Value: &ast.Atom{Type: lx.Int, Value: `1`}}}},
+				Name:  `err`,
+				Catch: &ast.Block{Statements: []core.Statement{&ast.Assignment{Identifier: `y`, Value: &ast.Atom{Type: lx.Int, Value: `2`}}}},
+			},
+		},
+		{
+			name:  `unnamed error`,
+			input: `try {} catch {}`,
+			want:  &ast.Try{Body: &ast.Block{}, Catch: &ast.Block{}},
+		},
+		{
+			name:        `missing catch`,
+			input:       `try { x = 1 }`,
+			shouldPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.input)
+			if tt.shouldPanic {
+				defer expectPanic(t, nil)
+			}
+			got := p.parse_try()
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.parse_try() = %v, want %v", got, tt.want)
+			}
+			checkWalks(t, got)
+		})
+	}
+}
+
+func TestParser_parse_throw(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        *ast.Throw
+		shouldPanic bool
+	}{
+		{
+			name:  `string`,
+			input: `throw "not found"`,
+			want: &ast.Throw{
+				Value: &ast.Atom{Type: lx.String, Value: `not found`},
+				Token: lx.Token{Type: lx.Throw, Value: `throw`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name:  `identifier`,
+			input: `throw err`,
+			want: &ast.Throw{
+				Value: &ast.Atom{Type: lx.Identifier, Value: `err`},
+				Token: lx.Token{Type: lx.Throw, Value: `throw`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name:        `missing value`,
+			input:       `throw`,
+			shouldPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.input)
+			if tt.shouldPanic {
+				defer expectPanic(t, nil)
+			}
+			got := p.parse_throw()
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.parse_throw() = %v, want %v", got, tt.want)
+			}
+			checkWalks(t, got)
+		})
+	}
+}
+
+func TestParser_parse_defer(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        *ast.Defer
+		shouldPanic bool
+	}{
+		{
+			name:  `call`,
+			input: `defer close(f)`,
+			want: &ast.Defer{Call: &ast.Call{
+				Name:  `close`,
+				Args:  []core.Expression{&ast.Atom{Type: lx.Identifier, Value: `f`}},
+				Token: lx.Token{Type: lx.Identifier, Value: `close`, Line: 1, Column: 7},
+			}},
+		},
+		{
+			name:        `not a call`,
+			input:       `defer f`,
+			shouldPanic: true,
+		},
+		{
+			name:        `negated call`,
+			input:       `defer !f()`,
+			shouldPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.input)
+			// defer is only valid in a function
+			p.functions = 1
+			if tt.shouldPanic {
+				defer expectPanic(t, nil)
+			}
+			got := p.parse_defer()
+			if !sameTree(got, tt.want) {
+				t.Errorf("Parser.parse_defer() = %v, want %v", got, tt.want)
+			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -575,7 +1097,7 @@ func TestParser_parse_return(t *testing.T) {
 			name:  `return one thing - a call`,
 			input: `return getID()`,
 			want: &ast.Return{Values: []core.Expression{
-				&ast.Call{Name: `getID`, Args: []core.Expression{}},
+				&ast.Call{Name: `getID`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `getID`, Line: 1, Column: 8}},
 			}},
 		},
 		{
@@ -613,6 +1135,36 @@ func TestParser_parse_return(t *testing.T) {
 				&ast.Atom{Type: lx.Bool, Value: `true`},
 			}},
 		},
+		{
+			name:  `return list literal`,
+			input: `return [1], x`,
+			want: &ast.Return{Values: []core.Expression{
+				&ast.List{Elements: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}}},
+				&ast.Atom{Type: lx.Identifier, Value: `x`},
+			}},
+		},
+		{
+			name:  `return unary expressions`,
+			input: `return ~x, -x, !ok`,
+			want: &ast.Return{Values: []core.Expression{
+				&ast.Atom{Type: lx.Identifier, Value: `x`, Complemented: true},
+				&ast.Atom{Type: lx.Identifier, Value: `x`, Signed: true},
+				&ast.Atom{Type: lx.Identifier, Value: `ok`, Negated: true},
+			}},
+		},
+		{
+			name:  `return complemented call`,
+			input: `return ~mask(1)`,
+			want: &ast.Return{Values: []core.Expression{
+				&ast.Call{Name: `mask`, Args: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}}, Complemented: true,
+					Token: lx.Token{Type: lx.Identifier, Value: `mask`, Line: 1, Column: 9}},
+			}},
+		},
+		{
+			name:  `return nothing before a new line`,
+			input: "return\nx",
+			want:  &ast.Return{Values: []core.Expression{}},
+		},
 		{
 			name:        `invalid token in return`,
 			input:       `return 1, var`,
@@ -625,9 +1177,11 @@ func TestParser_parse_return(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_return(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_return()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_return() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -652,22 +1206,24 @@ func TestParser_parse_loop(t *testing.T) {
 					Operator: lx.Token{Type: lx.LessThan, Value: `<`, Line: 1, Column: 15},
 					Right: &ast.Call{
 						Args:   []core.Expression{},
-						Name:   `length`,
-						Object: `s`,
+						Callee: &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `s`}, Name: `length`},
+						Token:  lx.Token{Type: lx.LeftParenthesis, Value: `(`, Line: 1, Column: 25},
 					},
 				},
 				PostIteration: &ast.Assignment{
 					Identifier: `i`,
 					Value:      &ast.Operation{Type: lx.Increment},
 				},
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
 			},
 		},
 		{
 			name:  `empty for loop`,
 			input: ` for {}`,
 			want: &ast.ForLoop{
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 2},
 			},
 		},
 		{
@@ -683,18 +1239,76 @@ func TestParser_parse_loop(t *testing.T) {
 				Body: &ast.Block{
 					Statements: []core.Statement{
 						&ast.ForLoop{
-							Name: `inner`,
-							Body: &ast.Block{},
+							Name:  `inner`,
+							Body:  &ast.Block{},
+							Token: lx.Token{Type: lx.For, Value: `for`, Line: 5, Column: 5},
 						},
 					},
 				},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 3, Column: 4},
 			},
 		},
+		{
+			name: `break and continue`,
+			input: `for {
+				continue
+				break
+			}`,
+			want: &ast.ForLoop{
+				Body: &ast.Block{
+					Statements: []core.Statement{&ast.Continue{}, &ast.Break{}},
+				},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name: `labeled break and continue`,
+			input: `
+			outer:
+			for {
+				for _ in xs {
+					continue outer
+					break outer
+				}
+			}`,
+			want: &ast.ForLoop{
+				Name: `outer`,
+				Body: &ast.Block{
+					Statements: []core.Statement{
+						&ast.RangeLoop{
+							First:    `_`,
+							Iterable: &ast.Atom{Type: lx.Identifier, Value: `xs`},
+							Body: &ast.Block{
+								Statements: []core.Statement{&ast.Continue{Label: `outer`}, &ast.Break{Label: `outer`}},
+							},
+							Token: lx.Token{Type: lx.For, Value: `for`, Line: 4, Column: 5},
+						},
+					},
+				},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 3, Column: 4},
+			},
+		},
+		{
+			name: `break label of sibling loop`,
+			input: `
+			for {
+				first:
+				for {}
+				break first
+			}`,
+			shouldPanic: true,
+		},
+		{
+			name:        `break out of loop from lambda`,
+			input:       `for { func() { break }() }`,
+			shouldPanic: true,
+		},
 		{
 			name:  `empty for loop with semicolons`,
 			input: ` for ;; {}`,
 			want: &ast.ForLoop{
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 2},
 			},
 		},
 		{
@@ -706,7 +1320,8 @@ func TestParser_parse_loop(t *testing.T) {
 					Operator: lx.Token{Type: lx.Equal, Value: `==`, Line: 1, Column: 7},
 					Right:    &ast.Atom{Type: lx.Identifier, Value: `j`},
 				},
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
 			},
 		},
 		{
@@ -718,7 +1333,8 @@ func TestParser_parse_loop(t *testing.T) {
 					Operator: lx.Token{Type: lx.Equal, Value: `==`, Line: 1, Column: 8},
 					Right:    &ast.Atom{Type: lx.Identifier, Value: `j`},
 				},
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
 			},
 		},
 		{
@@ -731,14 +1347,28 @@ func TestParser_parse_loop(t *testing.T) {
 					Operator: lx.Token{Type: lx.GreaterThan, Value: `>`, Line: 1, Column: 14},
 					Right:    &ast.Atom{Type: lx.Int, Value: `4`},
 				},
-				Body: &ast.Block{},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
+			},
+		},
+		{
+			name:  `for loop with initial statement and comma, and condition`,
+			input: `for key, value := next(); key < 5; {}`,
+			want: &ast.ForLoop{
+				PreLoop: &ast.MultiAssignment{
+					Identifiers: []string{`key`, `value`},
+					Values:      []core.Expression{&ast.Call{Name: `next`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `next`, Line: 1, Column: 19}}},
+					Define:      true,
+				},
+				Condition: &ast.Binary{
+					Left:     &ast.Atom{Type: lx.Identifier, Value: `key`},
+					Operator: lx.Token{Type: lx.LessThan, Value: `<`, Line: 1, Column: 31},
+					Right:    &ast.Atom{Type: lx.Int, Value: `5`},
+				},
+				Body:  &ast.Block{},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
 			},
 		},
-		// {
-		// 	name:       `for loop with initial statement and comma, and condition`,
-		// 	input:      `for key, value := val; key < 5; {}`,
-		// 	want:       (TODO), TODO(TEST) this should be a valid statement but multivaraibale assignment must be a thing first
-		// },
 		{
 			name:        `for loop missing final semicolon`,
 			input:       ` for i = 0; i < 4 {}`,
@@ -750,6 +1380,7 @@ func TestParser_parse_loop(t *testing.T) {
 			want: &ast.ForLoop{
 				PreLoop: &ast.Assignment{Identifier: `i`, Value: &ast.Atom{Type: lx.Int, Value: `0`}},
 				Body:    &ast.Block{},
+				Token:   lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 2},
 			},
 		},
 		{
@@ -761,6 +1392,7 @@ func TestParser_parse_loop(t *testing.T) {
 					Identifier: `i`,
 					Value:      &ast.Operation{Type: lx.Increment},
 				},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 2},
 			},
 		},
 		{
@@ -775,6 +1407,7 @@ func TestParser_parse_loop(t *testing.T) {
 						},
 					},
 				},
+				Token: lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 2},
 			},
 		},
 		{
@@ -783,8 +1416,9 @@ func TestParser_parse_loop(t *testing.T) {
 			want: &ast.RangeLoop{
 				First:    `i`,
 				Second:   `elem`,
-				Iterable: `array`,
+				Iterable: &ast.Atom{Type: lx.Identifier, Value: `array`},
 				Body:     &ast.Block{},
+				Token:    lx.Token{Type: lx.For, Value: `for`, Line: 1, Column: 1},
 			},
 		},
 	}
@@ -794,9 +1428,11 @@ func TestParser_parse_loop(t *testing.T) {
 			if tt.shouldPanic {
 				defer expectPanic(t, nil)
 			}
-			if got := p.parse_loop(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_loop()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_loop() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -813,7 +1449,7 @@ func TestParser_parse_loop_range(t *testing.T) {
 			want: &ast.RangeLoop{
 				First:    `i`,
 				Second:   `elem`,
-				Iterable: `array`,
+				Iterable: &ast.Atom{Type: lx.Identifier, Value: `array`},
 				Body:     &ast.Block{},
 			},
 		},
@@ -822,7 +1458,7 @@ func TestParser_parse_loop_range(t *testing.T) {
 			input: `for i in array {}`,
 			want: &ast.RangeLoop{
 				First:    `i`,
-				Iterable: `array`,
+				Iterable: &ast.Atom{Type: lx.Identifier, Value: `array`},
 				Body:     &ast.Block{},
 			},
 		},
@@ -831,7 +1467,7 @@ func TestParser_parse_loop_range(t *testing.T) {
 			input: `for i in array { x++ }`,
 			want: &ast.RangeLoop{
 				First:    `i`,
-				Iterable: `array`,
+				Iterable: &ast.Atom{Type: lx.Identifier, Value: `array`},
 				Body: &ast.Block{
 					Statements: []core.Statement{
 						&ast.Assignment{
@@ -842,6 +1478,72 @@ func TestParser_parse_loop_range(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  `for range - discarded first var`,
+			input: `for _, v in getItems() {}`,
+			want: &ast.RangeLoop{
+				First:    `_`,
+				Second:   `v`,
+				Iterable: &ast.Call{Name: `getItems`, Args: []core.Expression{}, Token: lx.Token{Type: lx.Identifier, Value: `getItems`, Line: 1, Column: 13}},
+				Body:     &ast.Block{},
+			},
+		},
+		{
+			name:  `for range - discarded second var`,
+			input: `for k, _ in cfg {}`,
+			want: &ast.RangeLoop{
+				First:    `k`,
+				Second:   `_`,
+				Iterable: &ast.Atom{Type: lx.Identifier, Value: `cfg`},
+				Body:     &ast.Block{},
+			},
+		},
+		{
+			name:  `for range over field`,
+			input: `for k, v in cfg.entries {}`,
+			want: &ast.RangeLoop{
+				First:    `k`,
+				Second:   `v`,
+				Iterable: &ast.Selector{Object: &ast.Atom{Type: lx.Identifier, Value: `cfg`}, Name: `entries`},
+				Body:     &ast.Block{},
+			},
+		},
+		{
+			name:  `for range over list literal`,
+			input: `for i, n in [1, 2] {}`,
+			want: &ast.RangeLoop{
+				First:  `i`,
+				Second: `n`,
+				Iterable: &ast.List{Elements: []core.Expression{
+					&ast.Atom{Type: lx.Int, Value: `1`},
+					&ast.Atom{Type: lx.Int, Value: `2`},
+				}},
+				Body: &ast.Block{},
+			},
+		},
+		{
+			name:  `for range over map literal`,
+			input: `for k, v in ["a": 1] {}`,
+			want: &ast.RangeLoop{
+				First:  `k`,
+				Second: `v`,
+				Iterable: &ast.Map{
+					Keys:   []core.Expression{&ast.Atom{Type: lx.String, Value: `a`}},
+					Values: []core.Expression{&ast.Atom{Type: lx.Int, Value: `1`}},
+				},
+				Body: &ast.Block{},
+			},
+		},
+		{
+			name:  `for range over string literal`,
+			input: `for i, r in "hero" {}`,
+			want: &ast.RangeLoop{
+				First:    `i`,
+				Second:   `r`,
+				Iterable: &ast.Atom{Type: lx.String, Value: `hero`},
+				Body:     &ast.Block{},
+			},
+		},
 		{
 			name:       `for range comma with no second identifier`,
 			input:      `for i, in array {}`,
@@ -858,9 +1560,11 @@ func TestParser_parse_loop_range(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := New(tt.input)
-			if got := p.attempt_parse_range_loop(); !reflect.DeepEqual(got, tt.want) {
+			got := p.attempt_parse_range_loop()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.attempt_parse_range_loop() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 			if tt.want == nil && p.curr != tt.wantCursor {
 				t.Errorf("Parser.attempt_parse_range_loop() cursor = %d, Expected cursor at %d", p.curr, tt.wantCursor)
 			}
@@ -902,9 +1606,11 @@ func TestParser_parse_toplevel(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := New(tt.input)
-			if got := p.parse_toplevel(); !reflect.DeepEqual(got, tt.want) {
+			got := p.parse_toplevel()
+			if !sameTree(got, tt.want) {
 				t.Errorf("Parser.parse_toplevel() = %v, want %v", got, tt.want)
 			}
+			checkWalks(t, got)
 		})
 	}
 }
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+)
+
+// astPkg is the package of the node types
+var astPkg = reflect.TypeOf(ast.Block{}).PkgPath()
+
+// checkWalks reports an error if the walkers of the ast package miss
+// nodes in the parsed tree [got]. The parser tests check every tree
+// they parse so each node the parser produces is walked
+func checkWalks(t *testing.T, got interface{}) {
+	t.Helper()
+	if !walksAll(got) {
+		t.Errorf("walk missed nodes in %v", got)
+	}
+}
+
+// walksAll returns true if ast.Inspect visits and ast.Rewrite reaches
+// each node in the parsed tree [got] once
+func walksAll(got interface{}) bool {
+	v := reflect.ValueOf(got)
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			if !walksAll(v.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+	if !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+		return true
+	}
+
+	want := map[core.Node]int{}
+	nodes(v, want)
+
+	visited := map[core.Node]int{}
+	ast.Inspect(got, func(n core.Node) bool {
+		visited[n]++
+		return true
+	})
+	rewritten := map[core.Node]int{}
+	ast.Rewrite(got, func(n core.Node) core.Node {
+		rewritten[n]++
+		return n
+	})
+	return reflect.DeepEqual(visited, want) && reflect.DeepEqual(rewritten, want)
+}
+
+// nodes counts the pointers to nodes reachable from [v] in [found]
+func nodes(v reflect.Value, found map[core.Node]int) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if v.Elem().Kind() == reflect.Struct && v.Elem().Type().PkgPath() == astPkg {
+			found[v.Interface()]++
+		}
+		nodes(v.Elem(), found)
+	case reflect.Interface:
+		if !v.IsNil() {
+			nodes(v.Elem(), found)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			nodes(v.Index(i), found)
+		}
+	case reflect.Struct:
+		if v.Type().PkgPath() != astPkg {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			nodes(v.Field(i), found)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package resolver
+
+import (
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	lx "github.com/amupitan/hero/lexer"
+)
+
+// scope holds the names defined in a block
+type scope struct {
+	parent *scope
+	names  map[string]*Symbol
+
+	// function is set on the scope holding the
+	// parameters of a function
+	function *ast.Function
+
+	// end is the end of the block of the scope
+	end ast.Pos
+
+	// pending holds the uses in the scope of names that weren't
+	// defined when they were used e.g. calls to functions declared
+	// later. They are resolved when the scope is left
+	pending []Use
+}
+
+type resolver struct {
+	scope *scope
+	table *Table
+}
+
+// Resolve records the free variables of every function in
+// [program] on the function's Captures field. A variable is free
+// in a function if it is used in the function but defined in a
+// scope enclosing it. Variables used before they are defined are
+// not captured.
+//
+// The names defined in [program] and their uses are returned in
+// a symbol table
+func Resolve(program *ast.Program) *Table {
+	r := &resolver{table: &Table{}}
+	r.push(nil, ast.Pos{})
+	r.resolve_block(program.Body)
+	r.pop()
+	return r.table
+}
+
+// push enters a new scope ending at [end]. [f] is set
+// if the scope holds the parameters of a function
+func (r *resolver) push(f *ast.Function, end ast.Pos) {
+	r.scope = &scope{
+		parent:   r.scope,
+		names:    map[string]*Symbol{},
+		function: f,
+		end:      end,
+	}
+}
+
+// pop leaves the current scope. The pending uses of names
+// defined in the scope are resolved and the others are left
+// to the enclosing scope or recorded as unresolved
+func (r *resolver) pop() {
+	s := r.scope
+	for _, u := range s.pending {
+		if sym, ok := s.names[u.Name]; ok {
+			sym.References = append(sym.References, u.Pos)
+		} else if s.parent != nil {
+			s.parent.pending = append(s.parent.pending, u)
+		} else {
+			r.table.Unresolved = append(r.table.Unresolved, u)
+		}
+	}
+	r.scope = s.parent
+}
+
+// end returns the end of the block [b] or the end
+// of the current scope if there is no block
+func (r *resolver) end(b *ast.Block) ast.Pos {
+	if b == nil {
+		return r.scope.end
+	}
+	return b.End
+}
+
+// define adds a name of [kind] defined by [node] at [pos] to
+// the current scope
+func (r *resolver) define(name string, kind SymbolKind, pos ast.Pos, node core.Node) {
+	if name == `` || name == `_` {
+		return
+	}
+	sym := &Symbol{Name: name, Kind: kind, Pos: pos, End: r.scope.end, Node: node}
+	r.scope.names[name] = sym
+	r.table.Symbols = append(r.table.Symbols, sym)
+}
+
+// reference resolves a use of [name] at [pos]. Every function
+// between the use and the scope that defines [name] captures it
+func (r *resolver) reference(name string, pos ast.Pos) {
+	if name == `_` {
+		return
+	}
+	var def *scope
+	for s := r.scope; s != nil; s = s.parent {
+		if _, ok := s.names[name]; ok {
+			def = s
+			break
+		}
+	}
+
+	// names that aren't defined yet can't be captured
+	if def == nil {
+		if pos.IsValid() {
+			r.scope.pending = append(r.scope.pending, Use{Name: name, Pos: pos})
+		}
+		return
+	}
+	if pos.IsValid() {
+		sym := def.names[name]
+		sym.References = append(sym.References, pos)
+	}
+
+	for s := r.scope; s != def; s = s.parent {
+		if s.function != nil {
+			capture(s.function, name)
+		}
+	}
+}
+
+// capture adds [name] to the captures of [f] if it isn't there
+func capture(f *ast.Function, name string) {
+	for _, c := range f.Captures {
+		if c == name {
+			return
+		}
+	}
+	f.Captures = append(f.Captures, name)
+}
+
+// resolve_block resolves the statements of a block
+// in the current scope
+func (r *resolver) resolve_block(b *ast.Block) {
+	if b == nil {
+		return
+	}
+	for _, s := range b.Statements {
+		r.resolve_statement(s)
+	}
+}
+
+// resolve_statement resolves any statement
+func (r *resolver) resolve_statement(s core.Statement) {
+	switch stmt := s.(type) {
+	case *ast.Function:
+		// a function can refer to itself
+		if !stmt.Lambda {
+			r.define(stmt.Name, Function, stmt.Pos, stmt)
+		}
+		r.resolve_function(stmt)
+	case *ast.Definition:
+		if stmt.Value != nil {
+			r.resolve_expression(stmt.Value)
+		}
+		r.define(stmt.Name, Variable, stmt.Pos, stmt)
+	case *ast.MultiAssignment:
+		for _, v := range stmt.Values {
+			r.resolve_expression(v)
+		}
+		for i, name := range stmt.Identifiers {
+			var pos ast.Pos
+			if i < len(stmt.Positions) {
+				pos = stmt.Positions[i]
+			}
+			if stmt.Define {
+				r.define(name, Variable, pos, stmt)
+			} else {
+				r.reference(name, pos)
+			}
+		}
+	case *ast.Block:
+		r.push(nil, stmt.End)
+		r.resolve_block(stmt)
+		r.pop()
+	case *ast.If:
+		// inits counts the scopes of the variables defined
+		// before the conditions of the branches
+		inits := 0
+		last := stmt
+		for last.Else != nil {
+			last = last.Else
+		}
+		for branch := stmt; branch != nil; branch = branch.Else {
+			if branch.Definition != nil {
+				r.push(nil, r.end(last.Body))
+				inits++
+				r.resolve_statement(branch.Definition)
+			}
+			if branch.Condition != nil {
+				r.resolve_expression(branch.Condition)
+			}
+			r.push(nil, r.end(branch.Body))
+			r.resolve_block(branch.Body)
+			r.pop()
+		}
+		for ; inits > 0; inits-- {
+			r.pop()
+		}
+	case *ast.Switch:
+		r.resolve_expression(stmt.Value)
+		for _, c := range stmt.Cases {
+			for _, v := range c.Values {
+				r.resolve_expression(v)
+			}
+			r.push(nil, r.end(c.Body))
+			r.resolve_block(c.Body)
+			r.pop()
+		}
+		if stmt.Default != nil {
+			r.push(nil, r.end(stmt.Default))
+			r.resolve_block(stmt.Default)
+			r.pop()
+		}
+	case *ast.ForLoop:
+		r.push(nil, r.end(stmt.Body))
+		if stmt.PreLoop != nil {
+			r.resolve_statement(stmt.PreLoop)
+		}
+		if stmt.Condition != nil {
+			r.resolve_expression(stmt.Condition)
+		}
+		if stmt.PostIteration != nil {
+			r.resolve_expression(stmt.PostIteration)
+		}
+		r.push(nil, r.end(stmt.Body))
+		r.resolve_block(stmt.Body)
+		r.pop()
+		r.pop()
+	case *ast.RangeLoop:
+		r.resolve_expression(stmt.Iterable)
+		r.push(nil, r.end(stmt.Body))
+		r.define(stmt.First, Variable, stmt.FirstPos, stmt)
+		r.define(stmt.Second, Variable, stmt.SecondPos, stmt)
+		r.resolve_block(stmt.Body)
+		r.pop()
+	case *ast.Try:
+		r.push(nil, r.end(stmt.Body))
+		r.resolve_block(stmt.Body)
+		r.pop()
+		r.push(nil, r.end(stmt.Catch))
+		r.define(stmt.Name, Variable, stmt.NamePos, stmt)
+		r.resolve_block(stmt.Catch)
+		r.pop()
+	case *ast.Throw:
+		r.resolve_expression(stmt.Value)
+	case *ast.Defer:
+		r.resolve_expression(stmt.Call)
+	case *ast.Return:
+		for _, v := range stmt.Values {
+			r.resolve_expression(v)
+		}
+	case *ast.Interface:
+		// interfaces are only used in types so they
+		// are recorded without being defined
+		r.table.Symbols = append(r.table.Symbols, &Symbol{Name: stmt.Name, Kind: Interface, Pos: stmt.Pos, End: r.scope.end, Node: stmt})
+	case *ast.Break, *ast.Continue:
+	default:
+		r.resolve_expression(stmt)
+	}
+}
+
+// resolve_function resolves the body of a function in
+// a new scope holding its parameters
+func (r *resolver) resolve_function(f *ast.Function) {
+	// an empty slice marks the function as resolved
+	f.Captures = []string{}
+
+	r.push(f, r.end(f.Body))
+	for _, param := range f.Parameters {
+		// default values can refer to the parameters before them
+		if param.Default != nil {
+			r.resolve_expression(param.Default)
+		}
+		r.define(param.Name, Parameter, param.Pos, param)
+	}
+	r.resolve_block(f.Body)
+	r.pop()
+}
+
+// resolve_expression resolves any expression
+func (r *resolver) resolve_expression(e core.Expression) {
+	switch exp := e.(type) {
+	case *ast.Atom:
+		if exp.Type == lx.Identifier {
+			r.reference(exp.Value, exp.Pos)
+		}
+	case *ast.Binary:
+		r.resolve_expression(exp.Left)
+		r.resolve_expression(exp.Right)
+	case *ast.Conditional:
+		r.resolve_expression(exp.Condition)
+		r.resolve_expression(exp.Then)
+		r.resolve_expression(exp.Else)
+	case *ast.Assignment:
+		r.resolve_expression(exp.Value)
+		r.reference(exp.Identifier, exp.Pos)
+	case *ast.Operation:
+		if exp.Value != nil {
+			r.resolve_expression(exp.Value)
+		}
+	case *ast.Call:
+		switch {
+		case exp.Func != nil:
+			r.resolve_function(exp.Func)
+		case exp.Callee != nil:
+			r.resolve_expression(exp.Callee)
+		default:
+			r.reference(exp.Name, ast.PosOf(exp.Token))
+		}
+		for _, arg := range exp.Args {
+			r.resolve_expression(arg)
+		}
+		for _, arg := range exp.Named {
+			r.resolve_expression(arg.Value)
+		}
+	case *ast.Conversion:
+		r.resolve_expression(exp.Value)
+	case *ast.Range:
+		r.resolve_expression(exp.Start)
+		r.resolve_expression(exp.End)
+	case *ast.Function:
+		r.resolve_function(exp)
+	case *ast.List:
+		for _, el := range exp.Elements {
+			r.resolve_expression(el)
+		}
+	case *ast.Map:
+		for i := range exp.Keys {
+			r.resolve_expression(exp.Keys[i])
+			r.resolve_expression(exp.Values[i])
+		}
+	case *ast.Selector:
+		r.resolve_expression(exp.Object)
+	case *ast.Index:
+		r.resolve_expression(exp.Object)
+		r.resolve_expression(exp.Index)
+	}
+}
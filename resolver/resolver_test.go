@@ -0,0 +1,282 @@
+package resolver
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+	"github.com/amupitan/hero/parser"
+)
+
+// functions returns the functions in [s] in the order they
+// are found, including lambdas and nested functions
+func functions(s core.Statement) []*ast.Function {
+	var fns []*ast.Function
+	var find func(s core.Statement)
+	find = func(s core.Statement) {
+		switch n := s.(type) {
+		case *ast.Program:
+			find(n.Body)
+		case *ast.Block:
+			for _, stmt := range n.Statements {
+				find(stmt)
+			}
+		case *ast.Function:
+			fns = append(fns, n)
+			find(n.Body)
+		case *ast.Definition:
+			if n.Value != nil {
+				find(n.Value)
+			}
+		case *ast.Return:
+			for _, v := range n.Values {
+				find(v)
+			}
+		case *ast.Call:
+			if n.Func != nil {
+				find(n.Func)
+			}
+			for _, arg := range n.Args {
+				find(arg)
+			}
+		case *ast.ForLoop:
+			find(n.Body)
+		case *ast.RangeLoop:
+			find(n.Body)
+		}
+	}
+	find(s)
+	return fns
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// want holds the captures of each function
+		// in the order they appear
+		want [][]string
+	}{
+		{
+			name: `no free variables`,
+			input: `
+			func add(x, y int) int {
+				z := x + y
+				return z
+			}`,
+			want: [][]string{{}},
+		},
+		{
+			name: `lambda captures enclosing variables`,
+			input: `
+			func makeCounter() int {
+				count := 0
+				step := 1
+				return func() int {
+					count += step
+					return count
+				}
+			}`,
+			want: [][]string{{}, {`step`, `count`}},
+		},
+		{
+			name: `nested lambdas capture through the outer lambda`,
+			input: `
+			x := 1
+			f := func() {
+				g := func() int { return x }
+			}`,
+			want: [][]string{{`x`}, {`x`}},
+		},
+		{
+			name: `parameters and locals shadow outer variables`,
+			input: `
+			x := 1
+			f := func(x int) {
+				y := x
+				for i in [y] {
+					return i
+				}
+			}`,
+			want: [][]string{{}},
+		},
+		{
+			name: `destructured variables are captured`,
+			input: `
+			func outer() {
+				q, r := divmod(7, 2)
+				f := func() int { return q + r }
+			}`,
+			want: [][]string{{}, {`q`, `r`}},
+		},
+		{
+			name: `recursive function captures itself`,
+			input: `
+			func outer() {
+				func fib(n int) int {
+					if n < 2 {
+						return n
+					}
+					return fib(n - 1) + fib(n - 2)
+				}
+			}`,
+			want: [][]string{{}, {`fib`}},
+		},
+		{
+			name: `variables defined later are not captured`,
+			input: `
+			f := func() int { return later }
+			later := 1`,
+			want: [][]string{{}},
+		},
+		{
+			name: `lambda call and field access`,
+			input: `
+			cfg := ["port": 80]
+			func(offset int) int {
+				return cfg.port + offset
+			}(1)`,
+			want: [][]string{{`cfg`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program := parser.New(tt.input).Parse().Body.(*ast.Program)
+			Resolve(program)
+
+			var got [][]string
+			for _, f := range functions(program) {
+				got = append(got, f.Captures)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() captures = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve_symbols(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// want describes each symbol as its name, kind,
+		// position and the positions of its references
+		want []string
+	}{
+		{
+			name: `definitions and references`,
+			input: `x := 1
+func add(a, b int) int {
+	return a + b + x
+}
+add(x, 2)`,
+			want: []string{
+				`x variable 1:1 [3:17 5:5]`,
+				`add function 2:6 [5:1]`,
+				`a parameter 2:10 [3:9]`,
+				`b parameter 2:13 [3:13]`,
+			},
+		},
+		{
+			name: `functions used before they are declared`,
+			input: `func a() {
+	b()
+}
+func b() {}`,
+			want: []string{
+				`a function 1:6 []`,
+				`b function 4:6 [2:2]`,
+			},
+		},
+		{
+			name: `shadowed names`,
+			input: `x := 1
+for x in [x] {
+	x++
+}
+try {} catch err {
+	err = x
+}
+interface Number { int }`,
+			want: []string{
+				`x variable 1:1 [2:11 6:8]`,
+				`x variable 2:5 [3:2]`,
+				`err variable 5:14 [6:2]`,
+				`Number interface 8:11 []`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := Resolve(parser.New(tt.input).Parse().Body.(*ast.Program))
+
+			var got []string
+			for _, sym := range table.Symbols {
+				refs := make([]string, 0, len(sym.References))
+				for _, ref := range sym.References {
+					refs = append(refs, ref.String())
+				}
+				got = append(got, fmt.Sprintf(`%s %s %s %v`, sym.Name, sym.Kind, sym.Pos, refs))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() symbols = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve_unresolved(t *testing.T) {
+	input := `if x := 1; x > 0 {
+	println(x)
+}
+func f() int {
+	return y + x
+}
+y := x`
+	table := Resolve(parser.New(input).Parse().Body.(*ast.Program))
+
+	var got []string
+	for _, u := range table.Unresolved {
+		got = append(got, fmt.Sprintf(`%s %s`, u.Name, u.Pos))
+	}
+	want := []string{`println 2:2`, `x 5:13`, `x 7:6`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() unresolved = %q, want %q", got, want)
+	}
+}
+
+func TestTable(t *testing.T) {
+	input := `x := 1
+func f(y int) {
+	z := y
+}
+w := 2`
+	table := Resolve(parser.New(input).Parse().Body.(*ast.Program))
+
+	if sym := table.At(ast.Pos{Line: 3, Column: 7}); sym == nil || sym.Name != `y` || sym.Kind != Parameter {
+		t.Errorf("Table.At(3:7) = %v, want the parameter y", sym)
+	}
+	if sym := table.At(ast.Pos{Line: 3, Column: 4}); sym != nil {
+		t.Errorf("Table.At(3:4) = %v, want nil", sym)
+	}
+
+	tests := []struct {
+		pos  ast.Pos
+		want []string
+	}{
+		{pos: ast.Pos{Line: 3, Column: 8}, want: []string{`x`, `f`, `y`, `z`}},
+		{pos: ast.Pos{Line: 5, Column: 7}, want: []string{`x`, `f`, `w`}},
+		{pos: ast.Pos{Line: 1, Column: 1}, want: []string{`f`}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, sym := range table.Visible(tt.pos) {
+			got = append(got, sym.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Table.Visible(%s) = %v, want %v", tt.pos, got, tt.want)
+		}
+	}
+}
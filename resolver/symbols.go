@@ -0,0 +1,123 @@
+package resolver
+
+import (
+	"unicode/utf8"
+
+	"github.com/amupitan/hero/ast"
+	"github.com/amupitan/hero/ast/core"
+)
+
+// SymbolKind is the kind of thing a name refers to
+type SymbolKind int
+
+const (
+	Variable SymbolKind = iota
+	Function
+	Parameter
+	Interface
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case Function:
+		return `function`
+	case Parameter:
+		return `parameter`
+	case Interface:
+		return `interface`
+	}
+	return `variable`
+}
+
+// Symbol is a name defined in a program
+type Symbol struct {
+	Name string
+	Kind SymbolKind
+
+	// Pos is the position of the name where it is defined
+	Pos ast.Pos
+
+	// End is the end of the block the name is defined in. It is
+	// unknown for names defined at the top level of a program
+	End ast.Pos
+
+	// Node is the node defining the name. It is an *ast.Definition,
+	// *ast.MultiAssignment, *ast.Function, *ast.Param, *ast.RangeLoop,
+	// *ast.Try or *ast.Interface
+	Node core.Node
+
+	// References holds the positions of the uses of the name
+	References []ast.Pos
+}
+
+// Table holds the symbols of a program in the order they are defined
+type Table struct {
+	Symbols []*Symbol
+
+	// Unresolved holds the uses of names that aren't defined by
+	// the program where they are used e.g. builtins, names defined
+	// by the host or names used outside the block defining them
+	Unresolved []Use
+}
+
+// Use is a use of a name at Pos
+type Use struct {
+	Name string
+	Pos  ast.Pos
+}
+
+// At returns the symbol whose name is defined or used at [pos]
+// or nil if there is none. [pos] can be anywhere in the name
+func (t *Table) At(pos ast.Pos) *Symbol {
+	for _, sym := range t.Symbols {
+		if sym.spans(sym.Pos, pos) {
+			return sym
+		}
+		for _, ref := range sym.References {
+			if sym.spans(ref, pos) {
+				return sym
+			}
+		}
+	}
+	return nil
+}
+
+// Visible returns the symbols that can be used at [pos]: the names
+// defined before it in the blocks enclosing it and the functions
+// defined anywhere at the top level. Names shadowed at [pos] aren't
+// returned
+func (t *Table) Visible(pos ast.Pos) []*Symbol {
+	visible := map[string]*Symbol{}
+	var names []string
+	for _, sym := range t.Symbols {
+		if sym.Kind == Interface || !sym.encloses(pos) {
+			continue
+		}
+		hoisted := sym.Kind == Function && !sym.End.IsValid()
+		if !hoisted && !sym.Pos.Before(pos) {
+			continue
+		}
+		// later definitions in enclosing blocks shadow earlier ones
+		if _, ok := visible[sym.Name]; !ok {
+			names = append(names, sym.Name)
+		}
+		visible[sym.Name] = sym
+	}
+
+	symbols := make([]*Symbol, 0, len(names))
+	for _, name := range names {
+		symbols = append(symbols, visible[name])
+	}
+	return symbols
+}
+
+// spans returns true if the name of [s] at [start] spans [pos]
+func (s *Symbol) spans(start, pos ast.Pos) bool {
+	return start.IsValid() && start.Line == pos.Line &&
+		pos.Column >= start.Column && pos.Column < start.Column+utf8.RuneCountInString(s.Name)
+}
+
+// encloses returns true if [pos] is in the block [s] is defined in
+func (s *Symbol) encloses(pos ast.Pos) bool {
+	return !s.End.IsValid() || pos.Before(s.End)
+}
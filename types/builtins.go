@@ -20,6 +20,13 @@ var Bool = &builtin{
 	verify: func(value string) bool { return true },
 }
 
+// Error is the type of the errors thrown by throw
+// statements and failed operations
+var Error = &builtin{
+	name:   `error`,
+	verify: func(value string) bool { return true },
+}
+
 var Float = &builtin{
 	name:   `float`,
 	verify: func(value string) bool { return true },
@@ -49,3 +56,15 @@ var String = &builtin{
 	name:   `string`,
 	verify: func(value string) bool { return true },
 }
+
+// Builtins holds the builtin types by name
+var Builtins = map[string]Type{
+	`bool`:    Bool,
+	`error`:   Error,
+	`float`:   Float,
+	`func`:    Func,
+	`generic`: Generic,
+	`int`:     Int,
+	`rune`:    Rune,
+	`string`:  String,
+}
@@ -0,0 +1,27 @@
+package types
+
+// Capability is a group of builtin functions that reach outside
+// the program. Hosts choose the capabilities a program can use
+type Capability string
+
+const (
+	FS     Capability = `fs`
+	Env    Capability = `env`
+	Time   Capability = `time`
+	Random Capability = `random`
+	Stdout Capability = `stdout`
+)
+
+// Capabilities holds the capabilities needed by the builtin
+// functions by name. Functions that aren't in it need none
+var Capabilities = map[string]Capability{
+	`print`:      Stdout,
+	`println`:    Stdout,
+	`read_file`:  FS,
+	`write_file`: FS,
+	`getenv`:     Env,
+	`now`:        Time,
+	`sleep`:      Time,
+	`random`:     Random,
+	`random_int`: Random,
+}
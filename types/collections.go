@@ -0,0 +1,28 @@
+package types
+
+// List is the type of lists whose elements are of type Elem e.g. list[int]
+type List struct {
+	Elem Type
+}
+
+func (l *List) String() string {
+	return `list[` + l.Elem.String() + `]`
+}
+
+func (l *List) IsType(value string) bool {
+	return true
+}
+
+// Map is the type of maps from Key to Value e.g. map[string]int
+type Map struct {
+	Key   Type
+	Value Type
+}
+
+func (m *Map) String() string {
+	return `map[` + m.Key.String() + `]` + m.Value.String()
+}
+
+func (m *Map) IsType(value string) bool {
+	return true
+}
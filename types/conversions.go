@@ -0,0 +1,26 @@
+package types
+
+// convertible holds the builtin types whose values
+// can be converted to each builtin type
+var convertible = map[Type][]Type{
+	Bool:   {Bool, String},
+	Error:  {Error, String},
+	Float:  {Float, Int, Rune, String},
+	Int:    {Float, Int, Rune, String},
+	Rune:   {Int, Rune, String},
+	String: {Bool, Float, Int, Rune, String},
+}
+
+// Convertible returns true if a value of type [from] can be
+// converted to [to]. Anything can be converted to generic
+func Convertible(from, to Type) bool {
+	if to == Generic {
+		return true
+	}
+	for _, t := range convertible[to] {
+		if t == from {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package types
+
+// number is satisfied by the numeric types
+var number = &Interface{
+	Name:  `number`,
+	Types: []Type{Float, Int},
+}
+
+// Functions holds the signatures of the builtin functions by name.
+// The functions are implemented by the evaluator
+var Functions = map[string]*Signature{
+	// output
+	`print`:   variadic(nil, Param{`values`, Generic}),
+	`println`: variadic(nil, Param{`values`, Generic}),
+
+	// collections
+	`len`: fixed(Int, Param{`v`, Generic}),
+	`append`: generic(Generic, func(t Type) *Signature {
+		return variadic(&List{Elem: t}, Param{`xs`, &List{Elem: t}}, Param{`items`, t})
+	}),
+
+	// strings
+	`split`:    fixed(&List{Elem: String}, Param{`s`, String}, Param{`sep`, String}),
+	`join`:     fixed(String, Param{`xs`, &List{Elem: String}}, Param{`sep`, String}),
+	`contains`: fixed(Bool, Param{`s`, String}, Param{`sub`, String}),
+	`upper`:    fixed(String, Param{`s`, String}),
+	`lower`:    fixed(String, Param{`s`, String}),
+	`trim`:     fixed(String, Param{`s`, String}),
+
+	// math
+	`abs`:   generic(number, func(t Type) *Signature { return fixed(t, Param{`x`, t}) }),
+	`sqrt`:  fixed(Float, Param{`x`, Float}),
+	`pow`:   fixed(Float, Param{`x`, Float}, Param{`y`, Float}),
+	`floor`: fixed(Float, Param{`x`, Float}),
+	`min`:   generic(Ordered, func(t Type) *Signature { return variadic(t, Param{`x`, t}, Param{`rest`, t}) }),
+	`max`:   generic(Ordered, func(t Type) *Signature { return variadic(t, Param{`x`, t}, Param{`rest`, t}) }),
+
+	// conversions
+	`str`:         fixed(String, Param{`v`, Generic}),
+	`parse_int`:   fixed(Int, Param{`s`, String}),
+	`parse_float`: fixed(Float, Param{`s`, String}),
+
+	// files
+	`read_file`:  fixed(String, Param{`path`, String}),
+	`write_file`: fixed(nil, Param{`path`, String}, Param{`data`, String}),
+
+	// environment
+	`getenv`: fixed(String, Param{`name`, String}),
+
+	// time in milliseconds
+	`now`:   fixed(Int),
+	`sleep`: fixed(nil, Param{`ms`, Int}),
+
+	// random numbers
+	`random`:     fixed(Float),
+	`random_int`: fixed(Int, Param{`n`, Int}),
+}
+
+// fixed returns the signature of a function taking [params] and
+// returning a value of type [result] if it is set
+func fixed(result Type, params ...Param) *Signature {
+	s := &Signature{Params: params}
+	if result != nil {
+		s.Returns = []Type{result}
+	}
+	return s
+}
+
+// variadic returns the signature of a function whose final
+// parameter is variadic
+func variadic(result Type, params ...Param) *Signature {
+	s := fixed(result, params...)
+	s.Variadic = true
+	return s
+}
+
+// generic returns the signature made by [f] from a type
+// parameter T constrained by [constraint]
+func generic(constraint Type, f func(t Type) *Signature) *Signature {
+	t := &TypeParam{Name: `T`, Constraint: constraint}
+	s := f(t)
+	s.TypeParams = []*TypeParam{t}
+	return s
+}
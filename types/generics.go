@@ -0,0 +1,79 @@
+package types
+
+// TypeParam is a type parameter of a generic function e.g. T in
+// func first<T>(xs list[T]) T. The type it stands for in a call
+// must satisfy its Constraint
+type TypeParam struct {
+	Name       string
+	Constraint Type
+}
+
+func (t *TypeParam) String() string {
+	return t.Name
+}
+
+func (t *TypeParam) IsType(value string) bool {
+	return true
+}
+
+// Interface is a constraint satisfied by the types it lists
+// e.g. interface Number { int | float }
+type Interface struct {
+	Name  string
+	Types []Type
+}
+
+func (i *Interface) String() string {
+	return i.Name
+}
+
+func (i *Interface) IsType(value string) bool {
+	return true
+}
+
+// Satisfies returns true if [t] satisfies the constraint [c]. Every
+// type satisfies the generic constraint and an interface that lists
+// no types. Other interfaces are satisfied by the types they list
+func Satisfies(t, c Type) bool {
+	if c == Generic {
+		return true
+	}
+	i, ok := c.(*Interface)
+	if !ok {
+		return false
+	}
+	if len(i.Types) == 0 {
+		return true
+	}
+	for _, member := range i.Types {
+		if Equal(t, member) {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal returns true if [a] and [b] are the same type
+func Equal(a, b Type) bool {
+	return a.String() == b.String()
+}
+
+// Comparable is satisfied by the types whose values can be compared with ==
+var Comparable = &Interface{
+	Name:  `comparable`,
+	Types: []Type{Bool, Float, Int, Rune, String},
+}
+
+// Ordered is satisfied by the types whose values can be ordered with <
+var Ordered = &Interface{
+	Name:  `ordered`,
+	Types: []Type{Float, Int, Rune, String},
+}
+
+// Constraints holds the builtin constraints by name. The generic
+// constraint is satisfied by every type
+var Constraints = map[string]Type{
+	`comparable`: Comparable,
+	`generic`:    Generic,
+	`ordered`:    Ordered,
+}
@@ -0,0 +1,14 @@
+package types
+
+// Nullable is a type whose values can also be null e.g. string?
+type Nullable struct {
+	Type Type
+}
+
+func (n *Nullable) String() string {
+	return n.Type.String() + `?`
+}
+
+func (n *Nullable) IsType(value string) bool {
+	return value == `null` || n.Type.IsType(value)
+}
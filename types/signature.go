@@ -0,0 +1,67 @@
+package types
+
+import "strings"
+
+// Param is a named parameter of a function signature
+type Param struct {
+	Name string
+	Type Type
+}
+
+// Signature is the type of a function implemented outside hero
+// e.g. a builtin function or of a function value written with its
+// signature e.g. func(int) bool. The final parameter takes zero or
+// more arguments if Variadic is set
+type Signature struct {
+	TypeParams []*TypeParam
+	Params     []Param
+	Variadic   bool
+	Returns    []Type
+}
+
+func (s *Signature) String() string {
+	b := strings.Builder{}
+	b.WriteString(`func`)
+	if len(s.TypeParams) > 0 {
+		params := make([]string, 0, len(s.TypeParams))
+		for _, t := range s.TypeParams {
+			if t.Constraint == Generic {
+				params = append(params, t.Name)
+			} else {
+				params = append(params, t.Name+` `+t.Constraint.String())
+			}
+		}
+		b.WriteString(`<` + strings.Join(params, `, `) + `>`)
+	}
+
+	// the parameters of function types have no names e.g. func(int)
+	params := make([]string, 0, len(s.Params))
+	for i, p := range s.Params {
+		param := p.Type.String()
+		if s.Variadic && i == len(s.Params)-1 {
+			param = `...` + param
+		}
+		if p.Name != `` {
+			param = p.Name + ` ` + param
+		}
+		params = append(params, param)
+	}
+	b.WriteString(`(` + strings.Join(params, `, `) + `)`)
+
+	switch len(s.Returns) {
+	case 0:
+	case 1:
+		b.WriteString(` ` + s.Returns[0].String())
+	default:
+		returns := make([]string, 0, len(s.Returns))
+		for _, t := range s.Returns {
+			returns = append(returns, t.String())
+		}
+		b.WriteString(` (` + strings.Join(returns, `, `) + `)`)
+	}
+	return b.String()
+}
+
+func (s *Signature) IsType(value string) bool {
+	return true
+}